@@ -16,6 +16,10 @@ import (
 type Provider interface {
 	Get(ctx context.Context, opts *options.Options) (imagedata.ImageData, http.Header, error)
 
+	// Has checks if the provider has an image for the given options
+	// without actually retrieving it.
+	Has(opts *options.Options) bool
+
 	// Close releases the image data held by the provider.
 	Close() error
 }
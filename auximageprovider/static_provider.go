@@ -22,6 +22,11 @@ func (s *staticProvider) Get(_ context.Context, _ *options.Options) (imagedata.I
 	return s.data.Ref(), s.headers.Clone(), nil
 }
 
+// Has returns true since the static provider always has the image.
+func (s *staticProvider) Has(_ *options.Options) bool {
+	return true
+}
+
 // Close releases the static image data held by the provider.
 func (s *staticProvider) Close() error {
 	return s.data.Close()
@@ -0,0 +1,42 @@
+package auximageprovider
+
+import (
+	"errors"
+
+	"github.com/imgproxy/imgproxy/v4/ensure"
+	"github.com/imgproxy/imgproxy/v4/env"
+)
+
+var IMGPROXY_WATERMARKS_CACHE_MAX_SIZE = env.Int("IMGPROXY_WATERMARKS_CACHE_MAX_SIZE")
+
+// URLConfig holds the configuration for the URL auxiliary image provider
+type URLConfig struct {
+	CacheMaxSize int // Maximum total size of cached images in bytes, 0 disables caching
+}
+
+// NewDefaultURLConfig creates a new default configuration for the URL auxiliary image provider
+func NewDefaultURLConfig() URLConfig {
+	return URLConfig{
+		CacheMaxSize: 64 * 1024 * 1024,
+	}
+}
+
+// LoadWatermarkURLConfigFromEnv loads the watermark URL provider configuration from the environment
+func LoadWatermarkURLConfigFromEnv(c *URLConfig) (*URLConfig, error) {
+	c = ensure.Ensure(c, NewDefaultURLConfig)
+
+	err := errors.Join(
+		IMGPROXY_WATERMARKS_CACHE_MAX_SIZE.Parse(&c.CacheMaxSize),
+	)
+
+	return c, err
+}
+
+// Validate checks the configuration values
+func (c *URLConfig) Validate() error {
+	if c.CacheMaxSize < 0 {
+		return IMGPROXY_WATERMARKS_CACHE_MAX_SIZE.ErrorNegative()
+	}
+
+	return nil
+}
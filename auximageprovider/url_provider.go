@@ -0,0 +1,192 @@
+package auximageprovider
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/imgproxy/imgproxy/v4/coalescing"
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/security"
+)
+
+// urlCacheItem is a cached image
+type urlCacheItem struct {
+	url  string
+	data imagedata.ImageData
+	size int
+}
+
+// urlProvider is an implementation of Provider, which downloads the image
+// from the URL specified in the options. Downloaded images are cached in memory,
+// the least recently used images are evicted when the total size of the cached images
+// exceeds the limit.
+// If the options don't specify the URL, it falls back to the base provider.
+type urlProvider struct {
+	config   *URLConfig
+	key      string
+	desc     string
+	base     Provider
+	idf      *imagedata.Factory
+	security *security.Checker
+
+	// Concurrent downloads of the same image are coalesced
+	downloads *coalescing.Group[imagedata.ImageData]
+
+	mu    sync.Mutex
+	lru   *list.List               // LRU list, the front is the most recently used item
+	items map[string]*list.Element // Items by URL
+	size  int                      // Total size of the cached images
+}
+
+// NewURLProvider creates a new Provider that downloads the image from the URL
+// stored in the options by the given key.
+// The base provider is used when the options don't specify the URL. It may be nil.
+func NewURLProvider(
+	c *URLConfig,
+	key, desc string,
+	base Provider,
+	idf *imagedata.Factory,
+	securityChecker *security.Checker,
+) (Provider, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &urlProvider{
+		config:    c,
+		key:       key,
+		desc:      desc,
+		base:      base,
+		idf:       idf,
+		security:  securityChecker,
+		downloads: coalescing.NewGroup[imagedata.ImageData](),
+		lru:       list.New(),
+		items:     make(map[string]*list.Element),
+	}, nil
+}
+
+// Get returns the image downloaded from the URL specified in the options
+// or the base provider image if the URL is not specified.
+func (p *urlProvider) Get(
+	ctx context.Context,
+	opts *options.Options,
+) (imagedata.ImageData, http.Header, error) {
+	imageURL := opts.GetString(p.key, "")
+	if len(imageURL) == 0 {
+		if p.base == nil {
+			return nil, nil, nil
+		}
+
+		return p.base.Get(ctx, opts)
+	}
+
+	// Verify the URL before looking it up in the cache
+	// since the allowed sources could have changed
+	if err := p.security.VerifySourceURL(imageURL); err != nil {
+		return nil, nil, err
+	}
+
+	if data := p.getCached(imageURL); data != nil {
+		return data, make(http.Header), nil
+	}
+
+	data, err := p.downloads.Do(ctx, imageURL, func() (imagedata.ImageData, error) {
+		data, _, err := p.idf.DownloadSync(ctx, imageURL, p.desc, imagedata.DownloadOptions{
+			MaxSrcFileSize: p.security.MaxSrcFileSize(opts),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		p.cache(imageURL, data)
+
+		return data, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, make(http.Header), nil
+}
+
+// Has checks if the options specify the URL or the base provider has an image
+func (p *urlProvider) Has(opts *options.Options) bool {
+	if len(opts.GetString(p.key, "")) > 0 {
+		return true
+	}
+
+	return p.base != nil && p.base.Has(opts)
+}
+
+// Close releases the cached images and the base provider
+func (p *urlProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for el := p.lru.Front(); el != nil; el = el.Next() {
+		el.Value.(*urlCacheItem).data.Close() //nolint:forcetypeassert
+	}
+
+	p.lru.Init()
+	clear(p.items)
+	p.size = 0
+
+	if p.base != nil {
+		return p.base.Close()
+	}
+
+	return nil
+}
+
+// getCached returns a new reference to the cached image if any
+func (p *urlProvider) getCached(imageURL string) imagedata.ImageData {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	el, ok := p.items[imageURL]
+	if !ok {
+		return nil
+	}
+
+	p.lru.MoveToFront(el)
+
+	return el.Value.(*urlCacheItem).data.Ref() //nolint:forcetypeassert
+}
+
+// cache stores a reference to the image in the cache
+// and evicts the least recently used images if the cache is full
+func (p *urlProvider) cache(imageURL string, data imagedata.ImageData) {
+	if p.config.CacheMaxSize == 0 {
+		return
+	}
+
+	size, err := data.Size()
+	// No sense to cache images that don't fit the cache at all
+	if err != nil || size > p.config.CacheMaxSize {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.items[imageURL]; ok {
+		return
+	}
+
+	p.items[imageURL] = p.lru.PushFront(&urlCacheItem{url: imageURL, data: data.Ref(), size: size})
+	p.size += size
+
+	for p.size > p.config.CacheMaxSize {
+		el := p.lru.Back()
+		it := el.Value.(*urlCacheItem) //nolint:forcetypeassert
+
+		p.lru.Remove(el)
+		delete(p.items, it.url)
+		p.size -= it.size
+
+		it.data.Close()
+	}
+}
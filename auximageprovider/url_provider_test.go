@@ -0,0 +1,245 @@
+package auximageprovider_test
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/auximageprovider"
+	"github.com/imgproxy/imgproxy/v4/fetcher"
+	"github.com/imgproxy/imgproxy/v4/httpheaders"
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/security"
+	"github.com/imgproxy/imgproxy/v4/testutil"
+)
+
+const testURLKey = "test_url"
+
+type URLProviderTestSuite struct {
+	testutil.LazySuite
+
+	testData []byte
+	hits     atomic.Int32
+
+	testServer testutil.LazyTestServer
+	idf        *imagedata.Factory
+
+	config         testutil.LazyObj[*auximageprovider.URLConfig]
+	securityConfig testutil.LazyObj[*security.Config]
+	base           testutil.LazyObj[auximageprovider.Provider]
+	provider       testutil.LazyObj[auximageprovider.Provider]
+}
+
+func (s *URLProviderTestSuite) SetupSuite() {
+	s.testData = testutil.NewTestDataProvider(s.T).Read("test1.jpg")
+
+	fc := fetcher.NewDefaultConfig()
+	fc.Transport.HTTP.AllowLoopbackSourceAddresses = true
+
+	f, err := fetcher.New(&fc)
+	s.Require().NoError(err)
+
+	s.idf = imagedata.NewFactory(f, nil)
+
+	s.testServer, _ = testutil.NewLazySuiteTestServer(
+		s,
+		func(srv *testutil.TestServer) error {
+			srv.SetHeaders(
+				httpheaders.ContentType, "image/jpeg",
+				httpheaders.ContentLength, strconv.Itoa(len(s.testData)),
+			).SetBody(s.testData).SetHook(func(_ *http.Request, _ http.ResponseWriter) {
+				s.hits.Add(1)
+			})
+
+			return nil
+		},
+	)
+
+	s.config, _ = testutil.NewLazySuiteObj(
+		s,
+		func() (*auximageprovider.URLConfig, error) {
+			c := auximageprovider.NewDefaultURLConfig()
+			return &c, nil
+		},
+	)
+
+	s.securityConfig, _ = testutil.NewLazySuiteObj(
+		s,
+		func() (*security.Config, error) {
+			c := security.NewDefaultConfig()
+			return &c, nil
+		},
+	)
+
+	s.base, _ = testutil.NewLazySuiteObj(
+		s,
+		func() (auximageprovider.Provider, error) {
+			return auximageprovider.NewStaticProvider(
+				s.T().Context(),
+				&auximageprovider.StaticConfig{
+					Path: testutil.NewTestDataProvider(s.T).Path("test2.jpg"),
+				},
+				"base image",
+				s.idf,
+			)
+		},
+	)
+
+	s.provider, _ = testutil.NewLazySuiteObj(
+		s,
+		func() (auximageprovider.Provider, error) {
+			sc, err := security.New(s.securityConfig())
+			if err != nil {
+				return nil, err
+			}
+
+			return auximageprovider.NewURLProvider(
+				s.config(), testURLKey, "test image", s.base(), s.idf, sc,
+			)
+		},
+		func(p auximageprovider.Provider) error {
+			return p.Close()
+		},
+	)
+}
+
+func (s *URLProviderTestSuite) SetupTest() {
+	s.hits.Store(0)
+}
+
+// get gets the image from the provider for the given URL and reads it
+func (s *URLProviderTestSuite) get(imageURL string) ([]byte, error) {
+	o := options.New()
+	if len(imageURL) > 0 {
+		o.Set(testURLKey, imageURL)
+	}
+
+	imgData, _, err := s.provider().Get(s.T().Context(), o)
+	if err != nil {
+		return nil, err
+	}
+	defer imgData.Close()
+
+	return io.ReadAll(imgData.Reader())
+}
+
+func (s *URLProviderTestSuite) TestGet() {
+	data, err := s.get(s.testServer().URL())
+	s.Require().NoError(err)
+	s.Require().Equal(s.testData, data)
+}
+
+func (s *URLProviderTestSuite) TestFallbackToBase() {
+	data, err := s.get("")
+	s.Require().NoError(err)
+	s.Require().Equal(testutil.NewTestDataProvider(s.T).Read("test2.jpg"), data)
+	s.Require().Zero(s.hits.Load())
+}
+
+func (s *URLProviderTestSuite) TestHas() {
+	o := options.New()
+	s.Require().True(s.provider().Has(o), "base provider should have the image")
+
+	sc, err := security.New(s.securityConfig())
+	s.Require().NoError(err)
+
+	p, err := auximageprovider.NewURLProvider(s.config(), testURLKey, "test image", nil, s.idf, sc)
+	s.Require().NoError(err)
+	defer p.Close()
+
+	s.Require().False(p.Has(o), "no URL and no base provider")
+
+	o.Set(testURLKey, s.testServer().URL())
+	s.Require().True(p.Has(o), "URL is specified")
+
+	// Has should not download the image
+	s.Require().Zero(s.hits.Load())
+}
+
+func (s *URLProviderTestSuite) TestCache() {
+	for range 3 {
+		data, err := s.get(s.testServer().URL())
+		s.Require().NoError(err)
+		s.Require().Equal(s.testData, data)
+	}
+
+	s.Require().EqualValues(1, s.hits.Load())
+}
+
+func (s *URLProviderTestSuite) TestCacheEviction() {
+	// The cache fits only one image
+	s.config().CacheMaxSize = len(s.testData)*2 - 1
+
+	url1 := s.testServer().URL() + "/1.jpg"
+	url2 := s.testServer().URL() + "/2.jpg"
+
+	for _, u := range []string{url1, url2, url1} {
+		_, err := s.get(u)
+		s.Require().NoError(err)
+	}
+
+	s.Require().EqualValues(3, s.hits.Load())
+}
+
+func (s *URLProviderTestSuite) TestCacheTooLargeImage() {
+	s.config().CacheMaxSize = len(s.testData) - 1
+
+	for range 2 {
+		_, err := s.get(s.testServer().URL())
+		s.Require().NoError(err)
+	}
+
+	s.Require().EqualValues(2, s.hits.Load())
+}
+
+func (s *URLProviderTestSuite) TestCacheDisabled() {
+	s.config().CacheMaxSize = 0
+
+	for range 2 {
+		_, err := s.get(s.testServer().URL())
+		s.Require().NoError(err)
+	}
+
+	s.Require().EqualValues(2, s.hits.Load())
+}
+
+func (s *URLProviderTestSuite) TestSourceNotAllowed() {
+	s.securityConfig().AllowedSources = []*regexp.Regexp{
+		regexp.MustCompile("^https://allowed.dev/"),
+	}
+
+	_, err := s.get(s.testServer().URL())
+	s.Require().Error(err)
+	s.Require().Zero(s.hits.Load())
+}
+
+func (s *URLProviderTestSuite) TestCachedSourceNotAllowed() {
+	_, err := s.get(s.testServer().URL())
+	s.Require().NoError(err)
+
+	// The cached image should not be returned if the source is not allowed anymore
+	s.securityConfig().AllowedSources = []*regexp.Regexp{
+		regexp.MustCompile("^https://allowed.dev/"),
+	}
+
+	_, err = s.get(s.testServer().URL())
+	s.Require().Error(err)
+	s.Require().EqualValues(1, s.hits.Load())
+}
+
+func (s *URLProviderTestSuite) TestMaxSrcFileSize() {
+	s.securityConfig().MaxSrcFileSize = 1
+
+	_, err := s.get(s.testServer().URL())
+	s.Require().Error(err)
+}
+
+func TestURLProvider(t *testing.T) {
+	suite.Run(t, new(URLProviderTestSuite))
+}
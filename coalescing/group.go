@@ -0,0 +1,155 @@
+package coalescing
+
+import (
+	"context"
+	"sync"
+)
+
+// Shareable is a value that can be shared between several callers.
+// Every caller gets its own reference and must close it when done.
+type Shareable[T any] interface {
+	Ref() T       // Ref returns a new reference to the same value
+	Close() error // Close releases the reference
+}
+
+// Group coalesces concurrent calls with the same key.
+// Only the first call (the leader) is executed while the calls made
+// during its execution (the followers) wait for it and share its result.
+type Group[T Shareable[T]] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// call represents an in-flight or completed leader call
+type call[T Shareable[T]] struct {
+	done chan struct{} // Closed when the leader call is completed
+
+	val   T     // Leader call result
+	err   error // Leader call error
+	retry bool  // Whether the followers should retry instead of using the result
+
+	waiters int  // Number of followers waiting for the result
+	holding bool // Whether the call holds a reference to val for the followers
+}
+
+// NewGroup creates a new Group instance
+func NewGroup[T Shareable[T]]() *Group[T] {
+	return &Group[T]{
+		calls: make(map[string]*call[T]),
+	}
+}
+
+// Do executes fn and returns its result.
+// If there is a call with the same key in progress, Do waits for it
+// and returns a new reference to its result or its error instead.
+//
+// If the leader call fails while its context is done (e.g. the leader request
+// was canceled or timed out), the error is not shared. Instead, the followers retry,
+// and one of them becomes a new leader.
+//
+// If ctx is done while waiting for the leader call, Do returns ctx.Err().
+func (g *Group[T]) Do(ctx context.Context, key string, fn func() (T, error)) (T, error) {
+	for {
+		g.mu.Lock()
+
+		c, ok := g.calls[key]
+		if !ok {
+			c = &call[T]{done: make(chan struct{})}
+			g.calls[key] = c
+			g.mu.Unlock()
+
+			return g.lead(ctx, key, c, fn)
+		}
+
+		c.waiters++
+		g.mu.Unlock()
+
+		val, retry, err := g.follow(ctx, c)
+		if !retry {
+			return val, err
+		}
+	}
+}
+
+// lead executes the leader call and publishes its result to the followers
+func (g *Group[T]) lead(ctx context.Context, key string, c *call[T], fn func() (T, error)) (T, error) {
+	completed := false
+
+	// If fn panics, let the followers retry
+	defer func() {
+		if !completed {
+			var zero T
+			g.complete(key, c, zero, nil, true)
+		}
+	}()
+
+	val, err := fn()
+	completed = true
+
+	g.complete(key, c, val, err, err != nil && ctx.Err() != nil)
+
+	return val, err
+}
+
+// complete stores the leader call result and wakes the followers up
+func (g *Group[T]) complete(key string, c *call[T], val T, err error, retry bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.calls, key)
+
+	c.err = err
+	c.retry = retry
+
+	// Take a reference for the followers so the leader can close its own one
+	// before the followers wake up
+	if !retry && err == nil && c.waiters > 0 {
+		c.val = val.Ref()
+		c.holding = true
+	}
+
+	close(c.done)
+}
+
+// follow waits for the leader call and returns a new reference to its result.
+// It returns true if the leader result can't be shared and the call should be retried.
+func (g *Group[T]) follow(ctx context.Context, c *call[T]) (T, bool, error) {
+	var zero T
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		g.leave(c)
+
+		return zero, false, ctx.Err()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	defer g.leave(c)
+
+	switch {
+	case c.retry:
+		return zero, true, nil
+	case c.err != nil:
+		return zero, false, c.err
+	default:
+		return c.val.Ref(), false, nil
+	}
+}
+
+// leave removes a follower from the call and releases the shared result
+// when there are no more followers.
+// It should be called with the lock held.
+func (g *Group[T]) leave(c *call[T]) {
+	c.waiters--
+
+	if c.waiters == 0 && c.holding {
+		c.holding = false
+		c.val.Close()
+	}
+}
@@ -0,0 +1,306 @@
+package coalescing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// testValue is a refcounted value for testing
+type testValue struct {
+	data     string
+	refCount *atomic.Int32
+}
+
+func newTestValue(data string) *testValue {
+	v := &testValue{data: data, refCount: new(atomic.Int32)}
+	v.refCount.Store(1)
+
+	return v
+}
+
+func (v *testValue) Ref() *testValue {
+	v.refCount.Add(1)
+	return v
+}
+
+func (v *testValue) Close() error {
+	if v.refCount.Add(-1) < 0 {
+		panic("testValue: already closed")
+	}
+
+	return nil
+}
+
+type GroupTestSuite struct {
+	suite.Suite
+
+	group *Group[*testValue]
+}
+
+func (s *GroupTestSuite) SetupTest() {
+	s.group = NewGroup[*testValue]()
+}
+
+// waitFollowers waits until the call with the key has n followers
+func (s *GroupTestSuite) waitFollowers(key string, n int) {
+	s.Require().Eventually(func() bool {
+		s.group.mu.Lock()
+		defer s.group.mu.Unlock()
+
+		c, ok := s.group.calls[key]
+		return ok && c.waiters == n
+	}, time.Second, time.Millisecond)
+}
+
+// runFollowers starts n calls that should become followers of the in-flight call
+func (s *GroupTestSuite) runFollowers(
+	ctx context.Context,
+	key string,
+	n int,
+	fn func() (*testValue, error),
+) ([]*testValue, []error, *sync.WaitGroup) {
+	vals := make([]*testValue, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+
+	for i := range n {
+		wg.Go(func() {
+			vals[i], errs[i] = s.group.Do(ctx, key, fn)
+		})
+	}
+
+	s.waitFollowers(key, n)
+
+	return vals, errs, &wg
+}
+
+func (s *GroupTestSuite) TestDo() {
+	val, err := s.group.Do(s.T().Context(), "key", func() (*testValue, error) {
+		return newTestValue("test"), nil
+	})
+	s.Require().NoError(err)
+	s.Require().Equal("test", val.data)
+	s.Require().EqualValues(1, val.refCount.Load())
+
+	s.Require().Empty(s.group.calls)
+}
+
+func (s *GroupTestSuite) TestCoalesce() {
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+	fn := func() (*testValue, error) {
+		calls.Add(1)
+		<-release
+		return newTestValue("test"), nil
+	}
+
+	var (
+		leaderVal *testValue
+		leaderErr error
+	)
+
+	leaderDone := make(chan struct{})
+	go func() {
+		leaderVal, leaderErr = s.group.Do(s.T().Context(), "key", fn)
+		close(leaderDone)
+	}()
+
+	s.waitFollowers("key", 0)
+
+	vals, errs, wg := s.runFollowers(s.T().Context(), "key", 5, fn)
+
+	close(release)
+	<-leaderDone
+
+	s.Require().NoError(leaderErr)
+
+	// Leader closes its reference before the followers use theirs
+	leaderVal.Close()
+
+	wg.Wait()
+
+	s.Require().EqualValues(1, calls.Load())
+
+	for i, v := range vals {
+		s.Require().NoError(errs[i])
+		s.Require().Same(leaderVal, v)
+		v.Close()
+	}
+
+	s.Require().EqualValues(0, leaderVal.refCount.Load())
+	s.Require().Empty(s.group.calls)
+}
+
+func (s *GroupTestSuite) TestDifferentKeys() {
+	var calls atomic.Int32
+
+	fn := func() (*testValue, error) {
+		calls.Add(1)
+		return newTestValue("test"), nil
+	}
+
+	v1, err := s.group.Do(s.T().Context(), "key1", fn)
+	s.Require().NoError(err)
+	defer v1.Close()
+
+	v2, err := s.group.Do(s.T().Context(), "key2", fn)
+	s.Require().NoError(err)
+	defer v2.Close()
+
+	s.Require().EqualValues(2, calls.Load())
+	s.Require().NotSame(v1, v2)
+}
+
+func (s *GroupTestSuite) TestErrorShared() {
+	testErr := errors.New("test error")
+
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+	fn := func() (*testValue, error) {
+		calls.Add(1)
+		<-release
+		return nil, testErr
+	}
+
+	leaderDone := make(chan error)
+	go func() {
+		_, err := s.group.Do(s.T().Context(), "key", fn)
+		leaderDone <- err
+	}()
+
+	s.waitFollowers("key", 0)
+
+	_, errs, wg := s.runFollowers(s.T().Context(), "key", 3, fn)
+
+	close(release)
+
+	s.Require().ErrorIs(<-leaderDone, testErr)
+
+	wg.Wait()
+
+	for _, err := range errs {
+		s.Require().ErrorIs(err, testErr)
+	}
+
+	s.Require().EqualValues(1, calls.Load())
+}
+
+func (s *GroupTestSuite) TestLeaderCanceled() {
+	var calls atomic.Int32
+
+	leaderCtx, cancelLeader := context.WithCancel(s.T().Context())
+	defer cancelLeader()
+
+	leaderDone := make(chan error)
+	go func() {
+		_, err := s.group.Do(leaderCtx, "key", func() (*testValue, error) {
+			calls.Add(1)
+			<-leaderCtx.Done()
+			return nil, leaderCtx.Err()
+		})
+		leaderDone <- err
+	}()
+
+	s.waitFollowers("key", 0)
+
+	release := make(chan struct{})
+
+	vals, errs, wg := s.runFollowers(s.T().Context(), "key", 3, func() (*testValue, error) {
+		calls.Add(1)
+		<-release
+		return newTestValue("test"), nil
+	})
+
+	cancelLeader()
+
+	s.Require().ErrorIs(<-leaderDone, context.Canceled)
+
+	// Wait for the rest of the followers to join the new leader
+	s.waitFollowers("key", 2)
+
+	close(release)
+	wg.Wait()
+
+	// One of the followers becomes a new leader, the rest share its result
+	s.Require().EqualValues(2, calls.Load())
+
+	for i, v := range vals {
+		s.Require().NoError(errs[i])
+		s.Require().Equal("test", v.data)
+		v.Close()
+	}
+
+	s.Require().EqualValues(0, vals[0].refCount.Load())
+}
+
+func (s *GroupTestSuite) TestFollowerCanceled() {
+	release := make(chan struct{})
+
+	var leaderVal *testValue
+
+	leaderDone := make(chan struct{})
+	go func() {
+		leaderVal, _ = s.group.Do(s.T().Context(), "key", func() (*testValue, error) {
+			<-release
+			return newTestValue("test"), nil
+		})
+		close(leaderDone)
+	}()
+
+	s.waitFollowers("key", 0)
+
+	followerCtx, cancelFollower := context.WithCancel(s.T().Context())
+
+	_, errs, wg := s.runFollowers(followerCtx, "key", 1, nil)
+
+	cancelFollower()
+	wg.Wait()
+
+	s.Require().ErrorIs(errs[0], context.Canceled)
+
+	close(release)
+	<-leaderDone
+
+	// No followers left, so the leader holds the only reference
+	s.Require().EqualValues(1, leaderVal.refCount.Load())
+	leaderVal.Close()
+}
+
+func (s *GroupTestSuite) TestLeaderPanic() {
+	release := make(chan struct{})
+
+	go func() {
+		defer func() { recover() }() //nolint:errcheck
+
+		s.group.Do(s.T().Context(), "key", func() (*testValue, error) {
+			<-release
+			panic("test panic")
+		})
+	}()
+
+	s.waitFollowers("key", 0)
+
+	vals, errs, wg := s.runFollowers(s.T().Context(), "key", 1, func() (*testValue, error) {
+		return newTestValue("test"), nil
+	})
+
+	close(release)
+	wg.Wait()
+
+	s.Require().NoError(errs[0])
+	s.Require().Equal("test", vals[0].data)
+	vals[0].Close()
+}
+
+func TestGroup(t *testing.T) {
+	suite.Run(t, new(GroupTestSuite))
+}
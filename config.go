@@ -14,6 +14,7 @@ import (
 	"github.com/imgproxy/imgproxy/v4/monitoring/prometheus"
 	optionsparser "github.com/imgproxy/imgproxy/v4/options/parser"
 	"github.com/imgproxy/imgproxy/v4/processing"
+	"github.com/imgproxy/imgproxy/v4/resultcache"
 	"github.com/imgproxy/imgproxy/v4/security"
 	"github.com/imgproxy/imgproxy/v4/server"
 	"github.com/imgproxy/imgproxy/v4/workers"
@@ -30,6 +31,7 @@ type Config struct {
 	Workers            workers.Config
 	FallbackImage      auximageprovider.StaticConfig
 	WatermarkImage     auximageprovider.StaticConfig
+	WatermarkURL       auximageprovider.URLConfig
 	Fetcher            fetcher.Config
 	ClientFeatures     clientfeatures.Config
 	Handlers           HandlerConfigs
@@ -41,6 +43,7 @@ type Config struct {
 	Monitoring         monitoring.Config
 	ErrorReport        errorreport.Config
 	ConditionalHeaders conditionalheaders.Config
+	ResultCache        resultcache.Config
 }
 
 // NewDefaultConfig creates a new default configuration
@@ -49,6 +52,7 @@ func NewDefaultConfig() Config {
 		Workers:        workers.NewDefaultConfig(),
 		FallbackImage:  auximageprovider.NewDefaultStaticConfig(),
 		WatermarkImage: auximageprovider.NewDefaultStaticConfig(),
+		WatermarkURL:   auximageprovider.NewDefaultURLConfig(),
 		Fetcher:        fetcher.NewDefaultConfig(),
 		ClientFeatures: clientfeatures.NewDefaultConfig(),
 		Handlers: HandlerConfigs{
@@ -63,6 +67,7 @@ func NewDefaultConfig() Config {
 		Monitoring:         monitoring.NewDefaultConfig(),
 		ErrorReport:        errorreport.NewDefaultConfig(),
 		ConditionalHeaders: conditionalheaders.NewDefaultConfig(),
+		ResultCache:        resultcache.NewDefaultConfig(),
 	}
 }
 
@@ -84,6 +89,10 @@ func LoadConfigFromEnv(c *Config) (*Config, error) {
 		return nil, err
 	}
 
+	if _, err = auximageprovider.LoadWatermarkURLConfigFromEnv(&c.WatermarkURL); err != nil {
+		return nil, err
+	}
+
 	if _, err = workers.LoadConfigFromEnv(&c.Workers); err != nil {
 		return nil, err
 	}
@@ -132,6 +141,10 @@ func LoadConfigFromEnv(c *Config) (*Config, error) {
 		return nil, err
 	}
 
+	if _, err = resultcache.LoadConfigFromEnv(&c.ResultCache); err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
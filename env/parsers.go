@@ -177,6 +177,47 @@ func parseImageTypesQuality(env string) (map[imagetype.Type]int, error) {
 	return result, nil
 }
 
+// parseImageTypesFloat parses format=value pairs (e.g., "jpg=0.02,avif=0.015") and returns
+// a map of image types to their non-negative float values.
+func parseImageTypesFloat(env string) (map[imagetype.Type]float64, error) {
+	result := make(map[imagetype.Type]float64)
+	parts := strings.SplitSeq(env, ",")
+
+	for p := range parts {
+		p = strings.TrimSpace(p)
+
+		imgtypeStr, vStr, ok := strings.Cut(p, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid format value string: %s", p)
+		}
+
+		imgtypeStr = strings.TrimSpace(imgtypeStr)
+		vStr = strings.TrimSpace(vStr)
+
+		if len(vStr) == 0 {
+			return nil, fmt.Errorf("missing value for format: %s", imgtypeStr)
+		}
+
+		if len(imgtypeStr) == 0 {
+			return nil, fmt.Errorf("missing image format in: %s", p)
+		}
+
+		v, err := strconv.ParseFloat(vStr, 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid value: %s", vStr)
+		}
+
+		t, ok := imagetype.GetTypeByName(imgtypeStr)
+		if !ok {
+			return nil, fmt.Errorf("unknown image format: %s", imgtypeStr)
+		}
+
+		result[t] = v
+	}
+
+	return result, nil
+}
+
 // parseURLPatterns parses a comma-separated list of wildcard patterns and converts them
 // to compiled regular expressions using RegexpFromPattern.
 func parseURLPatterns(env string) ([]*regexp.Regexp, error) {
@@ -19,6 +19,7 @@ type DurationVar = Desc[time.Duration]
 type StringSliceVar = Desc[[]string]
 type ImageTypesVar = Desc[[]imagetype.Type]
 type ImageTypesQualityVar = Desc[map[imagetype.Type]int]
+type ImageTypesFloatVar = Desc[map[imagetype.Type]float64]
 type URLPatternsVar = Desc[[]*regexp.Regexp]
 type HexSliceVar = Desc[[][]byte]
 type StringMapVar = Desc[map[string]string]
@@ -145,6 +146,16 @@ func ImageTypesQuality(name string) ImageTypesQualityVar {
 	}
 }
 
+// ImageTypesFloat defines image format float value map env var descriptor.
+// Parses format=value pairs (e.g., "jpg=0.02,avif=0.015").
+func ImageTypesFloat(name string) ImageTypesFloatVar {
+	return ImageTypesFloatVar{
+		Name:    name,
+		format:  "format=value pairs (e.g. jpg=0.02,avif=0.015)",
+		parseFn: parseImageTypesFloat,
+	}
+}
+
 // URLPatterns defines regexp patterns slice env var descriptor.
 // Parses comma-separated wildcard patterns and converts them to regexps.
 func URLPatterns(name string) URLPatternsVar {
@@ -411,6 +411,52 @@ func TestImageTypesQuality(t *testing.T) {
 	}
 }
 
+func TestImageTypesFloat(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      map[imagetype.Type]float64
+		wantErr   bool
+		errSubstr string
+	}{
+		{
+			input: "jpg=0.02,avif=0.015",
+			want: map[imagetype.Type]float64{
+				imagetype.JPEG: 0.02,
+				imagetype.AVIF: 0.015,
+			},
+		},
+		{
+			input: " jpg = 0.02 , webp = 1 ",
+			want:  map[imagetype.Type]float64{imagetype.JPEG: 0.02, imagetype.WEBP: 1},
+		},
+		{input: "jpg0.02", wantErr: true, errSubstr: "invalid format value string"},
+		{input: "jpg=invalid", wantErr: true, errSubstr: "invalid value"},
+		{input: "jpg=-1", wantErr: true, errSubstr: "invalid value"},
+		{input: "unknown=0.02", wantErr: true, errSubstr: "unknown image format"},
+		{input: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Setenv(testVar, tt.input)
+			desc := env.ImageTypesFloat(testVar)
+
+			var result map[imagetype.Type]float64
+			err := desc.Parse(&result)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.errSubstr != "" {
+					assert.Contains(t, err.Error(), tt.errSubstr)
+				}
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, result)
+			}
+		})
+	}
+}
+
 func TestURLPatterns(t *testing.T) {
 	tests := []struct {
 		input         string
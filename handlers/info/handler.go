@@ -0,0 +1,230 @@
+package info
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/imgproxy/imgproxy/v4/clientfeatures"
+	"github.com/imgproxy/imgproxy/v4/cookies"
+	"github.com/imgproxy/imgproxy/v4/errctx"
+	"github.com/imgproxy/imgproxy/v4/errorreport"
+	"github.com/imgproxy/imgproxy/v4/handlers"
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/monitoring"
+	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/options/keys"
+	optionsparser "github.com/imgproxy/imgproxy/v4/options/parser"
+	"github.com/imgproxy/imgproxy/v4/processing"
+	"github.com/imgproxy/imgproxy/v4/security"
+	"github.com/imgproxy/imgproxy/v4/server"
+	"github.com/imgproxy/imgproxy/v4/vips"
+	"github.com/imgproxy/imgproxy/v4/workers"
+)
+
+// HandlerContext provides access to shared handler dependencies
+type HandlerContext interface {
+	Workers() *workers.Workers
+	ClientFeaturesDetector() *clientfeatures.Detector
+	ImageDataFactory() *imagedata.Factory
+	Security() *security.Checker
+	OptionsParser() *optionsparser.Parser
+	Processor() *processing.Processor
+	Cookies() *cookies.Cookies
+	Monitoring() *monitoring.Monitoring
+}
+
+// Handler handles image info requests.
+// It responds with the source image metadata in JSON format
+// without running the processing pipeline.
+type Handler struct {
+	HandlerContext
+}
+
+// request holds the parameters and state for a single info request
+type request struct {
+	HandlerContext
+
+	reqID    string
+	req      *http.Request
+	rw       server.ResponseWriter
+	opts     *options.Options
+	imageURL string
+}
+
+// New creates new handler object
+func New(hCtx HandlerContext) *Handler {
+	return &Handler{
+		HandlerContext: hCtx,
+	}
+}
+
+// Execute handles the image info request
+func (h *Handler) Execute(
+	reqID string,
+	rw server.ResponseWriter,
+	req *http.Request,
+) *server.Error {
+	h.Monitoring().Stats().IncRequestsInProgress()
+	defer h.Monitoring().Stats().DecRequestsInProgress()
+
+	r, err := h.newRequest(req)
+	if err != nil {
+		return err
+	}
+
+	r.reqID = reqID
+	r.rw = rw
+
+	return r.execute()
+}
+
+// newRequest extracts image url and options from request URL and verifies them
+func (h *Handler) newRequest(req *http.Request) (*request, *server.Error) {
+	path, signature, err := handlers.SplitPathSignature(req)
+	if err != nil {
+		return nil, server.NewError(errctx.Wrap(err), handlers.ErrCategoryPathParsing)
+	}
+
+	if err = h.Security().VerifySignature(req.Context(), signature, path); err != nil {
+		return nil, server.NewError(errctx.Wrap(err), handlers.ErrCategorySecurity)
+	}
+
+	features := h.ClientFeaturesDetector().Features(req.Header)
+	o, imageURL, err := h.OptionsParser().ParsePath(req.Context(), path, &features)
+	if err != nil {
+		return nil, server.NewError(errctx.Wrap(err), handlers.ErrCategoryPathParsing)
+	}
+
+	imageOrigin := monitoring.MetaURLOrigin(imageURL)
+
+	errorreport.SetMetadata(req, "Source Image URL", imageURL)
+	errorreport.SetMetadata(req, "Source Image Origin", imageOrigin)
+
+	h.Monitoring().SetMetadata(req.Context(), monitoring.Meta{
+		monitoring.MetaSourceImageURL:    imageURL,
+		monitoring.MetaSourceImageOrigin: imageOrigin,
+	})
+
+	if err = h.Security().VerifySourceURL(imageURL); err != nil {
+		return nil, server.NewError(errctx.Wrap(err), handlers.ErrCategorySecurity)
+	}
+
+	return &request{
+		HandlerContext: h,
+
+		req:      req,
+		opts:     o,
+		imageURL: imageURL,
+	}, nil
+}
+
+// execute downloads the image header and responds with the image info
+func (r *request) execute() *server.Error {
+	ctx := r.req.Context()
+
+	jar, err := r.Cookies().JarFromRequest(r.req)
+	if err != nil {
+		return server.NewError(errctx.Wrap(err), handlers.ErrCategoryDownload)
+	}
+
+	do := imagedata.DownloadOptions{
+		Header:         make(http.Header),
+		MaxSrcFileSize: r.Security().MaxSrcFileSize(r.opts),
+		CookieJar:      jar,
+	}
+
+	// We download the image asynchronously, so the worker doesn't wait
+	// for the whole image to be downloaded. We need only the header.
+	imgdata, originHeaders, err := r.ImageDataFactory().DownloadAsync(
+		ctx, r.imageURL, "source image", do,
+	)
+	if imgdata != nil {
+		defer imgdata.Close()
+	}
+	if err != nil {
+		return server.NewError(errctx.Wrap(err), handlers.ErrCategoryDownload)
+	}
+
+	if terr := server.CheckTimeout(ctx); terr != nil {
+		return server.NewError(terr, handlers.ErrCategoryTimeout)
+	}
+
+	if !vips.SupportsLoad(imgdata.Format()) {
+		return server.NewError(
+			handlers.NewCantLoadError(ctx, imgdata.Format()),
+			handlers.ErrCategoryPathParsing,
+		)
+	}
+
+	info, ierr := r.readInfo(ctx, imgdata)
+	if info != nil {
+		defer info.Close()
+	}
+
+	// Check if the error was caused by an image data error
+	if derr := imgdata.Error(); derr != nil {
+		return server.NewError(errctx.Wrap(derr), handlers.ErrCategoryDownload)
+	}
+
+	if ierr != nil {
+		return server.NewError(ierr, handlers.ErrCategoryProcessing)
+	}
+
+	return r.respondWithInfo(originHeaders, info)
+}
+
+// readInfo acquires a worker and reads the image info
+func (r *request) readInfo(
+	ctx context.Context,
+	imgdata imagedata.ImageData,
+) (*processing.Info, errctx.Error) {
+	queueCtx, cancelQueueSpan := r.Monitoring().StartSpan(ctx, "Queue", nil)
+	release, err := r.Workers().Acquire(queueCtx)
+	cancelQueueSpan()
+
+	if err != nil {
+		if terr := server.CheckTimeout(ctx); terr != nil {
+			return nil, terr
+		}
+
+		return nil, errctx.Wrap(err)
+	}
+	defer release()
+
+	ctx, cancelSpan := r.Monitoring().StartSpan(ctx, "Reading image info", nil)
+	defer cancelSpan()
+
+	info, err := r.Processor().ImageInfo(ctx, imgdata)
+	return info, errctx.Wrap(err)
+}
+
+// respondWithInfo writes the image info as JSON
+func (r *request) respondWithInfo(
+	originHeaders http.Header,
+	info *processing.Info,
+) *server.Error {
+	body, err := json.Marshal(info)
+	if err != nil {
+		return server.NewError(errctx.Wrap(err), handlers.ErrCategoryProcessing)
+	}
+
+	r.rw.SetOriginHeaders(originHeaders)
+	r.rw.SetContentType("application/json")
+	r.rw.SetContentLength(len(body))
+	r.rw.SetExpires(r.opts.GetTime(keys.Expires))
+	r.rw.WriteHeader(http.StatusOK)
+
+	var ierr errctx.Error
+	if _, err = r.rw.Write(body); err != nil {
+		ierr = handlers.NewResponseWriteError(err)
+	}
+
+	server.LogResponse(
+		r.reqID, r.req, http.StatusOK, ierr,
+		slog.String("image_url", r.imageURL),
+	)
+
+	return nil
+}
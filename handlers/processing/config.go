@@ -13,6 +13,7 @@ var (
 	IMGPROXY_REPORT_IO_ERRORS          = env.Bool("IMGPROXY_REPORT_IO_ERRORS")
 	IMGPROXY_FALLBACK_IMAGE_HTTP_CODE  = env.Int("IMGPROXY_FALLBACK_IMAGE_HTTP_CODE")
 	IMGPROXY_ENABLE_DEBUG_HEADERS      = env.Bool("IMGPROXY_ENABLE_DEBUG_HEADERS")
+	IMGPROXY_COALESCE_REQUESTS         = env.Bool("IMGPROXY_COALESCE_REQUESTS")
 )
 
 // Config represents handler config
@@ -21,6 +22,7 @@ type Config struct {
 	ReportIOErrors          bool // Whether to report IO errors
 	FallbackImageHTTPCode   int  // Fallback image HTTP status code
 	EnableDebugHeaders      bool // Whether to enable debug headers
+	CoalesceRequests        bool // Whether to coalesce identical concurrent requests
 }
 
 // NewDefaultConfig creates a new configuration with defaults
@@ -30,6 +32,7 @@ func NewDefaultConfig() Config {
 		ReportIOErrors:          false,
 		FallbackImageHTTPCode:   http.StatusOK,
 		EnableDebugHeaders:      false,
+		CoalesceRequests:        false,
 	}
 }
 
@@ -42,6 +45,7 @@ func LoadConfigFromEnv(c *Config) (*Config, error) {
 		IMGPROXY_REPORT_IO_ERRORS.Parse(&c.ReportIOErrors),
 		IMGPROXY_FALLBACK_IMAGE_HTTP_CODE.Parse(&c.FallbackImageHTTPCode),
 		IMGPROXY_ENABLE_DEBUG_HEADERS.Parse(&c.EnableDebugHeaders),
+		IMGPROXY_COALESCE_REQUESTS.Parse(&c.CoalesceRequests),
 	)
 
 	return c, err
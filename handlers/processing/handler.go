@@ -5,6 +5,7 @@ import (
 
 	"github.com/imgproxy/imgproxy/v4/auximageprovider"
 	"github.com/imgproxy/imgproxy/v4/clientfeatures"
+	"github.com/imgproxy/imgproxy/v4/coalescing"
 	"github.com/imgproxy/imgproxy/v4/cookies"
 	"github.com/imgproxy/imgproxy/v4/errctx"
 	"github.com/imgproxy/imgproxy/v4/errorreport"
@@ -16,6 +17,7 @@ import (
 	"github.com/imgproxy/imgproxy/v4/options/keys"
 	optionsparser "github.com/imgproxy/imgproxy/v4/options/parser"
 	"github.com/imgproxy/imgproxy/v4/processing"
+	"github.com/imgproxy/imgproxy/v4/resultcache"
 	"github.com/imgproxy/imgproxy/v4/security"
 	"github.com/imgproxy/imgproxy/v4/server"
 	"github.com/imgproxy/imgproxy/v4/workers"
@@ -36,14 +38,16 @@ type HandlerContext interface {
 	Monitoring() *monitoring.Monitoring
 	ErrorReporter() *errorreport.Reporter
 	ConditionalHeaders() *conditionalheaders.Factory
+	ResultCache() *resultcache.Cache
 }
 
 // Handler handles image processing requests
 type Handler struct {
 	HandlerContext
 
-	stream *stream.Handler // Stream handler for raw image streaming
-	config *Config         // Handler configuration
+	stream     *stream.Handler              // Stream handler for raw image streaming
+	config     *Config                      // Handler configuration
+	coalescing *coalescing.Group[*response] // Identical concurrent requests coalescing group
 }
 
 // New creates new handler object
@@ -56,11 +60,17 @@ func New(
 		return nil, err
 	}
 
-	return &Handler{
+	h := &Handler{
 		HandlerContext: hCtx,
 		config:         config,
 		stream:         stream,
-	}, nil
+	}
+
+	if config.CoalesceRequests {
+		h.coalescing = coalescing.NewGroup[*response]()
+	}
+
+	return h, nil
 }
 
 // Execute handles the image processing request
@@ -88,6 +98,7 @@ func (h *Handler) Execute(
 	r.req = req
 	r.rw = rw
 	r.config = h.config
+	r.coalescing = h.coalescing
 	r.ch = h.ConditionalHeaders().NewRequest(r.req)
 
 	return r.execute()
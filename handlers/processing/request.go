@@ -5,9 +5,13 @@ import (
 	"net/http"
 
 	"github.com/imgproxy/imgproxy/v4/clientfeatures"
+	"github.com/imgproxy/imgproxy/v4/coalescing"
+	"github.com/imgproxy/imgproxy/v4/errctx"
 	"github.com/imgproxy/imgproxy/v4/fetcher"
 	"github.com/imgproxy/imgproxy/v4/handlers"
+	"github.com/imgproxy/imgproxy/v4/httpheaders"
 	"github.com/imgproxy/imgproxy/v4/httpheaders/conditionalheaders"
+	"github.com/imgproxy/imgproxy/v4/imagedata"
 	"github.com/imgproxy/imgproxy/v4/imagetype"
 	"github.com/imgproxy/imgproxy/v4/monitoring"
 	"github.com/imgproxy/imgproxy/v4/options"
@@ -24,6 +28,7 @@ type request struct {
 	req            *http.Request
 	rw             server.ResponseWriter
 	config         *Config
+	coalescing     *coalescing.Group[*response]
 	opts           *options.Options
 	imageURL       string
 	path           string
@@ -39,18 +44,69 @@ func (r *request) execute() *server.Error {
 	// Check if we can save the resulting image
 	canSave := vips.SupportsSave(outFormat) ||
 		outFormat == imagetype.Unknown ||
-		outFormat == imagetype.SVG
+		outFormat == imagetype.SVG ||
+		outFormat.IsPseudo()
 
 	if !canSave {
 		return server.NewError(handlers.NewCantSaveError(outFormat), handlers.ErrCategoryPathParsing)
 	}
 
+	// Request headers
+	imgRequestHeaders := r.makeImageRequestHeaders()
+
+	// create download options
+	do, err := r.makeDownloadOptions(imgRequestHeaders)
+	if err != nil {
+		return server.NewError(err, handlers.ErrCategoryDownload)
+	}
+
+	// Respond with the cached result if we have one
+	if entry := r.getCachedResult(do); entry != nil {
+		return r.respondWithCachedResult(entry, imgRequestHeaders)
+	}
+
+	// Fetch and process the image, or get the response of an identical request
+	// that is already in progress
+	resp, serr := r.getResponse(do)
+	if serr != nil {
+		return serr
+	}
+	defer resp.Close()
+
+	// Prepare to write image response headers
+	r.rw.SetOriginHeaders(resp.originHeaders)
+	r.ch.SetOriginHeaders(resp.originHeaders)
+
+	// Respond with NotModified if image was not modified
+	if resp.statusCode == http.StatusNotModified {
+		r.respondWithNotModified()
+		return nil
+	}
+
+	if resp.fallback {
+		r.rw.SetOriginHeaders(resp.fallbackHeaders)
+		r.rw.SetIsFallbackImage()
+	}
+
+	// Write debug headers. It seems unlogical to move they to responsewriter since they're
+	// not used anywhere else.
+	r.writeDebugHeaders(resp)
+
+	// Write headers that describe the processing result
+	httpheaders.CopyAll(resultHeaders(resp.result), r.rw.Header(), true)
+
+	// Responde with actual image
+	return r.respondWithImage(resp.statusCode, resp.result.OutData)
+}
+
+// processRequest fetches and processes the source image
+func (r *request) processRequest(do imagedata.DownloadOptions) (*response, *server.Error) {
 	ctx := r.req.Context()
 
 	// Acquire worker
 	releaseWorker, err := r.acquireWorker()
 	if err != nil {
-		return server.NewError(err, handlers.ErrCategoryQueue)
+		return nil, server.NewError(err, handlers.ErrCategoryQueue)
 	}
 	defer releaseWorker()
 
@@ -58,18 +114,6 @@ func (r *request) execute() *server.Error {
 	r.Monitoring().Stats().IncImagesInProgress()
 	defer r.Monitoring().Stats().DecImagesInProgress()
 
-	// Response status code is OK by default
-	statusCode := http.StatusOK
-
-	// Request headers
-	imgRequestHeaders := r.makeImageRequestHeaders()
-
-	// create download options
-	do, err := r.makeDownloadOptions(imgRequestHeaders)
-	if err != nil {
-		return server.NewError(err, handlers.ErrCategoryDownload)
-	}
-
 	// Fetch image actual
 	originData, originHeaders, err := r.fetchImage(do)
 	if originData != nil {
@@ -78,50 +122,68 @@ func (r *request) execute() *server.Error {
 
 	// Check that image detection didn't take too long
 	if terr := server.CheckTimeout(ctx); terr != nil {
-		return server.NewError(terr, handlers.ErrCategoryTimeout)
+		return nil, server.NewError(terr, handlers.ErrCategoryTimeout)
 	}
 
 	// Respond with NotModified if image was not modified
 	if nmErr, ok := errors.AsType[fetcher.NotModifiedError](err); ok {
-		r.rw.SetOriginHeaders(nmErr.Headers())
-		r.ch.SetOriginHeaders(nmErr.Headers())
-
-		r.respondWithNotModified()
-
-		return nil
+		return &response{
+			statusCode:    http.StatusNotModified,
+			originHeaders: nmErr.Headers(),
+		}, nil
 	}
 
-	// Prepare to write image response headers
-	r.rw.SetOriginHeaders(originHeaders)
-	r.ch.SetOriginHeaders(originHeaders)
+	// Response status code is OK by default
+	resp := &response{
+		statusCode:    http.StatusOK,
+		originHeaders: originHeaders,
+	}
 
 	// If error is not related to NotModified, respond with fallback image and replace image data
 	if err != nil {
-		originData, statusCode, err = r.handleDownloadError(err)
+		originData, resp.statusCode, resp.fallbackHeaders, err = r.handleDownloadError(err)
 		if err != nil {
-			return server.NewError(err, handlers.ErrCategoryDownload)
+			return nil, server.NewError(err, handlers.ErrCategoryDownload)
 		}
 		defer originData.Close() // if we got a fallback image, we also need to close it
+
+		resp.fallback = true
 	}
 
 	// Check if image supports load from origin format
-	if !vips.SupportsLoad(originData.Format()) {
-		return server.NewError(
+	if !r.Processor().SupportsLoad(originData.Format()) {
+		return nil, server.NewError(
 			handlers.NewCantLoadError(ctx, originData.Format()),
 			handlers.ErrCategoryPathParsing,
 		)
 	}
 
-	// Actually process the image
-	result, err := r.processImage(originData)
-
+	// Actually process the image.
 	// result.OutData always owns its own reference (via Ref() for the skip-processing
-	// path, or a freshly created ImageData for the normal processing path), so we
-	// can unconditionally defer its close here.
-	if result != nil && result.OutData != nil {
-		defer result.OutData.Close()
+	// path, or a freshly created ImageData for the normal processing path), so
+	// the response owns it from now on.
+	resp.result, err = r.processImage(originData)
+
+	if serr := r.checkProcessingResult(resp, originData, err); serr != nil {
+		resp.Close()
+		return nil, serr
 	}
 
+	// Cache the result unless it was produced from the fallback image
+	if !resp.fallback {
+		r.cacheResult(do, resp.result, originHeaders)
+	}
+
+	return resp, nil
+}
+
+// checkProcessingResult checks the processing errors and ensures that the result
+// and origin image data were fully read
+func (r *request) checkProcessingResult(
+	resp *response,
+	originData imagedata.ImageData,
+	err errctx.Error,
+) *server.Error {
 	// First, check if the processing error wasn't caused by an image data error
 	if derr := originData.Error(); derr != nil {
 		return server.NewError(r.wrapDownloadingErr(derr), handlers.ErrCategoryDownload)
@@ -132,13 +194,21 @@ func (r *request) execute() *server.Error {
 		return server.NewError(err, handlers.ErrCategoryProcessing)
 	}
 
-	// Write debug headers. It seems unlogical to move they to responsewriter since they're
-	// not used anywhere else.
-	dhErr := r.writeDebugHeaders(result, originData)
-	if dhErr != nil {
-		return dhErr
+	// Reading the result size ensures the result data is fully read, so it doesn't
+	// depend on the source download anymore and can be shared
+	if _, serr := resp.result.OutData.Size(); serr != nil {
+		return server.NewError(errctx.Wrap(serr), handlers.ErrCategoryImageDataSize)
 	}
 
-	// Responde with actual image
-	return r.respondWithImage(statusCode, result.OutData)
+	if r.config.EnableDebugHeaders {
+		// Try to read origin image size
+		size, serr := originData.Size()
+		if serr != nil {
+			return server.NewError(errctx.Wrap(serr), handlers.ErrCategoryImageDataSize)
+		}
+
+		resp.originSize = size
+	}
+
+	return nil
 }
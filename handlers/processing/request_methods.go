@@ -2,10 +2,12 @@ package processing
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/imgproxy/imgproxy/v4/errctx"
 	"github.com/imgproxy/imgproxy/v4/handlers"
@@ -14,7 +16,10 @@ import (
 	"github.com/imgproxy/imgproxy/v4/monitoring"
 	"github.com/imgproxy/imgproxy/v4/options/keys"
 	"github.com/imgproxy/imgproxy/v4/processing"
+	"github.com/imgproxy/imgproxy/v4/processing/palette"
+	"github.com/imgproxy/imgproxy/v4/resultcache"
 	"github.com/imgproxy/imgproxy/v4/server"
+	"github.com/imgproxy/imgproxy/v4/storage/common"
 )
 
 // makeImageRequestHeaders creates headers for the image request
@@ -65,6 +70,64 @@ func (r *request) makeDownloadOptions(
 	}, nil
 }
 
+// getResponse fetches and processes the source image.
+// If request coalescing is enabled and an identical request is already in progress,
+// it waits for that request and shares its response instead.
+func (r *request) getResponse(do imagedata.DownloadOptions) (*response, *server.Error) {
+	if r.coalescing == nil {
+		return r.processRequest(do)
+	}
+
+	resp, err := r.coalescing.Do(r.req.Context(), r.coalescingKey(do), func() (*response, error) {
+		resp, serr := r.processRequest(do)
+		if serr != nil {
+			return nil, responseError{serr}
+		}
+
+		return resp, nil
+	})
+
+	if rerr, ok := errors.AsType[responseError](err); ok {
+		return nil, rerr.serr
+	}
+
+	// The request was canceled or timed out while waiting for the identical request
+	if err != nil {
+		if terr := server.CheckTimeout(r.req.Context()); terr != nil {
+			return nil, server.NewError(terr, handlers.ErrCategoryTimeout)
+		}
+
+		return nil, server.NewError(errctx.Wrap(err), handlers.ErrCategoryTimeout)
+	}
+
+	return resp, nil
+}
+
+// coalescingKey builds a key identifying requests that can share the response.
+// Besides the path and the client features that may affect the result,
+// it includes everything that may make the source image response differ.
+func (r *request) coalescingKey(do imagedata.DownloadOptions) string {
+	var b strings.Builder
+
+	b.WriteString(resultcache.Key(r.path, r.features))
+
+	// Conditional headers may cause a Not Modified response
+	for _, name := range []string{httpheaders.IfModifiedSince, httpheaders.IfNoneMatch} {
+		b.WriteByte(0)
+		b.WriteString(do.Header.Get(name))
+	}
+
+	// Cookies are passed to the source only when cookie passthrough is enabled
+	if do.CookieJar != nil {
+		for _, c := range r.req.Header.Values(httpheaders.Cookie) {
+			b.WriteByte(0)
+			b.WriteString(c)
+		}
+	}
+
+	return b.String()
+}
+
 // fetchImage downloads the source image asynchronously
 func (r *request) fetchImage(
 	do imagedata.DownloadOptions,
@@ -81,11 +144,11 @@ func (r *request) fetchImage(
 // handleDownloadError replaces the image data with fallback image if needed
 func (r *request) handleDownloadError(
 	err errctx.Error,
-) (imagedata.ImageData, int, errctx.Error) {
+) (imagedata.ImageData, int, http.Header, errctx.Error) {
 	// If there is no fallback image configured, just return the error
 	data, headers := r.getFallbackImage()
 	if data == nil {
-		return nil, 0, err
+		return nil, 0, nil, err
 	}
 
 	// Just send error
@@ -117,10 +180,7 @@ func (r *request) handleDownloadError(
 	headers.Del(httpheaders.Expires)
 	headers.Del(httpheaders.LastModified)
 
-	r.rw.SetOriginHeaders(headers)
-	r.rw.SetIsFallbackImage()
-
-	return data, statusCode, nil
+	return data, statusCode, headers, nil
 }
 
 // getFallbackImage returns fallback image if any
@@ -164,30 +224,30 @@ func (r *request) processImage(
 }
 
 // writeDebugHeaders writes debug headers (X-Origin-*, X-Result-*) to the response
-func (r *request) writeDebugHeaders(
-	result *processing.Result,
-	originData imagedata.ImageData,
-) *server.Error {
+func (r *request) writeDebugHeaders(resp *response) {
 	if !r.config.EnableDebugHeaders {
-		return nil
+		return
 	}
 
-	if result != nil {
-		r.rw.Header().Set(httpheaders.XOriginWidth, strconv.Itoa(result.OriginWidth))
-		r.rw.Header().Set(httpheaders.XOriginHeight, strconv.Itoa(result.OriginHeight))
-		r.rw.Header().Set(httpheaders.XResultWidth, strconv.Itoa(result.ResultWidth))
-		r.rw.Header().Set(httpheaders.XResultHeight, strconv.Itoa(result.ResultHeight))
+	if resp.result != nil {
+		r.rw.Header().Set(httpheaders.XOriginWidth, strconv.Itoa(resp.result.OriginWidth))
+		r.rw.Header().Set(httpheaders.XOriginHeight, strconv.Itoa(resp.result.OriginHeight))
+		r.rw.Header().Set(httpheaders.XResultWidth, strconv.Itoa(resp.result.ResultWidth))
+		r.rw.Header().Set(httpheaders.XResultHeight, strconv.Itoa(resp.result.ResultHeight))
 	}
 
-	// Try to read origin image size
-	size, err := originData.Size()
-	if err != nil {
-		return server.NewError(errctx.Wrap(err), handlers.ErrCategoryImageDataSize)
-	}
+	r.rw.Header().Set(httpheaders.XOriginContentLength, strconv.Itoa(resp.originSize))
+}
 
-	r.rw.Header().Set(httpheaders.XOriginContentLength, strconv.Itoa(size))
+// resultHeaders returns the response headers that describe the processing result
+func resultHeaders(result *processing.Result) http.Header {
+	header := make(http.Header)
 
-	return nil
+	if result != nil && len(result.Palette) > 0 {
+		header.Set(httpheaders.XPalette, palette.Header(result.Palette))
+	}
+
+	return header
 }
 
 // respondWithNotModified writes not-modified response
@@ -252,6 +312,87 @@ func (r *request) respondWithImage(statusCode int, resultData imagedata.ImageDat
 	return nil
 }
 
+// canUseResultCache checks if the processing result can be shared between users
+// through the result cache.
+// When cookies are passed to the source, the source image may depend on the user,
+// so such results are never cached.
+func (r *request) canUseResultCache(do imagedata.DownloadOptions) bool {
+	return r.ResultCache() != nil && do.CookieJar == nil
+}
+
+// getCachedResult returns the cached processing result if the result cache
+// is enabled and the result is cached
+func (r *request) getCachedResult(do imagedata.DownloadOptions) *resultcache.Entry {
+	if !r.canUseResultCache(do) {
+		return nil
+	}
+
+	entry, ok := r.ResultCache().Get(resultcache.Key(r.path, r.features))
+	if !ok {
+		r.Monitoring().Stats().IncResultCacheMisses()
+		return nil
+	}
+
+	r.Monitoring().Stats().IncResultCacheHits()
+
+	return entry
+}
+
+// cacheResult stores the processing result in the result cache if it is enabled
+func (r *request) cacheResult(
+	do imagedata.DownloadOptions,
+	result *processing.Result,
+	originHeaders http.Header,
+) {
+	if !r.canUseResultCache(do) {
+		return
+	}
+
+	data, err := io.ReadAll(result.OutData.Reader())
+	if err != nil {
+		slog.Warn("Can't read processing result for caching", "error", err)
+		return
+	}
+
+	err = r.ResultCache().Set(resultcache.Key(r.path, r.features), &resultcache.Entry{
+		Format:        result.OutData.Format(),
+		Headers:       originHeaders,
+		ResultHeaders: resultHeaders(result),
+		ExpiresAt:     r.opts.GetTime(keys.Expires),
+		Data:          data,
+	})
+	if err != nil {
+		slog.Warn("Can't cache processing result", "error", err)
+	}
+}
+
+// respondWithCachedResult writes the cached processing result.
+// imgRequestHeaders are the conditional headers we would send to the source;
+// if the cached source response matches them, we respond with Not Modified
+// just like the source would.
+func (r *request) respondWithCachedResult(
+	entry *resultcache.Entry,
+	imgRequestHeaders http.Header,
+) *server.Error {
+	r.rw.SetOriginHeaders(entry.Headers)
+	r.ch.SetOriginHeaders(entry.Headers)
+
+	if common.IsNotModified(imgRequestHeaders, entry.Headers) {
+		r.respondWithNotModified()
+		return nil
+	}
+
+	resultData := imagedata.NewFromBytesWithFormat(entry.Format, entry.Data)
+	defer resultData.Close()
+
+	// Cached result should not be cached by clients longer than it lives in our cache
+	r.rw.SetExpires(entry.ExpiresAt)
+
+	httpheaders.CopyAll(entry.ResultHeaders, r.rw.Header(), true)
+
+	return r.respondWithImage(http.StatusOK, resultData)
+}
+
 // wrapDownloadingErr wraps original error to download error
 func (r *request) wrapDownloadingErr(originalErr error) errctx.Error {
 	if originalErr == nil {
@@ -0,0 +1,51 @@
+package processing
+
+import (
+	"net/http"
+
+	"github.com/imgproxy/imgproxy/v4/processing"
+	"github.com/imgproxy/imgproxy/v4/server"
+)
+
+// response holds the result of fetching and processing the source image.
+// It can be shared between coalesced requests.
+type response struct {
+	statusCode      int                // Response status code
+	originHeaders   http.Header        // Source image response headers
+	fallback        bool               // Whether the fallback image was used
+	fallbackHeaders http.Header        // Fallback image headers
+	result          *processing.Result // Processing result, nil for Not Modified response
+	originSize      int                // Source image size, set only when debug headers are enabled
+}
+
+// Ref returns a new reference to the response
+func (r *response) Ref() *response {
+	resp := *r
+
+	if r.result != nil && r.result.OutData != nil {
+		result := *r.result
+		result.OutData = r.result.OutData.Ref()
+		resp.result = &result
+	}
+
+	return &resp
+}
+
+// Close releases the result image data
+func (r *response) Close() error {
+	if r.result != nil && r.result.OutData != nil {
+		return r.result.OutData.Close()
+	}
+
+	return nil
+}
+
+// responseError wraps [server.Error] so it can be passed through [coalescing.Group]
+type responseError struct {
+	serr *server.Error
+}
+
+// Error implements the error interface
+func (e responseError) Error() string {
+	return e.serr.Err.Error()
+}
@@ -77,5 +77,6 @@ const (
 	XRequestID                      = "X-Request-ID"
 	XResultWidth                    = "X-Result-Width"
 	XResultHeight                   = "X-Result-Height"
+	XPalette                        = "X-Palette"
 	XOriginContentLength            = "X-Origin-Content-Length"
 )
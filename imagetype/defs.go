@@ -24,7 +24,7 @@ var (
 		SupportsColourProfile: true,
 		SupportsQuality:       true,
 		SupportsAnimationLoad: true,
-		SupportsAnimationSave: false,
+		SupportsAnimationSave: true,
 		SupportsThumbnail:     false,
 		SupportsHDR:           true,
 	})
@@ -37,8 +37,8 @@ var (
 		SupportsAlpha:         true,
 		SupportsColourProfile: true,
 		SupportsQuality:       false,
-		SupportsAnimationLoad: false,
-		SupportsAnimationSave: false,
+		SupportsAnimationLoad: true,
+		SupportsAnimationSave: true,
 		SupportsThumbnail:     false,
 		SupportsHDR:           true,
 	})
@@ -122,7 +122,7 @@ var (
 		SupportsColourProfile: true,
 		SupportsQuality:       true,
 		SupportsAnimationLoad: false,
-		SupportsAnimationSave: false,
+		SupportsAnimationSave: true,
 		SupportsThumbnail:     true,
 		SupportsHDR:           true,
 	})
@@ -154,6 +154,110 @@ var (
 		SupportsThumbnail:     false,
 		SupportsHDR:           true,
 	})
+
+	PDF = RegisterType(&TypeDesc{
+		String:                "pdf",
+		Ext:                   ".pdf",
+		Mime:                  "application/pdf",
+		IsVector:              true,
+		SupportsAlpha:         false,
+		SupportsColourProfile: false,
+		SupportsQuality:       false,
+		SupportsAnimationLoad: false,
+		SupportsAnimationSave: false,
+		SupportsThumbnail:     false,
+		SupportsHDR:           false,
+	})
+
+	MP4 = RegisterType(&TypeDesc{
+		String:                "mp4",
+		Ext:                   ".mp4",
+		Mime:                  "video/mp4",
+		IsVector:              false,
+		IsVideo:               true,
+		SupportsAlpha:         false,
+		SupportsColourProfile: false,
+		SupportsQuality:       false,
+		SupportsAnimationLoad: false,
+		SupportsAnimationSave: false,
+		SupportsThumbnail:     false,
+		SupportsHDR:           false,
+	})
+
+	MOV = RegisterType(&TypeDesc{
+		String:                "mov",
+		Ext:                   ".mov",
+		Mime:                  "video/quicktime",
+		IsVector:              false,
+		IsVideo:               true,
+		SupportsAlpha:         false,
+		SupportsColourProfile: false,
+		SupportsQuality:       false,
+		SupportsAnimationLoad: false,
+		SupportsAnimationSave: false,
+		SupportsThumbnail:     false,
+		SupportsHDR:           false,
+	})
+
+	WEBM = RegisterType(&TypeDesc{
+		String:                "webm",
+		Ext:                   ".webm",
+		Mime:                  "video/webm",
+		IsVector:              false,
+		IsVideo:               true,
+		SupportsAlpha:         false,
+		SupportsColourProfile: false,
+		SupportsQuality:       false,
+		SupportsAnimationLoad: false,
+		SupportsAnimationSave: false,
+		SupportsThumbnail:     false,
+		SupportsHDR:           false,
+	})
+
+	BLURHASH = RegisterType(&TypeDesc{
+		String:                "blurhash",
+		Ext:                   ".txt",
+		Mime:                  "text/plain",
+		IsVector:              false,
+		IsPseudo:              true,
+		SupportsAlpha:         false,
+		SupportsColourProfile: false,
+		SupportsQuality:       false,
+		SupportsAnimationLoad: false,
+		SupportsAnimationSave: false,
+		SupportsThumbnail:     false,
+		SupportsHDR:           false,
+	})
+
+	THUMBHASH = RegisterType(&TypeDesc{
+		String:                "thumbhash",
+		Ext:                   ".txt",
+		Mime:                  "text/plain",
+		IsVector:              false,
+		IsPseudo:              true,
+		SupportsAlpha:         true,
+		SupportsColourProfile: false,
+		SupportsQuality:       false,
+		SupportsAnimationLoad: false,
+		SupportsAnimationSave: false,
+		SupportsThumbnail:     false,
+		SupportsHDR:           false,
+	})
+
+	PALETTE = RegisterType(&TypeDesc{
+		String:                "palette",
+		Ext:                   ".json",
+		Mime:                  "application/json",
+		IsVector:              false,
+		IsPseudo:              true,
+		SupportsAlpha:         true,
+		SupportsColourProfile: false,
+		SupportsQuality:       false,
+		SupportsAnimationLoad: false,
+		SupportsAnimationSave: false,
+		SupportsThumbnail:     false,
+		SupportsHDR:           false,
+	})
 )
 
 // init registers default magic bytes for common image formats
@@ -188,7 +292,7 @@ func init() {
 	)
 
 	// PNG magic bytes
-	RegisterMagicBytes(PNG, []byte("\x89PNG\r\n\x1a\n"))
+	RegisterMagicBytes(PNG, pngSignature)
 
 	// WEBP magic bytes (RIFF container with WEBP fourcc) - using wildcard for size
 	RegisterMagicBytes(WEBP, []byte("RIFF????WEBP"))
@@ -209,9 +313,30 @@ func init() {
 		[]byte("????ftyphevs"),
 		[]byte("????ftypmif1"))
 
-	// AVIF magic bytes
-	RegisterMagicBytes(AVIF, []byte("????ftypavif"))
+	// AVIF magic bytes. Animated AVIF images (image sequences) use the "avis" brand
+	RegisterMagicBytes(AVIF, []byte("????ftypavif"), []byte("????ftypavis"))
 
 	// BMP magic bytes
 	RegisterMagicBytes(BMP, []byte("BM"))
+
+	// PDF magic bytes
+	RegisterMagicBytes(PDF, []byte("%PDF-"))
+
+	// MP4 magic bytes (ISO BMFF container with video brands)
+	RegisterMagicBytes(MP4, []byte("????ftypisom"),
+		[]byte("????ftypiso2"),
+		[]byte("????ftypiso4"),
+		[]byte("????ftypiso5"),
+		[]byte("????ftypiso6"),
+		[]byte("????ftypmp41"),
+		[]byte("????ftypmp42"),
+		[]byte("????ftypavc1"),
+		[]byte("????ftypdash"),
+		[]byte("????ftypM4V "))
+
+	// MOV magic bytes
+	RegisterMagicBytes(MOV, []byte("????ftypqt  "))
+
+	// WEBM magic bytes (EBML header)
+	RegisterMagicBytes(WEBM, []byte{0x1a, 0x45, 0xdf, 0xa3})
 }
@@ -0,0 +1,51 @@
+package imagetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// IsAnimatedPNG detects if the PNG image is animated (APNG).
+// APNG images have an acTL chunk before the first IDAT chunk.
+func IsAnimatedPNG(r io.Reader) (bool, error) {
+	sig := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, sig); err != nil {
+		return false, err
+	}
+
+	if !bytes.Equal(sig, pngSignature) {
+		return false, nil
+	}
+
+	// Chunk header: 4 bytes of data length and 4 bytes of chunk type
+	header := make([]byte, 8)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		switch string(header[4:]) {
+		case "acTL":
+			return true, nil
+		case "IDAT", "IEND":
+			return false, nil
+		}
+
+		// Skip chunk data and CRC
+		size := int64(binary.BigEndian.Uint32(header[:4])) + 4
+		if _, err := io.CopyN(io.Discard, r, size); err != nil {
+			if errors.Is(err, io.EOF) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+}
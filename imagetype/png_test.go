@@ -0,0 +1,56 @@
+package imagetype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+)
+
+// pngChunk creates a PNG chunk with the given type and data.
+// CRC is not checked by the detection, so we leave it zero
+func pngChunk(typ string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return append(chunk, 0, 0, 0, 0)
+}
+
+func pngData(chunks ...[]byte) []byte {
+	data := []byte("\x89PNG\r\n\x1a\n")
+	for _, c := range chunks {
+		data = append(data, c...)
+	}
+	return data
+}
+
+func TestIsAnimatedPNG(t *testing.T) {
+	ihdr := pngChunk("IHDR", make([]byte, 13))
+	actl := pngChunk("acTL", make([]byte, 8))
+	idat := pngChunk("IDAT", make([]byte, 16))
+	iend := pngChunk("IEND", nil)
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"Still", pngData(ihdr, idat, iend), false},
+		{"Animated", pngData(ihdr, actl, idat, iend), true},
+		{"AnimatedAfterMetadata", pngData(ihdr, pngChunk("iCCP", make([]byte, 1024)), actl, idat, iend), true},
+		{"acTLAfterIDAT", pngData(ihdr, idat, actl, iend), false},
+		{"Truncated", pngData(ihdr, pngChunk("iCCP", make([]byte, 1024))[:64]), false},
+		{"NotPNG", []byte("GIF89a\x00\x00\x00\x00"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imagetype.IsAnimatedPNG(bytes.NewReader(tt.data))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -21,6 +21,8 @@ type TypeDesc struct {
 	Ext                   string
 	Mime                  string
 	IsVector              bool
+	IsVideo               bool
+	IsPseudo              bool
 	SupportsAlpha         bool
 	SupportsColourProfile bool
 	SupportsQuality       bool
@@ -71,8 +71,8 @@ func TestTypeProperties(t *testing.T) {
 			expectAlpha:         true,
 			expectColourProfile: true,
 			expectQuality:       false,
-			expectAnimationLoad: false,
-			expectAnimationSave: false,
+			expectAnimationLoad: true,
+			expectAnimationSave: true,
 			expectThumbnail:     false,
 		},
 		{
@@ -127,9 +127,20 @@ func TestTypeProperties(t *testing.T) {
 			expectColourProfile: true,
 			expectQuality:       true,
 			expectAnimationLoad: false,
-			expectAnimationSave: false,
+			expectAnimationSave: true,
 			expectThumbnail:     true,
 		},
+		{
+			name:                "PDF",
+			typ:                 imagetype.PDF,
+			expectVector:        true,
+			expectAlpha:         false,
+			expectColourProfile: false,
+			expectQuality:       false,
+			expectAnimationLoad: false,
+			expectAnimationSave: false,
+			expectThumbnail:     false,
+		},
 	}
 
 	for _, tt := range tests {
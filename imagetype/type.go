@@ -72,6 +72,26 @@ func (t Type) IsVector() bool {
 	return false
 }
 
+// IsVideo checks if the image type is a video format.
+// Video sources can't be loaded directly and require frame extraction.
+func (t Type) IsVideo() bool {
+	desc := GetTypeDesc(t)
+	if desc != nil {
+		return desc.IsVideo
+	}
+	return false
+}
+
+// IsPseudo checks if the image type is a pseudo-format.
+// Pseudo-formats are not images but data calculated from the processed image.
+func (t Type) IsPseudo() bool {
+	desc := GetTypeDesc(t)
+	if desc != nil {
+		return desc.IsPseudo
+	}
+	return false
+}
+
 // SupportsAlpha checks if the image type supports alpha transparency.
 func (t Type) SupportsAlpha() bool {
 	desc := GetTypeDesc(t)
@@ -3,6 +3,7 @@ package imagetype_test
 import (
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -15,6 +16,8 @@ func TestDefaultTypesRegistered(t *testing.T) {
 	defaultTypes := []imagetype.Type{
 		imagetype.JPEG, imagetype.JXL, imagetype.PNG, imagetype.WEBP, imagetype.GIF,
 		imagetype.ICO, imagetype.SVG, imagetype.HEIC, imagetype.AVIF, imagetype.BMP, imagetype.TIFF,
+		imagetype.PDF, imagetype.MP4, imagetype.MOV, imagetype.WEBM,
+		imagetype.BLURHASH, imagetype.THUMBHASH, imagetype.PALETTE,
 	}
 
 	for _, typ := range defaultTypes {
@@ -66,3 +69,51 @@ func TestDetect(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectPDF(t *testing.T) {
+	data := "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<< /Type /Catalog >>\nendobj\n"
+
+	got, err := imagetype.Detect(strings.NewReader(data), "", "")
+	require.NoError(t, err)
+	require.Equal(t, imagetype.PDF, got)
+}
+
+func TestDetectAVIF(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Still", "\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1miaf"},
+		{"Sequence", "\x00\x00\x00\x20ftypavis\x00\x00\x00\x00avisavifmsf1miaf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imagetype.Detect(strings.NewReader(tt.data), "", "")
+			require.NoError(t, err)
+			require.Equal(t, imagetype.AVIF, got)
+		})
+	}
+}
+
+func TestDetectVideo(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want imagetype.Type
+	}{
+		{"MP4", "\x00\x00\x00\x20ftypisom\x00\x00\x02\x00isomiso2avc1mp41", imagetype.MP4},
+		{"MP4_mp42", "\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom", imagetype.MP4},
+		{"MOV", "\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00qt  ", imagetype.MOV},
+		{"WEBM", "\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\x82\x84webm", imagetype.WEBM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imagetype.Detect(strings.NewReader(tt.data), "", "")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.True(t, got.IsVideo())
+		})
+	}
+}
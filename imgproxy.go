@@ -11,6 +11,7 @@ import (
 	"github.com/imgproxy/imgproxy/v4/errorreport"
 	"github.com/imgproxy/imgproxy/v4/fetcher"
 	healthhandler "github.com/imgproxy/imgproxy/v4/handlers/health"
+	infohandler "github.com/imgproxy/imgproxy/v4/handlers/info"
 	landinghandler "github.com/imgproxy/imgproxy/v4/handlers/landing"
 	processinghandler "github.com/imgproxy/imgproxy/v4/handlers/processing"
 	streamhandler "github.com/imgproxy/imgproxy/v4/handlers/stream"
@@ -18,8 +19,10 @@ import (
 	"github.com/imgproxy/imgproxy/v4/imagedata"
 	"github.com/imgproxy/imgproxy/v4/memory"
 	"github.com/imgproxy/imgproxy/v4/monitoring"
+	"github.com/imgproxy/imgproxy/v4/options/keys"
 	optionsparser "github.com/imgproxy/imgproxy/v4/options/parser"
 	"github.com/imgproxy/imgproxy/v4/processing"
+	"github.com/imgproxy/imgproxy/v4/resultcache"
 	"github.com/imgproxy/imgproxy/v4/security"
 	"github.com/imgproxy/imgproxy/v4/server"
 	"github.com/imgproxy/imgproxy/v4/workers"
@@ -28,12 +31,14 @@ import (
 const (
 	faviconPath   = "/favicon.ico"
 	healthPath    = "/health"
+	infoPath      = "/info/*"
 	wellKnownPath = "/.well-known/*"
 )
 
 // ImgproxyHandlers holds the handlers for imgproxy.
 type ImgproxyHandlers struct {
 	Health     *healthhandler.Handler
+	Info       *infohandler.Handler
 	Landing    *landinghandler.Handler
 	Processing *processinghandler.Handler
 	Stream     *streamhandler.Handler
@@ -56,6 +61,7 @@ type Imgproxy struct {
 	config                 *Config
 	errorReporter          *errorreport.Reporter
 	conditionalHeaders     *conditionalheaders.Factory
+	resultCache            *resultcache.Cache
 }
 
 // New creates a new imgproxy instance.
@@ -98,6 +104,15 @@ func New(ctx context.Context, config *Config) (*Imgproxy, error) {
 		return nil, err
 	}
 
+	// Watermark URL provider falls back to the static watermark
+	// when the watermark URL is not specified in the processing options
+	watermarkImage, err = auximageprovider.NewURLProvider(
+		&config.WatermarkURL, keys.WatermarkURL, "watermark", watermarkImage, idf, securityChecker,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	workers, err := workers.New(&config.Workers)
 	if err != nil {
 		return nil, err
@@ -123,6 +138,11 @@ func New(ctx context.Context, config *Config) (*Imgproxy, error) {
 		return nil, err
 	}
 
+	resultCache, err := resultcache.New(&config.ResultCache)
+	if err != nil {
+		return nil, err
+	}
+
 	imgproxy := &Imgproxy{
 		workers:                workers,
 		fallbackImage:          fallbackImage,
@@ -138,10 +158,12 @@ func New(ctx context.Context, config *Config) (*Imgproxy, error) {
 		monitoring:             monitoring,
 		errorReporter:          errorReporter,
 		conditionalHeaders:     conditionalHeaders,
+		resultCache:            resultCache,
 	}
 
 	imgproxy.handlers.Health = healthhandler.New()
 	imgproxy.handlers.Landing = landinghandler.New()
+	imgproxy.handlers.Info = infohandler.New(imgproxy)
 
 	imgproxy.handlers.Stream, err = streamhandler.New(imgproxy, &config.Handlers.Stream)
 	if err != nil {
@@ -176,6 +198,11 @@ func (i *Imgproxy) BuildRouter() (*server.Router, error) {
 		r.GET(i.config.Server.HealthCheckPath, i.handlers.Health.Execute).Silent()
 	}
 
+	r.GET(
+		infoPath, i.handlers.Info.Execute,
+		r.WithSecret, r.WithCORS, r.WithPanic, r.WithReportError, r.WithMonitoring,
+	)
+
 	r.GET(
 		"/*", i.handlers.Processing.Execute,
 		r.WithSecret, r.WithCORS, r.WithPanic, r.WithReportError, r.WithMonitoring,
@@ -285,6 +312,10 @@ func (i *Imgproxy) ConditionalHeaders() *conditionalheaders.Factory {
 	return i.conditionalHeaders
 }
 
+func (i *Imgproxy) ResultCache() *resultcache.Cache {
+	return i.resultCache
+}
+
 // startMemoryTicker starts a ticker that periodically frees memory and optionally logs memory stats
 func (i *Imgproxy) startMemoryTicker(ctx context.Context) {
 	ticker := time.NewTicker(i.config.Server.FreeMemoryInterval)
@@ -0,0 +1,62 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/imgproxy/imgproxy/v4/httpheaders"
+	"github.com/imgproxy/imgproxy/v4/testutil/servertest"
+	"github.com/stretchr/testify/suite"
+)
+
+// InfoHandlerTestSuite is a test suite for testing image info handler
+type InfoHandlerTestSuite struct {
+	servertest.Suite
+}
+
+func (s *InfoHandlerTestSuite) SetupSubTest() {
+	s.ResetLazyObjects()
+}
+
+func (s *InfoHandlerTestSuite) TestInfo() {
+	res := s.GET("/info/unsafe/plain/local:///test1.png")
+	defer res.Body.Close()
+
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal("application/json", res.Header.Get(httpheaders.ContentType))
+
+	body, err := io.ReadAll(res.Body)
+	s.Require().NoError(err)
+
+	var info map[string]any
+	s.Require().NoError(json.Unmarshal(body, &info))
+
+	s.Require().Equal("png", info["format"])
+	s.Require().InDelta(10, info["width"], 0)
+	s.Require().InDelta(10, info["height"], 0)
+	s.Require().InDelta(1, info["pages"], 0)
+	s.Require().Equal(false, info["has_alpha"])
+}
+
+func (s *InfoHandlerTestSuite) TestInfoSignatureValidationFailure() {
+	s.Config().Security.Keys = [][]byte{[]byte("test-key")}
+	s.Config().Security.Salts = [][]byte{[]byte("test-salt")}
+
+	res := s.GET("/info/unsafe/plain/local:///test1.png")
+	defer res.Body.Close()
+
+	s.Require().Equal(http.StatusForbidden, res.StatusCode)
+}
+
+func (s *InfoHandlerTestSuite) TestInfoInvalidFormat() {
+	res := s.GET("/info/unsafe/plain/local:///geometry.png@xyz")
+	defer res.Body.Close()
+
+	s.Require().Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func TestInfoHandler(t *testing.T) {
+	suite.Run(t, new(InfoHandlerTestSuite))
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -673,6 +675,178 @@ func (s *ProcessingHandlerTestSuite) TestPresetUsage() {
 	s.Require().False(s.TestData.FileEqualsToReader("geometry.png", res.Body))
 }
 
+func (s *ProcessingHandlerTestSuite) TestResultCache() {
+	s.Config().ResultCache.Path = s.T().TempDir()
+
+	var bodies [2][]byte
+
+	for i := range bodies {
+		res := s.GET("/unsafe/rs:fill:4:4/plain/local:///test1.png")
+		defer res.Body.Close()
+
+		s.Require().Equal(http.StatusOK, res.StatusCode)
+
+		body, err := io.ReadAll(res.Body)
+		s.Require().NoError(err)
+
+		bodies[i] = body
+	}
+
+	s.Require().Equal(bodies[0], bodies[1])
+
+	stats := s.Imgproxy().Monitoring().Stats()
+	s.Require().InDelta(1.0, stats.ResultCacheMisses(), 0)
+	s.Require().InDelta(1.0, stats.ResultCacheHits(), 0)
+}
+
+func (s *ProcessingHandlerTestSuite) TestResultCacheNotModified() {
+	s.Config().ResultCache.Path = s.T().TempDir()
+	s.Config().ConditionalHeaders.ETagEnabled = true
+
+	etag := `"loremipsumdolor"`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set(httpheaders.Etag, etag)
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(s.TestData.Read("test1.png"))
+	}))
+	defer ts.Close()
+
+	path := "/unsafe/rs:fill:4:4/plain/" + ts.URL
+
+	res := s.GET(path)
+	defer res.Body.Close()
+
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+
+	header := make(http.Header)
+	header.Set(httpheaders.IfNoneMatch, etag)
+
+	// The cached result should be checked against the conditional headers
+	res = s.GET(path, header)
+	defer res.Body.Close()
+
+	s.Require().Equal(http.StatusNotModified, res.StatusCode)
+	s.Require().Equal(etag, res.Header.Get(httpheaders.Etag))
+
+	stats := s.Imgproxy().Monitoring().Stats()
+	s.Require().InDelta(1.0, stats.ResultCacheHits(), 0)
+}
+
+func (s *ProcessingHandlerTestSuite) TestResultCacheCookiePassthrough() {
+	s.Config().ResultCache.Path = s.T().TempDir()
+	s.Config().Cookies.CookiePassthrough = true
+	s.Config().Cookies.CookiePassthroughAll = true
+
+	var hits atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(s.TestData.Read("test1.png"))
+	}))
+	defer ts.Close()
+
+	path := "/unsafe/rs:fill:4:4/plain/" + ts.URL
+
+	for _, cookie := range []string{"user=1", "user=2"} {
+		header := make(http.Header)
+		header.Set(httpheaders.Cookie, cookie)
+
+		res := s.GET(path, header)
+		defer res.Body.Close()
+
+		s.Require().Equal(http.StatusOK, res.StatusCode)
+	}
+
+	// Results that may depend on user cookies should not be cached
+	s.Require().Equal(int32(2), hits.Load())
+
+	stats := s.Imgproxy().Monitoring().Stats()
+	s.Require().InDelta(0.0, stats.ResultCacheHits(), 0)
+}
+
+func (s *ProcessingHandlerTestSuite) TestResultCachePaletteHeader() {
+	s.Config().ResultCache.Path = s.T().TempDir()
+
+	var palettes [2]string
+
+	for i := range palettes {
+		res := s.GET("/unsafe/rs:fill:4:4/palette:3/plain/local:///test1.png")
+		defer res.Body.Close()
+
+		s.Require().Equal(http.StatusOK, res.StatusCode)
+
+		palettes[i] = res.Header.Get(httpheaders.XPalette)
+	}
+
+	// The palette header should be restored from the cached result
+	s.Require().NotEmpty(palettes[0])
+	s.Require().Equal(palettes[0], palettes[1])
+
+	stats := s.Imgproxy().Monitoring().Stats()
+	s.Require().InDelta(1.0, stats.ResultCacheMisses(), 0)
+	s.Require().InDelta(1.0, stats.ResultCacheHits(), 0)
+}
+
+func (s *ProcessingHandlerTestSuite) TestCoalesceRequests() {
+	s.Config().Handlers.Processing.CoalesceRequests = true
+
+	const requestsCount = 5
+
+	var hits atomic.Int32
+
+	firstHit := make(chan struct{})
+	release := make(chan struct{})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			close(firstHit)
+		}
+
+		<-release
+
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(s.TestData.Read("test1.png"))
+	}))
+	defer ts.Close()
+
+	path := "/unsafe/rs:fill:4:4/plain/" + ts.URL
+
+	// Make sure the server is started before making concurrent requests
+	s.Server()
+
+	var wg sync.WaitGroup
+
+	statusCodes := make([]int, requestsCount)
+	bodies := make([][]byte, requestsCount)
+
+	for i := range requestsCount {
+		wg.Go(func() {
+			res := s.GET(path)
+			defer res.Body.Close()
+
+			statusCodes[i] = res.StatusCode
+			bodies[i], _ = io.ReadAll(res.Body)
+		})
+	}
+
+	// Give the rest of the requests some time to join the first one
+	<-firstHit
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+
+	wg.Wait()
+
+	s.Require().EqualValues(1, hits.Load())
+
+	for i := range requestsCount {
+		s.Require().Equal(http.StatusOK, statusCodes[i])
+		s.Require().Equal(bodies[0], bodies[i])
+	}
+}
+
 func TestProcessingHandler(t *testing.T) {
 	suite.Run(t, new(ProcessingHandlerTestSuite))
 }
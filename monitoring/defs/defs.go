@@ -28,6 +28,12 @@ const (
 	WorkersUtilization     = "workers_utilization"
 	WorkersUtilizationDesc = "A gauge of the workers utilization in percents."
 
+	ResultCacheHitsTotal     = "result_cache_hits_total"
+	ResultCacheHitsTotalDesc = "A counter of the result cache hits."
+
+	ResultCacheMissesTotal     = "result_cache_misses_total"
+	ResultCacheMissesTotalDesc = "A counter of the result cache misses."
+
 	VipsMemoryBytes     = "vips_memory_bytes"
 	VipsMemoryBytesDesc = "A gauge of the vips tracked memory usage in bytes."
 
@@ -106,6 +106,18 @@ func New(config *Config, stats *stats.Stats) (*Prometheus, error) {
 		Help:      defs.WorkersUtilizationDesc,
 	}, stats.WorkersUtilization)
 
+	resultCacheHits := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Name:      defs.ResultCacheHitsTotal,
+		Help:      defs.ResultCacheHitsTotalDesc,
+	}, stats.ResultCacheHits)
+
+	resultCacheMisses := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Name:      defs.ResultCacheMissesTotal,
+		Help:      defs.ResultCacheMissesTotalDesc,
+	}, stats.ResultCacheMisses)
+
 	vipsMemoryBytes := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
 		Namespace: config.Namespace,
 		Name:      defs.VipsMemoryBytes,
@@ -134,6 +146,8 @@ func New(config *Config, stats *stats.Stats) (*Prometheus, error) {
 		requestsInProgress,
 		imagesInProgress,
 		workersUtilization,
+		resultCacheHits,
+		resultCacheMisses,
 		vipsMemoryBytes,
 		vipsMaxMemoryBytes,
 		vipsAllocs,
@@ -8,6 +8,8 @@ import (
 type Stats struct {
 	requestsInProgress atomic.Int64
 	imagesInProgress   atomic.Int64
+	resultCacheHits    atomic.Int64
+	resultCacheMisses  atomic.Int64
 	WorkersNumber      int
 }
 
@@ -48,6 +50,26 @@ func (s *Stats) DecImagesInProgress() {
 	s.imagesInProgress.Add(-1)
 }
 
+// ResultCacheHits returns the total number of result cache hits
+func (s *Stats) ResultCacheHits() float64 {
+	return float64(s.resultCacheHits.Load())
+}
+
+// IncResultCacheHits increments the result cache hits counter
+func (s *Stats) IncResultCacheHits() {
+	s.resultCacheHits.Add(1)
+}
+
+// ResultCacheMisses returns the total number of result cache misses
+func (s *Stats) ResultCacheMisses() float64 {
+	return float64(s.resultCacheMisses.Load())
+}
+
+// IncResultCacheMisses increments the result cache misses counter
+func (s *Stats) IncResultCacheMisses() {
+	s.resultCacheMisses.Add(1)
+}
+
 // WorkersUtilization returns the current workers utilization percentage
 func (s *Stats) WorkersUtilization() float64 {
 	if s.WorkersNumber == 0 {
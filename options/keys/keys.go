@@ -1,6 +1,9 @@
 package keys
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 const (
 	Width  = "width"
@@ -16,14 +19,17 @@ const (
 	ExtendGravityType    = ExtendGravity + SuffixType
 	ExtendGravityXOffset = ExtendGravity + SuffixXOffset
 	ExtendGravityYOffset = ExtendGravity + SuffixYOffset
+	ExtendFill           = PrefixExtend + SuffixFill
 
 	ExtendAspectRatioEnabled        = PrefixExtendAspectRatio + SuffixEnabled
 	ExtendAspectRatioGravity        = PrefixExtendAspectRatio + SuffixGravity
 	ExtendAspectRatioGravityType    = ExtendAspectRatioGravity + SuffixType
 	ExtendAspectRatioGravityXOffset = ExtendAspectRatioGravity + SuffixXOffset
 	ExtendAspectRatioGravityYOffset = ExtendAspectRatioGravity + SuffixYOffset
+	ExtendAspectRatioFill           = PrefixExtendAspectRatio + SuffixFill
 
-	ResizingType = "resizing_type"
+	ResizingType      = "resizing_type"
+	ResizingAlgorithm = "resizing_algorithm"
 
 	ZoomWidth  = "zoom_width"
 	ZoomHeight = "zoom_height"
@@ -46,13 +52,19 @@ const (
 	PaddingRight  = "padding.right"
 	PaddingBottom = "padding.bottom"
 	PaddingLeft   = "padding.left"
+	PaddingFill   = "padding" + SuffixFill
+
+	MaskType   = "mask.type"
+	MaskRadius = "mask.radius"
 
 	TrimThreshold = "trim.threshold"
 	TrimColor     = "trim.color"
 	TrimEqualHor  = "trim.equal_horizontal"
 	TrimEqualVer  = "trim.equal_vertical"
 
-	Rotate = "rotate"
+	Rotate         = "rotate"
+	RotateFree     = "rotate_free"
+	RotateAutoCrop = "rotate_auto_crop"
 
 	FlipHorizontal = "flip.horizontal"
 	FlipVertical   = "flip.vertical"
@@ -61,19 +73,60 @@ const (
 
 	MaxBytes = "max_bytes"
 
+	AutoqualityMethod       = "autoquality.method"
+	AutoqualityTarget       = "autoquality.target"
+	AutoqualityMinQuality   = "autoquality.min_quality"
+	AutoqualityMaxQuality   = "autoquality.max_quality"
+	AutoqualityAllowedError = "autoquality.allowed_error"
+
 	Background = "background"
 
 	Blur     = "blur"
 	Sharpen  = "sharpen"
 	Pixelate = "pixelate"
 
+	Brightness = "brightness"
+	Contrast   = "contrast"
+	Saturation = "saturation"
+	Gamma      = "gamma"
+
 	WatermarkOpacity  = "watermark.opacity"
 	WatermarkPosition = "watermark.position"
 	WatermarkXOffset  = "watermark" + SuffixXOffset
 	WatermarkYOffset  = "watermark" + SuffixYOffset
 	WatermarkScale    = "watermark.scale"
+	WatermarkURL      = "watermark_url"
+
+	WatermarkText      = "watermark_text.text"
+	WatermarkTextFont  = "watermark_text.font"
+	WatermarkTextSize  = "watermark_text.size"
+	WatermarkTextColor = "watermark_text.color"
+
+	Format     = "format"
+	BestFormat = "best_format"
+
+	Lossless     = "lossless.enabled"
+	LosslessNear = "lossless.near"
+
+	JpegProgressive  = "jpeg_options.progressive"
+	JpegNoSubsample  = "jpeg_options.no_subsample"
+	JpegTrellisQuant = "jpeg_options.trellis_quant"
+
+	PngInterlaced         = "png_options.interlaced"
+	PngQuantize           = "png_options.quantize"
+	PngQuantizationColors = "png_options.quantization_colors"
+
+	WebpPreset   = "webp_options.preset"
+	WebpEffort   = "webp_options.effort"
+	WebpLossless = "webp_options.lossless"
 
-	Format = "format"
+	AvifSpeed    = "avif_options.speed"
+	AvifLossless = "avif_options.lossless"
+
+	JxlEffort   = "jxl_options.effort"
+	JxlLossless = "jxl_options.lossless"
+
+	Palette = "palette"
 
 	CacheBuster = "cachebuster"
 
@@ -95,6 +148,19 @@ const (
 
 	EnforceThumbnail = "enforce_thumbnail"
 
+	Page  = "page"
+	Pages = "pages"
+
+	AnimationDropFrames = "animation.drop_frames"
+	AnimationMaxFPS     = "animation.max_fps"
+	AnimationSpeed      = "animation.speed"
+	AnimationLoop       = "animation.loop"
+
+	VideoThumbnailSecond            = "video_thumbnail.second"
+	VideoThumbnailBestFrame         = "video_thumbnail.best_frame"
+	VideoThumbnailAnimationDuration = "video_thumbnail.animation.duration"
+	VideoThumbnailAnimationFPS      = "video_thumbnail.animation.fps"
+
 	ReturnAttachment = "return_attachment"
 
 	MaxSrcResolution            = "max_src_resolution"
@@ -102,6 +168,7 @@ const (
 	MaxAnimationFrames          = "max_animation_frames"
 	MaxAnimationFrameResolution = "max_animation_frame_resolution"
 	MaxResultDimension          = "max_result_dimension"
+	MaxPdfPages                 = "max_pdf_pages"
 
 	PreferWebP  = "prefer_webp"
 	EnforceWebP = "enforce_webp"
@@ -117,13 +184,26 @@ const (
 
 	PrefixFormatQuality = "format_quality"
 
+	PrefixWatermarkLayer = "watermark_layer"
+
 	SuffixEnabled = ".enabled"
 	SuffixGravity = ".gravity"
 	SuffixType    = ".type"
 	SuffixXOffset = ".x_offset"
 	SuffixYOffset = ".y_offset"
+	SuffixFill    = ".fill"
 )
 
 func FormatQuality(format fmt.Stringer) string {
 	return PrefixFormatQuality + "." + format.String()
 }
+
+// WatermarkLayer returns the key of the watermark option for the given layer.
+// The first layer (0) uses the base watermark option keys.
+func WatermarkLayer(layer int, key string) string {
+	if layer == 0 {
+		return key
+	}
+
+	return PrefixWatermarkLayer + "." + strconv.Itoa(layer) + "." + key
+}
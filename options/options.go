@@ -56,6 +56,18 @@ func (o *Options) Descendants() iter.Seq[*Options] {
 	}
 }
 
+// Last returns the last descendant of the Options.
+// If the Options has no child, it returns itself.
+func (o *Options) Last() *Options {
+	last := o
+
+	for c := range o.Descendants() {
+		last = c
+	}
+
+	return last
+}
+
 // HasChild checks if the Options has a child Options.
 func (o *Options) HasChild() bool {
 	return o.child != nil
@@ -177,6 +189,15 @@ func (o *Options) CopyValue(fromKey, toKey string) {
 	}
 }
 
+// Clone returns a copy of the Options that doesn't share the underlying map.
+// The copy keeps the reference to the main Options but doesn't have a child.
+func (o *Options) Clone() *Options {
+	return &Options{
+		m:    maps.Clone(o.m),
+		main: o.main,
+	}
+}
+
 // Has checks if an option key exists.
 func (o *Options) Has(key string) bool {
 	_, ok := o.m[key]
@@ -140,6 +140,28 @@ func (s *OptionsTestSuite) TestCopyValue() {
 	s.Require().Equal(200, options.Get(o, "key3", 0))
 }
 
+func (s *OptionsTestSuite) TestClone() {
+	o := options.New()
+	o.Set("key1", 100)
+
+	child := o.AddChild()
+	child.Set("key2", 200)
+
+	c := child.Clone()
+	c.Set("key1", 300)
+	c.Set("key2", 400)
+
+	s.Require().Same(o, c.Main())
+	s.Require().False(c.HasChild())
+	s.Require().Equal(300, options.Get(c, "key1", 0))
+	s.Require().Equal(400, options.Get(c, "key2", 0))
+
+	// The original Options are not modified
+	s.Require().False(child.Has("key1"))
+	s.Require().Equal(200, options.Get(child, "key2", 0))
+	s.Require().Equal(100, options.Get(o, "key1", 0))
+}
+
 func (s *OptionsTestSuite) TestGetInt() {
 	o := options.New()
 	o.Set("int", 42)
@@ -231,6 +253,16 @@ func (s *OptionsTestSuite) TestDepth() {
 	s.Require().Equal(2, o.Child().Child().Depth())
 }
 
+func (s *OptionsTestSuite) TestLast() {
+	o := testNestedOptions()
+
+	s.Require().Same(o.Child().Child(), o.Last())
+	s.Require().Same(o.Child().Child(), o.Child().Last())
+
+	single := testOptions()
+	s.Require().Same(single, single.Last())
+}
+
 func (s *OptionsTestSuite) TestMap() {
 	s.Run("WithoutChildren", func() {
 		o := testOptions()
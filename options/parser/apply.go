@@ -4,14 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
+	"net/url"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/imgproxy/imgproxy/v4/imagetype"
 	"github.com/imgproxy/imgproxy/v4/options"
 	"github.com/imgproxy/imgproxy/v4/options/keys"
 	"github.com/imgproxy/imgproxy/v4/processing"
+	"github.com/imgproxy/imgproxy/v4/processing/palette"
+	"github.com/imgproxy/imgproxy/v4/vips"
 	"github.com/imgproxy/imgproxy/v4/vips/color"
 )
 
@@ -44,7 +49,7 @@ func (p *Parser) applyExtendAspectRatioOption(ctx context.Context, o *options.Op
 }
 
 func (p *Parser) applySizeOption(ctx context.Context, o *options.Options, args []string) (err error) {
-	if err = p.ensureMaxArgs(ctx, "size", args, 7); err != nil {
+	if err = p.ensureMaxArgs(ctx, "size", args, 8); err != nil {
 		return
 	}
 
@@ -79,8 +84,12 @@ func (p *Parser) applyResizingTypeOption(ctx context.Context, o *options.Options
 	return parseFromMap(ctx, p, o, keys.ResizingType, processing.ResizeTypes, args...)
 }
 
+func (p *Parser) applyResizingAlgorithmOption(ctx context.Context, o *options.Options, args []string) error {
+	return parseFromMap(ctx, p, o, keys.ResizingAlgorithm, vips.Kernels, args...)
+}
+
 func (p *Parser) applyResizeOption(ctx context.Context, o *options.Options, args []string) error {
-	if err := p.ensureMaxArgs(ctx, "resize", args, 8); err != nil {
+	if err := p.ensureMaxArgs(ctx, "resize", args, 9); err != nil {
 		return err
 	}
 
@@ -147,7 +156,7 @@ func (p *Parser) applyCropOption(ctx context.Context, o *options.Options, args [
 }
 
 func (p *Parser) applyPaddingOption(ctx context.Context, o *options.Options, args []string) error {
-	if err := p.ensureMaxArgs(ctx, "padding", args, 4); err != nil {
+	if err := p.ensureMaxArgs(ctx, "padding", args, 5); err != nil {
 		return err
 	}
 
@@ -181,6 +190,14 @@ func (p *Parser) applyPaddingOption(ctx context.Context, o *options.Options, arg
 		o.CopyValue(keys.PaddingRight, keys.PaddingLeft)
 	}
 
+	if len(args) > 4 && len(args[4]) > 0 {
+		if err := parseFromMap(ctx, p, o, keys.PaddingFill, processing.ExtendFills, args[4]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.PaddingFill)
+	}
+
 	return nil
 }
 
@@ -222,13 +239,60 @@ func (p *Parser) applyTrimOption(ctx context.Context, o *options.Options, args [
 	return nil
 }
 
+func (p *Parser) applyMaskOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "mask", args, 2); err != nil {
+		return err
+	}
+
+	if len(args[0]) > 0 {
+		if err := parseFromMap(ctx, p, o, keys.MaskType, processing.MaskTypes, args[0]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.MaskType)
+	}
+
+	if len(args) > 1 && len(args[1]) > 0 {
+		if err := p.parsePositiveFloat(ctx, o, keys.MaskRadius, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.MaskRadius)
+	}
+
+	return nil
+}
+
 func (p *Parser) applyRotateOption(ctx context.Context, o *options.Options, args []string) error {
-	if err := p.parseInt(ctx, o, keys.Rotate, args...); err != nil {
+	if err := p.ensureMaxArgs(ctx, "rotate", args, 2); err != nil {
 		return err
 	}
 
-	if options.Get(o, keys.Rotate, 0)%90 != 0 {
-		return newOptionArgumentError(ctx, keys.Rotate, "Rotation angle must be a multiple of 90")
+	angle, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || math.IsInf(angle, 0) || math.IsNaN(angle) {
+		return newInvalidArgumentError(ctx, keys.Rotate, args[0], "angle in degrees")
+	}
+
+	// Split the angle into the multiple of 90 and the remaining free angle.
+	// Rotation by a multiple of 90 is lossless and doesn't change the image geometry
+	// much, so we handle it separately from the free rotation.
+	right := int(math.Round(angle/90)) * 90
+	free := angle - float64(right)
+
+	o.Set(keys.Rotate, (right%360+360)%360)
+
+	if free != 0 {
+		o.Set(keys.RotateFree, free)
+	} else {
+		o.Delete(keys.RotateFree)
+	}
+
+	if len(args) > 1 && len(args[1]) > 0 {
+		if err := p.parseBool(ctx, o, keys.RotateAutoCrop, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.RotateAutoCrop)
 	}
 
 	return nil
@@ -288,6 +352,56 @@ func (p *Parser) applyMaxBytesOption(ctx context.Context, o *options.Options, ar
 	return p.parsePositiveInt(ctx, o, keys.MaxBytes, args...)
 }
 
+func (p *Parser) applyAutoqualityOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "autoquality", args, 5); err != nil {
+		return err
+	}
+
+	nArgs := len(args)
+
+	if len(args[0]) > 0 {
+		if err := parseFromMap(ctx, p, o, keys.AutoqualityMethod, processing.AutoqualityMethods, args[0]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.AutoqualityMethod)
+	}
+
+	if nArgs > 1 && len(args[1]) > 0 {
+		if err := p.parsePositiveNonZeroFloat(ctx, o, keys.AutoqualityTarget, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.AutoqualityTarget)
+	}
+
+	if nArgs > 2 && len(args[2]) > 0 {
+		if err := p.parseQualityInt(ctx, o, keys.AutoqualityMinQuality, 1, args[2]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.AutoqualityMinQuality)
+	}
+
+	if nArgs > 3 && len(args[3]) > 0 {
+		if err := p.parseQualityInt(ctx, o, keys.AutoqualityMaxQuality, 1, args[3]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.AutoqualityMaxQuality)
+	}
+
+	if nArgs > 4 && len(args[4]) > 0 {
+		if err := p.parsePositiveFloat(ctx, o, keys.AutoqualityAllowedError, args[4]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.AutoqualityAllowedError)
+	}
+
+	return nil
+}
+
 func (p *Parser) applyBackgroundOption(ctx context.Context, o *options.Options, args []string) error {
 	switch len(args) {
 	case 1:
@@ -342,37 +456,171 @@ func (p *Parser) applyPixelateOption(ctx context.Context, o *options.Options, ar
 	return p.parsePositiveInt(ctx, o, keys.Pixelate, args...)
 }
 
-func (p *Parser) applyWatermarkOption(ctx context.Context, o *options.Options, args []string) error {
+func (p *Parser) applyBrightnessOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.parseInt(ctx, o, keys.Brightness, args...); err != nil {
+		return err
+	}
+
+	if b := o.GetInt(keys.Brightness, 0); b < -255 || b > 255 {
+		return newInvalidArgumentError(ctx, keys.Brightness, args[0], "number in range -255-255")
+	}
+
+	return nil
+}
+
+func (p *Parser) applyContrastOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveFloat(ctx, o, keys.Contrast, args...)
+}
+
+func (p *Parser) applySaturationOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveFloat(ctx, o, keys.Saturation, args...)
+}
+
+func (p *Parser) applyGammaOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveNonZeroFloat(ctx, o, keys.Gamma, args...)
+}
+
+func (p *Parser) applyAdjustOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "adjust", args, 4); err != nil {
+		return err
+	}
+
+	if len(args[0]) > 0 {
+		if err := p.applyBrightnessOption(ctx, o, args[0:1]); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 1 && len(args[1]) > 0 {
+		if err := p.applyContrastOption(ctx, o, args[1:2]); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 2 && len(args[2]) > 0 {
+		if err := p.applySaturationOption(ctx, o, args[2:3]); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 3 && len(args[3]) > 0 {
+		if err := p.applyGammaOption(ctx, o, args[3:4]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Parser) applyWatermarkOption(
+	ctx context.Context,
+	o *options.Options,
+	layer int,
+	args []string,
+) error {
 	if err := p.ensureMaxArgs(ctx, "watermark", args, 7); err != nil {
 		return err
 	}
 
-	if err := p.parseOpacityFloat(ctx, o, keys.WatermarkOpacity, args[0]); err != nil {
+	if err := p.parseOpacityFloat(
+		ctx, o, keys.WatermarkLayer(layer, keys.WatermarkOpacity), args[0],
+	); err != nil {
 		return err
 	}
 
 	if len(args) > 1 && len(args[1]) > 0 {
 		if _, err := p.parseGravityType(
-			ctx, o, keys.WatermarkPosition, processing.WatermarkGravityTypes, args[1],
+			ctx, o, keys.WatermarkLayer(layer, keys.WatermarkPosition), processing.WatermarkGravityTypes, args[1],
 		); err != nil {
 			return err
 		}
 	}
 
 	if len(args) > 2 && len(args[2]) > 0 {
-		if err := p.parseFloat(ctx, o, keys.WatermarkXOffset, args[2]); err != nil {
+		if err := p.parseFloat(ctx, o, keys.WatermarkLayer(layer, keys.WatermarkXOffset), args[2]); err != nil {
 			return err
 		}
 	}
 
 	if len(args) > 3 && len(args[3]) > 0 {
-		if err := p.parseFloat(ctx, o, keys.WatermarkYOffset, args[3]); err != nil {
+		if err := p.parseFloat(ctx, o, keys.WatermarkLayer(layer, keys.WatermarkYOffset), args[3]); err != nil {
 			return err
 		}
 	}
 
 	if len(args) > 4 && len(args[4]) > 0 {
-		if err := p.parsePositiveNonZeroFloat(ctx, o, keys.WatermarkScale, args[4]); err != nil {
+		if err := p.parsePositiveNonZeroFloat(
+			ctx, o, keys.WatermarkLayer(layer, keys.WatermarkScale), args[4],
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Parser) applyWatermarkURLOption(
+	ctx context.Context,
+	o *options.Options,
+	layer int,
+	args []string,
+) error {
+	key := keys.WatermarkLayer(layer, keys.WatermarkURL)
+
+	if err := p.ensureMaxArgs(ctx, key, args, 1); err != nil {
+		return err
+	}
+
+	if len(args[0]) == 0 {
+		o.Delete(key)
+		return nil
+	}
+
+	return p.parseBase64String(ctx, o, key, args[0])
+}
+
+func (p *Parser) applyWatermarkTextOption(
+	ctx context.Context,
+	o *options.Options,
+	layer int,
+	args []string,
+) error {
+	textKey := keys.WatermarkLayer(layer, keys.WatermarkText)
+
+	if err := p.ensureMaxArgs(ctx, textKey, args, 4); err != nil {
+		return err
+	}
+
+	if len(args[0]) == 0 {
+		o.Delete(textKey)
+	} else if err := p.parseBase64String(ctx, o, textKey, args[0]); err != nil {
+		return err
+	}
+
+	if len(args) > 1 && len(args[1]) > 0 {
+		fontKey := keys.WatermarkLayer(layer, keys.WatermarkTextFont)
+
+		font, err := url.PathUnescape(args[1])
+		if err != nil {
+			return newInvalidArgumentError(ctx, fontKey, args[1], "URL-encoded font description")
+		}
+
+		o.Set(fontKey, font)
+	}
+
+	if len(args) > 2 && len(args[2]) > 0 {
+		if err := p.parseIntInRange(
+			ctx, o, keys.WatermarkLayer(layer, keys.WatermarkTextSize),
+			1, p.config.MaxWatermarkTextSize, args[2],
+		); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 3 && len(args[3]) > 0 {
+		if err := p.parseHexRGBColor(
+			ctx, o, keys.WatermarkLayer(layer, keys.WatermarkTextColor), args[3],
+		); err != nil {
 			return err
 		}
 	}
@@ -380,13 +628,52 @@ func (p *Parser) applyWatermarkOption(ctx context.Context, o *options.Options, a
 	return nil
 }
 
+// applyWatermarkLayerOption applies a watermark option to an additional watermark layer.
+// Additional layer options are named as the base watermark options
+// with the layer index suffix (e.g. `watermark_1`, `wmu_2`).
+// It returns false if the name is not a watermark layer option name.
+func (p *Parser) applyWatermarkLayerOption(
+	ctx context.Context,
+	o *options.Options,
+	name string,
+	args []string,
+) (bool, error) {
+	i := strings.LastIndexByte(name, '_')
+	if i < 0 {
+		return false, nil
+	}
+
+	layer, err := strconv.Atoi(name[i+1:])
+	if err != nil || layer < 1 || layer >= processing.WatermarkLayersCount {
+		return false, nil
+	}
+
+	switch name[:i] {
+	case "watermark", "wm":
+		return true, p.applyWatermarkOption(ctx, o, layer, args)
+	case "watermark_text", "wmt":
+		return true, p.applyWatermarkTextOption(ctx, o, layer, args)
+	case "watermark_url", "wmu":
+		return true, p.applyWatermarkURLOption(ctx, o, layer, args)
+	}
+
+	return false, nil
+}
+
 func (p *Parser) applyFormatOption(ctx context.Context, o *options.Options, args []string) error {
 	if err := p.ensureMaxArgs(ctx, keys.Format, args, 1); err != nil {
 		return err
 	}
 
+	if args[0] == "best" {
+		o.Delete(keys.Format)
+		o.Set(keys.BestFormat, true)
+		return nil
+	}
+
 	if f, ok := imagetype.GetTypeByName(args[0]); ok {
 		o.Set(keys.Format, f)
+		o.Delete(keys.BestFormat)
 	} else {
 		return newInvalidArgumentError(ctx, keys.Format, args[0], "supported image format")
 	}
@@ -394,6 +681,191 @@ func (p *Parser) applyFormatOption(ctx context.Context, o *options.Options, args
 	return nil
 }
 
+func (p *Parser) applyLosslessOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "lossless", args, 2); err != nil {
+		return err
+	}
+
+	if err := p.parseBool(ctx, o, keys.Lossless, args[0]); err != nil {
+		return err
+	}
+
+	if len(args) > 1 && len(args[1]) > 0 {
+		if err := p.parseBool(ctx, o, keys.LosslessNear, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.LosslessNear)
+	}
+
+	return nil
+}
+
+func (p *Parser) applyJpegOptionsOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "jpeg_options", args, 3); err != nil {
+		return err
+	}
+
+	nArgs := len(args)
+
+	if len(args[0]) > 0 {
+		if err := p.parseBool(ctx, o, keys.JpegProgressive, args[0]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.JpegProgressive)
+	}
+
+	if nArgs > 1 && len(args[1]) > 0 {
+		if err := p.parseBool(ctx, o, keys.JpegNoSubsample, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.JpegNoSubsample)
+	}
+
+	if nArgs > 2 && len(args[2]) > 0 {
+		if err := p.parseBool(ctx, o, keys.JpegTrellisQuant, args[2]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.JpegTrellisQuant)
+	}
+
+	return nil
+}
+
+func (p *Parser) applyPngOptionsOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "png_options", args, 3); err != nil {
+		return err
+	}
+
+	nArgs := len(args)
+
+	if len(args[0]) > 0 {
+		if err := p.parseBool(ctx, o, keys.PngInterlaced, args[0]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.PngInterlaced)
+	}
+
+	if nArgs > 1 && len(args[1]) > 0 {
+		if err := p.parseBool(ctx, o, keys.PngQuantize, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.PngQuantize)
+	}
+
+	if nArgs > 2 && len(args[2]) > 0 {
+		if err := p.parseIntInRange(ctx, o, keys.PngQuantizationColors, 2, 256, args[2]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.PngQuantizationColors)
+	}
+
+	return nil
+}
+
+func (p *Parser) applyWebpOptionsOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "webp_options", args, 3); err != nil {
+		return err
+	}
+
+	nArgs := len(args)
+
+	if len(args[0]) > 0 {
+		if err := parseFromMap(ctx, p, o, keys.WebpPreset, vips.WebpPresets, args[0]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.WebpPreset)
+	}
+
+	if nArgs > 1 && len(args[1]) > 0 {
+		if err := p.parseIntInRange(ctx, o, keys.WebpEffort, 1, 6, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.WebpEffort)
+	}
+
+	if nArgs > 2 && len(args[2]) > 0 {
+		if err := p.parseBool(ctx, o, keys.WebpLossless, args[2]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.WebpLossless)
+	}
+
+	return nil
+}
+
+func (p *Parser) applyAvifOptionsOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "avif_options", args, 2); err != nil {
+		return err
+	}
+
+	if len(args[0]) > 0 {
+		if err := p.parseIntInRange(ctx, o, keys.AvifSpeed, 0, 9, args[0]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.AvifSpeed)
+	}
+
+	if len(args) > 1 && len(args[1]) > 0 {
+		if err := p.parseBool(ctx, o, keys.AvifLossless, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.AvifLossless)
+	}
+
+	return nil
+}
+
+func (p *Parser) applyJxlOptionsOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "jxl_options", args, 2); err != nil {
+		return err
+	}
+
+	if len(args[0]) > 0 {
+		if err := p.parseIntInRange(ctx, o, keys.JxlEffort, 1, 9, args[0]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.JxlEffort)
+	}
+
+	if len(args) > 1 && len(args[1]) > 0 {
+		if err := p.parseBool(ctx, o, keys.JxlLossless, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.JxlLossless)
+	}
+
+	return nil
+}
+
+func (p *Parser) applyPaletteOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, keys.Palette, args, 1); err != nil {
+		return err
+	}
+
+	i, err := strconv.Atoi(args[0])
+	if err != nil || i < 0 || i > palette.MaxColors {
+		return newInvalidArgumentError(ctx, keys.Palette, args[0], fmt.Sprintf("number in range 0-%d", palette.MaxColors))
+	}
+
+	o.Set(keys.Palette, i)
+
+	return nil
+}
+
 func (p *Parser) applyCacheBusterOption(ctx context.Context, o *options.Options, args []string) error {
 	if err := p.ensureMaxArgs(ctx, keys.CacheBuster, args, 1); err != nil {
 		return err
@@ -490,6 +962,68 @@ func (p *Parser) applyEnforceThumbnailOption(ctx context.Context, o *options.Opt
 	return p.parseBool(ctx, o, keys.EnforceThumbnail, args...)
 }
 
+func (p *Parser) applyPageOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveInt(ctx, o, keys.Page, args...)
+}
+
+func (p *Parser) applyPagesOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveNonZeroInt(ctx, o, keys.Pages, args...)
+}
+
+func (p *Parser) applyDropFramesOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, keys.AnimationDropFrames, args, 1); err != nil {
+		return err
+	}
+
+	// Dropping every frame makes no sense, so 1 is not allowed.
+	// 0 disables frames dropping
+	if n, err := strconv.Atoi(args[0]); err != nil || n < 0 || n == 1 {
+		return newInvalidArgumentError(ctx, keys.AnimationDropFrames, args[0], "0 or number greater than 1")
+	}
+
+	return p.parsePositiveInt(ctx, o, keys.AnimationDropFrames, args...)
+}
+
+func (p *Parser) applyMaxFPSOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveFloat(ctx, o, keys.AnimationMaxFPS, args...)
+}
+
+func (p *Parser) applyAnimationSpeedOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveNonZeroFloat(ctx, o, keys.AnimationSpeed, args...)
+}
+
+func (p *Parser) applyAnimationLoopOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveInt(ctx, o, keys.AnimationLoop, args...)
+}
+
+func (p *Parser) applyVideoThumbnailSecondOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parsePositiveFloat(ctx, o, keys.VideoThumbnailSecond, args...)
+}
+
+func (p *Parser) applyVideoThumbnailBestFrameOption(ctx context.Context, o *options.Options, args []string) error {
+	return p.parseBool(ctx, o, keys.VideoThumbnailBestFrame, args...)
+}
+
+func (p *Parser) applyVideoThumbnailAnimationOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.ensureMaxArgs(ctx, "video_thumbnail_animation", args, 2); err != nil {
+		return err
+	}
+
+	if err := p.parsePositiveFloat(ctx, o, keys.VideoThumbnailAnimationDuration, args[0]); err != nil {
+		return err
+	}
+
+	if len(args) > 1 && len(args[1]) > 0 {
+		if err := p.parsePositiveNonZeroFloat(ctx, o, keys.VideoThumbnailAnimationFPS, args[1]); err != nil {
+			return err
+		}
+	} else {
+		o.Delete(keys.VideoThumbnailAnimationFPS)
+	}
+
+	return nil
+}
+
 func (p *Parser) applyReturnAttachmentOption(ctx context.Context, o *options.Options, args []string) error {
 	return p.parseBool(ctx, o, keys.ReturnAttachment, args...)
 }
@@ -538,6 +1072,14 @@ func (p *Parser) applyMaxResultDimensionOption(
 	return p.parseInt(ctx, o, keys.MaxResultDimension, args...)
 }
 
+func (p *Parser) applyMaxPdfPagesOption(ctx context.Context, o *options.Options, args []string) error {
+	if err := p.IsSecurityOptionsAllowed(ctx); err != nil {
+		return err
+	}
+
+	return p.parsePositiveInt(ctx, o, keys.MaxPdfPages, args...)
+}
+
 func (p *Parser) applyPresetOption(
 	ctx context.Context,
 	o *options.Options,
@@ -20,6 +20,8 @@ var (
 	IMGPROXY_BASE_URL                     = env.String("IMGPROXY_BASE_URL")
 	IMGPROXY_URL_REPLACEMENTS             = env.URLReplacements("IMGPROXY_URL_REPLACEMENTS")
 	IMGPROXY_BASE64_URL_INCLUDES_FILENAME = env.Bool("IMGPROXY_BASE64_URL_INCLUDES_FILENAME")
+	IMGPROXY_SOURCE_URL_ENCRYPTION_KEY    = env.HexSlice("IMGPROXY_SOURCE_URL_ENCRYPTION_KEY")
+	IMGPROXY_MAX_WATERMARK_TEXT_SIZE      = env.Int("IMGPROXY_MAX_WATERMARK_TEXT_SIZE")
 )
 
 // Config represents the configuration for options processing
@@ -37,6 +39,12 @@ type Config struct {
 	BaseURL                   string           // Base URL for relative URLs
 	URLReplacements           []URLReplacement // URL replacement rules
 	Base64URLIncludesFilename bool             // Whether base64 URLs include filename
+
+	// Source URL encryption
+	SourceURLEncryptionKeys [][]byte // List of AES keys used to decrypt source URLs
+
+	// Limits
+	MaxWatermarkTextSize int // Maximum allowed watermark text font size
 }
 
 // NewDefaultConfig creates a new default configuration for options processing
@@ -52,6 +60,9 @@ func NewDefaultConfig() Config {
 		ArgumentsSeparator:        ":",
 		BaseURL:                   "",
 		Base64URLIncludesFilename: false,
+
+		// Limits
+		MaxWatermarkTextSize: 256,
 	}
 }
 
@@ -79,6 +90,12 @@ func LoadConfigFromEnv(c *Config) (*Config, error) {
 		IMGPROXY_BASE64_URL_INCLUDES_FILENAME.Parse(&c.Base64URLIncludesFilename),
 
 		IMGPROXY_URL_REPLACEMENTS.Parse(&c.URLReplacements),
+
+		// Source URL encryption
+		IMGPROXY_SOURCE_URL_ENCRYPTION_KEY.Parse(&c.SourceURLEncryptionKeys),
+
+		// Limits
+		IMGPROXY_MAX_WATERMARK_TEXT_SIZE.Parse(&c.MaxWatermarkTextSize),
 	)
 
 	c.Presets = append(c.Presets, presetsFromFile...)
@@ -93,5 +110,18 @@ func (c *Config) Validate() error {
 		return IMGPROXY_ARGUMENTS_SEPARATOR.ErrorEmpty()
 	}
 
+	// Source URL encryption keys should be valid AES-128, AES-192, or AES-256 keys
+	for _, key := range c.SourceURLEncryptionKeys {
+		if l := len(key); l != 16 && l != 24 && l != 32 {
+			return IMGPROXY_SOURCE_URL_ENCRYPTION_KEY.Errorf(
+				"invalid key size: %d bytes (expected 16, 24, or 32)", l,
+			)
+		}
+	}
+
+	if c.MaxWatermarkTextSize <= 0 {
+		return IMGPROXY_MAX_WATERMARK_TEXT_SIZE.ErrorZeroOrNegative()
+	}
+
 	return nil
 }
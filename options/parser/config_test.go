@@ -120,3 +120,33 @@ func TestPresetsFromBothSources(t *testing.T) {
 	require.NotNil(t, cfg)
 	require.Equal(t, []string{"preset1", "preset2", "file_preset1", "file_preset2"}, cfg.Presets)
 }
+
+func TestSourceURLEncryptionKeys(t *testing.T) {
+	// Setup environment with two keys for rotation
+	t.Setenv(
+		"IMGPROXY_SOURCE_URL_ENCRYPTION_KEY",
+		"1eb5b0e971ad7f45324c1bb15c947cb207c43152fa5c6c7f35c4f36e0c18e0f1,"+
+			"000102030405060708090a0b0c0d0e0f",
+	)
+
+	// Load config
+	cfg, err := optionsparser.LoadConfigFromEnv(nil)
+
+	// Verify
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.Len(t, cfg.SourceURLEncryptionKeys, 2)
+	require.NoError(t, cfg.Validate())
+}
+
+func TestSourceURLEncryptionKeyInvalidSize(t *testing.T) {
+	// Setup environment with a key of invalid size
+	t.Setenv("IMGPROXY_SOURCE_URL_ENCRYPTION_KEY", "0001020304")
+
+	// Load config
+	cfg, err := optionsparser.LoadConfigFromEnv(nil)
+
+	// Verify that validation fails
+	require.NoError(t, err)
+	require.Error(t, cfg.Validate())
+}
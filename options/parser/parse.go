@@ -177,6 +177,28 @@ func (p *Parser) parsePositiveInt(
 	return nil
 }
 
+// parseIntInRange parses an integer option value in the given range (minV-maxV)
+func (p *Parser) parseIntInRange(
+	ctx context.Context,
+	o *options.Options,
+	key string,
+	minV, maxV int,
+	args ...string,
+) error {
+	if err := p.ensureMaxArgs(ctx, key, args, 1); err != nil {
+		return err
+	}
+
+	i, err := strconv.Atoi(args[0])
+	if err != nil || i < minV || i > maxV {
+		return newInvalidArgumentError(ctx, key, args[0], fmt.Sprintf("number in range %d-%d", minV, maxV))
+	}
+
+	o.Set(key, i)
+
+	return nil
+}
+
 // parseQualityInt parses a quality integer option value (minQ-100)
 func (p *Parser) parseQualityInt(
 	ctx context.Context,
@@ -372,24 +394,43 @@ func (p *Parser) parseGravity(
 		fallthrough
 
 	default:
-		if nArgs > 3 {
-			return newInvalidArgsError(ctx, key, args)
-		}
+		return p.parseGravityOffsets(ctx, o, key, gType, args...)
+	}
 
-		if nArgs > 1 && len(args[1]) > 0 {
-			if x, err := strconv.ParseFloat(args[1], 64); err == nil && p.isGravityOffsetValid(gType, x) {
-				o.Set(keyXOffset, x)
-			} else {
-				return newInvalidArgumentError(ctx, keyXOffset, args[1])
-			}
+	return nil
+}
+
+// parseGravityOffsets parses gravity offsets for the given gravity type.
+// args[0] is the gravity type argument and is not parsed here.
+func (p *Parser) parseGravityOffsets(
+	ctx context.Context,
+	o *options.Options,
+	key string,
+	gType processing.GravityType,
+	args ...string,
+) error {
+	nArgs := len(args)
+
+	keyXOffset := key + keys.SuffixXOffset
+	keyYOffset := key + keys.SuffixYOffset
+
+	if nArgs > 3 {
+		return newInvalidArgsError(ctx, key, args)
+	}
+
+	if nArgs > 1 && len(args[1]) > 0 {
+		if x, err := strconv.ParseFloat(args[1], 64); err == nil && p.isGravityOffsetValid(gType, x) {
+			o.Set(keyXOffset, x)
+		} else {
+			return newInvalidArgumentError(ctx, keyXOffset, args[1])
 		}
+	}
 
-		if nArgs > 2 && len(args[2]) > 0 {
-			if y, err := strconv.ParseFloat(args[2], 64); err == nil && p.isGravityOffsetValid(gType, y) {
-				o.Set(keyYOffset, y)
-			} else {
-				return newInvalidArgumentError(ctx, keyYOffset, args[2])
-			}
+	if nArgs > 2 && len(args[2]) > 0 {
+		if y, err := strconv.ParseFloat(args[2], 64); err == nil && p.isGravityOffsetValid(gType, y) {
+			o.Set(keyYOffset, y)
+		} else {
+			return newInvalidArgumentError(ctx, keyYOffset, args[2])
 		}
 	}
 
@@ -402,7 +443,7 @@ func (p *Parser) parseExtend(
 	key string,
 	args []string,
 ) error {
-	if err := p.ensureMaxArgs(ctx, key, args, 4); err != nil {
+	if err := p.ensureMaxArgs(ctx, key, args, 5); err != nil {
 		return err
 	}
 
@@ -410,8 +451,28 @@ func (p *Parser) parseExtend(
 		return err
 	}
 
+	// Fill mode goes after the gravity arguments
+	if len(args) > 4 {
+		if len(args[4]) > 0 {
+			if err := parseFromMap(ctx, p, o, key+keys.SuffixFill, processing.ExtendFills, args[4]); err != nil {
+				return err
+			}
+		} else {
+			o.Delete(key + keys.SuffixFill)
+		}
+
+		args = args[:4]
+	}
+
 	if len(args) > 1 {
-		return p.parseGravity(ctx, o, key+keys.SuffixGravity, processing.ExtendGravityTypes, args[1:]...)
+		if len(args[1]) > 0 {
+			return p.parseGravity(ctx, o, key+keys.SuffixGravity, processing.ExtendGravityTypes, args[1:]...)
+		}
+
+		// Gravity type is omitted, so the offsets are applied to the default gravity
+		o.Delete(key + keys.SuffixGravity + keys.SuffixType)
+
+		return p.parseGravityOffsets(ctx, o, key+keys.SuffixGravity, processing.GravityCenter, args[1:]...)
 	}
 
 	return nil
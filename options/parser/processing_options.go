@@ -27,6 +27,8 @@ func (p *Parser) applyURLOption(
 		return p.applySizeOption(ctx, o, args)
 	case "resizing_type", "rt":
 		return p.applyResizingTypeOption(ctx, o, args)
+	case "resizing_algorithm", "ra":
+		return p.applyResizingAlgorithmOption(ctx, o, args)
 	case "width", "w":
 		return p.applyWidthOption(ctx, o, args)
 	case "height", "h":
@@ -53,6 +55,8 @@ func (p *Parser) applyURLOption(
 		return p.applyTrimOption(ctx, o, args)
 	case "padding", "pd":
 		return p.applyPaddingOption(ctx, o, args)
+	case "mask", "msk":
+		return p.applyMaskOption(ctx, o, args)
 	case "auto_rotate", "ar":
 		return p.applyAutoRotateOption(ctx, o, args)
 	case "rotate", "rot":
@@ -67,8 +71,22 @@ func (p *Parser) applyURLOption(
 		return p.applySharpenOption(ctx, o, args)
 	case "pixelate", "pix":
 		return p.applyPixelateOption(ctx, o, args)
+	case "adjust", "a":
+		return p.applyAdjustOption(ctx, o, args)
+	case "brightness", "br":
+		return p.applyBrightnessOption(ctx, o, args)
+	case "contrast", "co":
+		return p.applyContrastOption(ctx, o, args)
+	case "saturation", "sa":
+		return p.applySaturationOption(ctx, o, args)
+	case "gamma", "gm":
+		return p.applyGammaOption(ctx, o, args)
 	case "watermark", "wm":
-		return p.applyWatermarkOption(ctx, o, args)
+		return p.applyWatermarkOption(ctx, o, 0, args)
+	case "watermark_text", "wmt":
+		return p.applyWatermarkTextOption(ctx, o, 0, args)
+	case "watermark_url", "wmu":
+		return p.applyWatermarkURLOption(ctx, o, 0, args)
 	case "strip_metadata", "sm":
 		return p.applyStripMetadataOption(ctx, o.Main(), args)
 	case "keep_copyright", "kcr":
@@ -79,6 +97,24 @@ func (p *Parser) applyURLOption(
 		return p.applyPreserveHDROption(ctx, o.Main(), args)
 	case "enforce_thumbnail", "eth":
 		return p.applyEnforceThumbnailOption(ctx, o.Main(), args)
+	case "page", "pg":
+		return p.applyPageOption(ctx, o.Main(), args)
+	case "pages", "pgs":
+		return p.applyPagesOption(ctx, o.Main(), args)
+	case "drop_frames", "df":
+		return p.applyDropFramesOption(ctx, o.Main(), args)
+	case "max_fps", "mfps":
+		return p.applyMaxFPSOption(ctx, o.Main(), args)
+	case "animation_speed", "asp":
+		return p.applyAnimationSpeedOption(ctx, o.Main(), args)
+	case "animation_loop", "alp":
+		return p.applyAnimationLoopOption(ctx, o.Main(), args)
+	case "video_thumbnail_second", "vts":
+		return p.applyVideoThumbnailSecondOption(ctx, o.Main(), args)
+	case "video_thumbnail_best_frame", "vtbf":
+		return p.applyVideoThumbnailBestFrameOption(ctx, o.Main(), args)
+	case "video_thumbnail_animation", "vta":
+		return p.applyVideoThumbnailAnimationOption(ctx, o.Main(), args)
 	// Saving options
 	case "quality", "q":
 		return p.applyQualityOption(ctx, o.Main(), args)
@@ -86,8 +122,24 @@ func (p *Parser) applyURLOption(
 		return p.applyFormatQualityOption(ctx, o.Main(), args)
 	case "max_bytes", "mb":
 		return p.applyMaxBytesOption(ctx, o.Main(), args)
+	case "autoquality", "aq":
+		return p.applyAutoqualityOption(ctx, o.Main(), args)
 	case "format", "f", "ext":
 		return p.applyFormatOption(ctx, o.Main(), args)
+	case "palette", "pl":
+		return p.applyPaletteOption(ctx, o.Main(), args)
+	case "lossless", "ll":
+		return p.applyLosslessOption(ctx, o.Main(), args)
+	case "jpeg_options", "jpgo":
+		return p.applyJpegOptionsOption(ctx, o.Main(), args)
+	case "png_options", "pngo":
+		return p.applyPngOptionsOption(ctx, o.Main(), args)
+	case "webp_options", "webpo":
+		return p.applyWebpOptionsOption(ctx, o.Main(), args)
+	case "avif_options", "avifo":
+		return p.applyAvifOptionsOption(ctx, o.Main(), args)
+	case "jxl_options", "jxlo":
+		return p.applyJxlOptionsOption(ctx, o.Main(), args)
 	// Handling options
 	case "skip_processing", "skp":
 		return p.applySkipProcessingFormatsOption(ctx, o.Main(), args)
@@ -106,15 +158,21 @@ func (p *Parser) applyURLOption(
 		return p.applyPresetOption(ctx, o, args, usedPresets...)
 	// Security
 	case "max_src_resolution", "msr":
-		return p.applyMaxSrcResolutionOption(ctx, o, args)
+		return p.applyMaxSrcResolutionOption(ctx, o.Main(), args)
 	case "max_src_file_size", "msfs":
-		return p.applyMaxSrcFileSizeOption(ctx, o, args)
+		return p.applyMaxSrcFileSizeOption(ctx, o.Main(), args)
 	case "max_animation_frames", "maf":
 		return p.applyMaxAnimationFramesOption(ctx, o.Main(), args)
 	case "max_animation_frame_resolution", "mafr":
 		return p.applyMaxAnimationFrameResolutionOption(ctx, o.Main(), args)
 	case "max_result_dimension", "mrd":
 		return p.applyMaxResultDimensionOption(ctx, o.Main(), args)
+	case "max_pdf_pages", "mpp":
+		return p.applyMaxPdfPagesOption(ctx, o.Main(), args)
+	}
+
+	if ok, err := p.applyWatermarkLayerOption(ctx, o, name, args); ok {
+		return err
 	}
 
 	return newUnknownOptionError(ctx, "processing", name)
@@ -130,6 +188,15 @@ func (p *Parser) applyURLOptions(
 	allowAll = allowAll || len(p.config.AllowedProcessingOptions) == 0
 
 	for _, opt := range options {
+		// Options are always applied to the last pipeline of the chain
+		// that may be extended by a preset
+		o = o.Last()
+
+		if opt.Name == pipelineSeparator {
+			o = o.AddChild()
+			continue
+		}
+
 		if !allowAll && !slices.Contains(p.config.AllowedProcessingOptions, opt.Name) {
 			return newForbiddenOptionError(ctx, "processing", opt.Name)
 		}
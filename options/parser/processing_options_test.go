@@ -1,6 +1,9 @@
 package optionsparser_test
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"encoding/base64"
 	"fmt"
 	"net/url"
@@ -16,6 +19,7 @@ import (
 	optionsparser "github.com/imgproxy/imgproxy/v4/options/parser"
 	"github.com/imgproxy/imgproxy/v4/processing"
 	"github.com/imgproxy/imgproxy/v4/testutil"
+	"github.com/imgproxy/imgproxy/v4/vips"
 	"github.com/imgproxy/imgproxy/v4/vips/color"
 	"github.com/stretchr/testify/suite"
 )
@@ -107,6 +111,113 @@ func (s *ProcessingOptionsTestSuite) TestParseBase64URLWithReplacement() {
 	s.Require().Equal(imagetype.PNG, options.Get(o, keys.Format, imagetype.Unknown))
 }
 
+func (s *ProcessingOptionsTestSuite) encryptURL(originURL string, key []byte) string {
+	c, err := aes.NewCipher(key)
+	s.Require().NoError(err)
+
+	padLen := aes.BlockSize - len(originURL)%aes.BlockSize
+	data := append([]byte(originURL), bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+
+	ciphertext := make([]byte, aes.BlockSize+len(data))
+	copy(ciphertext[:aes.BlockSize], "0123456789abcdef")
+
+	cipher.NewCBCEncrypter(c, ciphertext[:aes.BlockSize]).CryptBlocks(ciphertext[aes.BlockSize:], data)
+
+	return base64.RawURLEncoding.EncodeToString(ciphertext)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParseEncryptedURL() {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	s.config().SourceURLEncryptionKeys = [][]byte{key}
+
+	originURL := "http://images.dev/lorem/ipsum.jpg?param=value"
+	path := fmt.Sprintf("/size:100:100/enc/%s.png", s.encryptURL(originURL, key))
+	o, imageURL, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+	s.Require().Equal(originURL, imageURL)
+	s.Require().Equal(imagetype.PNG, options.Get(o, keys.Format, imagetype.Unknown))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParseEncryptedURLWithAtExtension() {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	s.config().SourceURLEncryptionKeys = [][]byte{key}
+
+	originURL := "http://images.dev/lorem/ipsum.jpg?param=value"
+	path := fmt.Sprintf("/size:100:100/enc/%s@png", s.encryptURL(originURL, key))
+	o, imageURL, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+	s.Require().Equal(originURL, imageURL)
+	s.Require().Equal(imagetype.PNG, options.Get(o, keys.Format, imagetype.Unknown))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParseEncryptedURLWithoutExtension() {
+	key := []byte("0123456789abcdef")
+	s.config().SourceURLEncryptionKeys = [][]byte{key}
+
+	originURL := "http://images.dev/lorem/ipsum.jpg?param=value"
+	path := fmt.Sprintf("/size:100:100/enc/%s", s.encryptURL(originURL, key))
+	o, imageURL, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+	s.Require().Equal(originURL, imageURL)
+	s.Require().Equal(imagetype.Unknown, options.Get(o, keys.Format, imagetype.Unknown))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParseEncryptedURLWithFilename() {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	s.config().SourceURLEncryptionKeys = [][]byte{key}
+	s.config().Base64URLIncludesFilename = true
+
+	originURL := "http://images.dev/lorem/ipsum.jpg?param=value"
+	path := fmt.Sprintf("/size:100:100/enc/%s.png/puppy.jpg", s.encryptURL(originURL, key))
+	o, imageURL, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+	s.Require().Equal(originURL, imageURL)
+	s.Require().Equal(imagetype.PNG, options.Get(o, keys.Format, imagetype.Unknown))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParseEncryptedURLKeyRotation() {
+	oldKey := []byte("0123456789abcdef0123456789abcdef")
+	newKey := []byte("fedcba9876543210fedcba9876543210")
+	s.config().SourceURLEncryptionKeys = [][]byte{newKey, oldKey}
+
+	originURL := "http://images.dev/lorem/ipsum.jpg?param=value"
+
+	for _, key := range [][]byte{oldKey, newKey} {
+		path := fmt.Sprintf("/size:100:100/enc/%s.png", s.encryptURL(originURL, key))
+		_, imageURL, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+		s.Require().NoError(err)
+		s.Require().Equal(originURL, imageURL)
+	}
+}
+
+func (s *ProcessingOptionsTestSuite) TestParseEncryptedURLWrongKey() {
+	s.config().SourceURLEncryptionKeys = [][]byte{[]byte("fedcba9876543210fedcba9876543210")}
+
+	originURL := "http://images.dev/lorem/ipsum.jpg?param=value"
+	path := fmt.Sprintf(
+		"/size:100:100/enc/%s.png",
+		s.encryptURL(originURL, []byte("0123456789abcdef0123456789abcdef")),
+	)
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().ErrorAs(err, &optionsparser.InvalidURLError{})
+}
+
+func (s *ProcessingOptionsTestSuite) TestParseEncryptedURLWithoutKey() {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	originURL := "http://images.dev/lorem/ipsum.jpg?param=value"
+	path := fmt.Sprintf("/size:100:100/enc/%s.png", s.encryptURL(originURL, key))
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().ErrorAs(err, &optionsparser.InvalidURLError{})
+}
+
 func (s *ProcessingOptionsTestSuite) TestParsePlainURL() {
 	originURL := "http://images.dev/lorem/ipsum.jpg"
 	path := fmt.Sprintf("/size:100:100/plain/%s@png", originURL)
@@ -198,6 +309,125 @@ func (s *ProcessingOptionsTestSuite) TestParsePathFormat() {
 	s.Require().Equal(imagetype.WEBP, options.Get(o, keys.Format, imagetype.Unknown))
 }
 
+func (s *ProcessingOptionsTestSuite) TestParsePathFormatPlaceholder() {
+	path := "/format:blurhash/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(imagetype.BLURHASH, options.Get(o, keys.Format, imagetype.Unknown))
+
+	path = "/plain/http://images.dev/lorem/ipsum.jpg@thumbhash"
+	o, _, err = s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(imagetype.THUMBHASH, options.Get(o, keys.Format, imagetype.Unknown))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathPalette() {
+	path := "/palette:8/format:palette/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(8, o.GetInt(keys.Palette, 0))
+	s.Require().Equal(imagetype.PALETTE, options.Get(o, keys.Format, imagetype.Unknown))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathPaletteInvalid() {
+	path := "/palette:100/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+	s.Require().ErrorContains(err, "Invalid palette")
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathLossless() {
+	path := "/lossless:1:1/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().True(o.GetBool(keys.Lossless, false))
+	s.Require().True(o.GetBool(keys.LosslessNear, false))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathEncoderOptions() {
+	path := "/jpgo:1::1/pngo:0:1:64/webpo:drawing:6/avifo:5:1/jxlo::1/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().True(o.GetBool(keys.JpegProgressive, false))
+	s.Require().False(o.Has(keys.JpegNoSubsample))
+	s.Require().True(o.GetBool(keys.JpegTrellisQuant, false))
+
+	s.Require().False(o.GetBool(keys.PngInterlaced, true))
+	s.Require().True(o.GetBool(keys.PngQuantize, false))
+	s.Require().Equal(64, o.GetInt(keys.PngQuantizationColors, 0))
+
+	s.Require().Equal(
+		vips.WebpPreset(vips.WebpPresetDrawing),
+		options.Get(o, keys.WebpPreset, vips.WebpPreset(vips.WebpPresetDefault)),
+	)
+	s.Require().Equal(6, o.GetInt(keys.WebpEffort, 0))
+	s.Require().False(o.Has(keys.WebpLossless))
+
+	s.Require().Equal(5, o.GetInt(keys.AvifSpeed, 0))
+	s.Require().True(o.GetBool(keys.AvifLossless, false))
+
+	s.Require().False(o.Has(keys.JxlEffort))
+	s.Require().True(o.GetBool(keys.JxlLossless, false))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathEncoderOptionsInvalid() {
+	testCases := []string{
+		"/jpeg_options:1:1:1:1/plain/http://images.dev/lorem/ipsum.jpg",
+		"/png_options:1:1:1/plain/http://images.dev/lorem/ipsum.jpg",
+		"/png_options:1:1:300/plain/http://images.dev/lorem/ipsum.jpg",
+		"/webp_options:cartoon/plain/http://images.dev/lorem/ipsum.jpg",
+		"/webp_options::7/plain/http://images.dev/lorem/ipsum.jpg",
+		"/avif_options:10/plain/http://images.dev/lorem/ipsum.jpg",
+		"/jxl_options:0/plain/http://images.dev/lorem/ipsum.jpg",
+	}
+
+	for _, path := range testCases {
+		_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+		s.Require().Error(err, path)
+	}
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathFormatBest() {
+	path := "/format:best/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().True(o.GetBool(keys.BestFormat, false))
+	s.Require().False(o.Has(keys.Format))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathFormatBestOverriddenByExtension() {
+	path := "/format:best/plain/http://images.dev/lorem/ipsum.jpg@png"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(imagetype.PNG, options.Get(o, keys.Format, imagetype.Unknown))
+	s.Require().False(o.Has(keys.BestFormat))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathFormatBestExtension() {
+	path := "/format:webp/plain/http://images.dev/lorem/ipsum.jpg@best"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().True(o.GetBool(keys.BestFormat, false))
+	s.Require().False(o.Has(keys.Format))
+}
+
 func (s *ProcessingOptionsTestSuite) TestParsePathResize() {
 	path := "/resize:fill:100:200:1/plain/http://images.dev/lorem/ipsum.jpg"
 	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
@@ -219,6 +449,26 @@ func (s *ProcessingOptionsTestSuite) TestParsePathResizingType() {
 	s.Require().Equal(processing.ResizeFill, options.Get(o, keys.ResizingType, processing.ResizeFit))
 }
 
+func (s *ProcessingOptionsTestSuite) TestParsePathResizingAlgorithm() {
+	path := "/resizing_algorithm:nearest/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(
+		vips.Kernel(vips.KernelNearest),
+		options.Get(o, keys.ResizingAlgorithm, vips.Kernel(vips.KernelLanczos3)),
+	)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathResizingAlgorithmInvalid() {
+	path := "/ra:bicubic/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &optionsparser.OptionArgumentError{})
+}
+
 func (s *ProcessingOptionsTestSuite) TestParsePathSize() {
 	path := "/size:100:200:1/plain/http://images.dev/lorem/ipsum.jpg"
 	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
@@ -272,6 +522,64 @@ func (s *ProcessingOptionsTestSuite) TestParsePathExtend() {
 	s.Require().InDelta(20.0, o.GetFloat(keys.ExtendGravityYOffset, 0.0), 0.0001)
 }
 
+func (s *ProcessingOptionsTestSuite) TestParsePathExtendFill() {
+	path := "/extend:1:so:10:20:blur/extend_ar:1::::mirror/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(
+		processing.GravitySouth,
+		options.Get(o, keys.ExtendGravityType, processing.GravityUnknown),
+	)
+	s.Require().Equal(
+		processing.ExtendFillBlur,
+		options.Get(o, keys.ExtendFill, processing.ExtendFillBackground),
+	)
+
+	s.Require().True(o.GetBool(keys.ExtendAspectRatioEnabled, false))
+	s.Require().False(o.Has(keys.ExtendAspectRatioGravityType))
+	s.Require().Equal(
+		processing.ExtendFillMirror,
+		options.Get(o, keys.ExtendAspectRatioFill, processing.ExtendFillBackground),
+	)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathExtendOffsetsWithoutGravity() {
+	path := "/extend:1::10:20:blur/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().False(o.Has(keys.ExtendGravityType))
+	s.Require().InDelta(10.0, o.GetFloat(keys.ExtendGravityXOffset, 0.0), 0.0001)
+	s.Require().InDelta(20.0, o.GetFloat(keys.ExtendGravityYOffset, 0.0), 0.0001)
+	s.Require().Equal(
+		processing.ExtendFillBlur,
+		options.Get(o, keys.ExtendFill, processing.ExtendFillBackground),
+	)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathSizeExtendFill() {
+	path := "/size:100:200:0:1:ce:0:0:edge/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().True(o.GetBool(keys.ExtendEnabled, false))
+	s.Require().Equal(
+		processing.ExtendFillEdge,
+		options.Get(o, keys.ExtendFill, processing.ExtendFillBackground),
+	)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathExtendFillInvalid() {
+	path := "/extend:1:ce:0:0:stretch/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+}
+
 func (s *ProcessingOptionsTestSuite) TestParsePathExtendSmartGravity() {
 	path := "/extend:1:sm/plain/http://images.dev/lorem/ipsum.jpg"
 	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
@@ -416,6 +724,136 @@ func (s *ProcessingOptionsTestSuite) TestParsePathSharpen() {
 	s.Require().InDelta(0.2, o.GetFloat(keys.Sharpen, 0.0), 0.0001)
 }
 
+func (s *ProcessingOptionsTestSuite) TestParsePathAdjust() {
+	path := "/adjust:-20:1.2:0.5:2.2/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(-20, o.GetInt(keys.Brightness, 0))
+	s.Require().InDelta(1.2, o.GetFloat(keys.Contrast, 1.0), 0.0001)
+	s.Require().InDelta(0.5, o.GetFloat(keys.Saturation, 1.0), 0.0001)
+	s.Require().InDelta(2.2, o.GetFloat(keys.Gamma, 1.0), 0.0001)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathAdjustPartial() {
+	path := "/adjust::1.2/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().False(o.Has(keys.Brightness))
+	s.Require().InDelta(1.2, o.GetFloat(keys.Contrast, 1.0), 0.0001)
+	s.Require().False(o.Has(keys.Saturation))
+	s.Require().False(o.Has(keys.Gamma))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathBrightnessInvalid() {
+	path := "/brightness:300/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathGammaInvalid() {
+	path := "/gamma:0/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathPaddingFill() {
+	path := "/padding:10:20:::blur/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(10, o.GetInt(keys.PaddingTop, 0))
+	s.Require().Equal(20, o.GetInt(keys.PaddingRight, 0))
+	s.Require().Equal(10, o.GetInt(keys.PaddingBottom, 0))
+	s.Require().Equal(20, o.GetInt(keys.PaddingLeft, 0))
+	s.Require().Equal(
+		processing.ExtendFillBlur,
+		options.Get(o, keys.PaddingFill, processing.ExtendFillBackground),
+	)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathRotate() {
+	testCases := []struct {
+		arg      string
+		rotate   int
+		free     float64
+		autoCrop bool
+	}{
+		{"90", 90, 0, false},
+		{"-90", 270, 0, false},
+		{"450", 90, 0, false},
+		{"12.5:1", 0, 12.5, true},
+		{"100", 90, 10, false},
+		{"-30:true", 0, -30, true},
+		{"135", 180, -45, false},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.arg, func() {
+			path := fmt.Sprintf("/rotate:%s/plain/http://images.dev/lorem/ipsum.jpg", tc.arg)
+			o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+			s.Require().NoError(err)
+
+			s.Require().Equal(tc.rotate, o.GetInt(keys.Rotate, 0))
+			s.Require().InDelta(tc.free, o.GetFloat(keys.RotateFree, 0.0), 0.0001)
+			s.Require().Equal(tc.autoCrop, o.GetBool(keys.RotateAutoCrop, false))
+		})
+	}
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathRotateInvalid() {
+	testCases := []string{
+		"/rotate:abc/plain/http://images.dev/lorem/ipsum.jpg",
+		"/rotate:10:maybe/plain/http://images.dev/lorem/ipsum.jpg",
+		"/rotate:10:1:1/plain/http://images.dev/lorem/ipsum.jpg",
+	}
+
+	for _, path := range testCases {
+		_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+		s.Require().Error(err, path)
+	}
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathMask() {
+	path := "/mask:rounded:12.5/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(processing.MaskRoundedRect, options.Get(o, keys.MaskType, processing.MaskNone))
+	s.Require().InDelta(12.5, o.GetFloat(keys.MaskRadius, 0.0), 0.0001)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathMaskCircle() {
+	path := "/msk:circle/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(processing.MaskCircle, options.Get(o, keys.MaskType, processing.MaskNone))
+	s.Require().False(o.Has(keys.MaskRadius))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathMaskInvalid() {
+	testCases := []string{
+		"/mask:star/plain/http://images.dev/lorem/ipsum.jpg",
+		"/mask:rounded:-5/plain/http://images.dev/lorem/ipsum.jpg",
+		"/mask:rounded:5:1/plain/http://images.dev/lorem/ipsum.jpg",
+	}
+
+	for _, path := range testCases {
+		_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+		s.Require().Error(err, path)
+	}
+}
+
 func (s *ProcessingOptionsTestSuite) TestParsePathDpr() {
 	path := "/dpr:2/plain/http://images.dev/lorem/ipsum.jpg"
 	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
@@ -441,6 +879,279 @@ func (s *ProcessingOptionsTestSuite) TestParsePathWatermark() {
 	s.Require().InDelta(0.6, o.GetFloat(keys.WatermarkScale, 0.0), 0.0001)
 }
 
+func (s *ProcessingOptionsTestSuite) TestParsePathWatermarkText() {
+	text := base64.RawURLEncoding.EncodeToString([]byte("© Lorem Ipsum"))
+
+	path := fmt.Sprintf("/watermark_text:%s:serif%%20bold:32:ff0000/plain/http://images.dev/lorem/ipsum.jpg", text)
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal("© Lorem Ipsum", o.GetString(keys.WatermarkText, ""))
+	s.Require().Equal("serif bold", o.GetString(keys.WatermarkTextFont, ""))
+	s.Require().Equal(32, o.GetInt(keys.WatermarkTextSize, 0))
+	s.Require().Equal(color.RGB{R: 255, G: 0, B: 0}, options.Get(o, keys.WatermarkTextColor, color.RGB{}))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathWatermarkTextInvalidSize() {
+	path := "/wmt:dGVzdA::0/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &optionsparser.OptionArgumentError{})
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathWatermarkTextTooLargeSize() {
+	s.config().MaxWatermarkTextSize = 100
+
+	path := "/wmt:dGVzdA::100/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+	s.Require().Equal(100, o.GetInt(keys.WatermarkTextSize, 0))
+
+	path = "/wmt:dGVzdA::101/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err = s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &optionsparser.OptionArgumentError{})
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathWatermarkURL() {
+	wmURL := base64.RawURLEncoding.EncodeToString([]byte("https://images.dev/watermark.png"))
+
+	path := fmt.Sprintf("/watermark_url:%s/plain/http://images.dev/lorem/ipsum.jpg", wmURL)
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal("https://images.dev/watermark.png", o.GetString(keys.WatermarkURL, ""))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathWatermarkURLEmpty() {
+	path := "/wmu:dGVzdA/wmu:/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().False(o.Has(keys.WatermarkURL))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathWatermarkLayers() {
+	wmURL := base64.RawURLEncoding.EncodeToString([]byte("https://images.dev/watermark.png"))
+	text := base64.RawURLEncoding.EncodeToString([]byte("Lorem Ipsum"))
+
+	path := fmt.Sprintf(
+		"/wm:0.5:nowe/wm_1:0.7:soea:10:20:0.3/wmu_1:%s/watermark_text_2:%s::16/plain/http://images.dev/lorem/ipsum.jpg",
+		wmURL, text,
+	)
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().InDelta(0.5, o.GetFloat(keys.WatermarkOpacity, 0.0), 0.0001)
+	s.Require().Equal(
+		processing.GravityNorthWest,
+		options.Get(o, keys.WatermarkPosition, processing.GravityUnknown),
+	)
+	s.Require().False(o.Has(keys.WatermarkURL))
+
+	s.Require().InDelta(0.7, o.GetFloat(keys.WatermarkLayer(1, keys.WatermarkOpacity), 0.0), 0.0001)
+	s.Require().Equal(
+		processing.GravitySouthEast,
+		options.Get(o, keys.WatermarkLayer(1, keys.WatermarkPosition), processing.GravityUnknown),
+	)
+	s.Require().InDelta(10.0, o.GetFloat(keys.WatermarkLayer(1, keys.WatermarkXOffset), 0.0), 0.0001)
+	s.Require().InDelta(20.0, o.GetFloat(keys.WatermarkLayer(1, keys.WatermarkYOffset), 0.0), 0.0001)
+	s.Require().InDelta(0.3, o.GetFloat(keys.WatermarkLayer(1, keys.WatermarkScale), 0.0), 0.0001)
+	s.Require().Equal(
+		"https://images.dev/watermark.png",
+		o.GetString(keys.WatermarkLayer(1, keys.WatermarkURL), ""),
+	)
+
+	s.Require().Equal("Lorem Ipsum", o.GetString(keys.WatermarkLayer(2, keys.WatermarkText), ""))
+	s.Require().Equal(16, o.GetInt(keys.WatermarkLayer(2, keys.WatermarkTextSize), 0))
+	s.Require().False(o.Has(keys.WatermarkLayer(2, keys.WatermarkOpacity)))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathWatermarkLayerOutOfRange() {
+	path := fmt.Sprintf(
+		"/wm_%d:0.5/plain/http://images.dev/lorem/ipsum.jpg",
+		processing.WatermarkLayersCount,
+	)
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &optionsparser.UnknownOptionError{})
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathPage() {
+	path := "/page:2/pages:3/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(2, o.GetInt(keys.Page, 0))
+	s.Require().Equal(3, o.GetInt(keys.Pages, 0))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathPagesInvalid() {
+	path := "/pgs:0/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &optionsparser.OptionArgumentError{})
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathAnimation() {
+	path := "/df:3/mfps:12.5/asp:1.5/alp:2/plain/http://images.dev/lorem/ipsum.gif"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(3, o.GetInt(keys.AnimationDropFrames, 0))
+	s.Require().InDelta(12.5, o.GetFloat(keys.AnimationMaxFPS, 0), 0.0001)
+	s.Require().InDelta(1.5, o.GetFloat(keys.AnimationSpeed, 0), 0.0001)
+	s.Require().Equal(2, o.GetInt(keys.AnimationLoop, -1))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathAnimationLoopInfinite() {
+	path := "/animation_loop:0/plain/http://images.dev/lorem/ipsum.gif"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().True(o.Has(keys.AnimationLoop))
+	s.Require().Equal(0, o.GetInt(keys.AnimationLoop, -1))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathAnimationInvalid() {
+	paths := []string{
+		"/df:1/plain/http://images.dev/lorem/ipsum.gif",
+		"/df:-2/plain/http://images.dev/lorem/ipsum.gif",
+		"/mfps:-1/plain/http://images.dev/lorem/ipsum.gif",
+		"/asp:0/plain/http://images.dev/lorem/ipsum.gif",
+		"/alp:-1/plain/http://images.dev/lorem/ipsum.gif",
+	}
+
+	for _, path := range paths {
+		_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+		s.Require().Error(err, path)
+		s.Require().ErrorAs(err, &optionsparser.OptionArgumentError{}, path)
+	}
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathVideoThumbnail() {
+	path := "/vts:2.5/vtbf:1/vta:3:12/plain/http://images.dev/lorem/ipsum.mp4"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().InDelta(2.5, o.GetFloat(keys.VideoThumbnailSecond, 0), 0.0001)
+	s.Require().True(o.GetBool(keys.VideoThumbnailBestFrame, false))
+	s.Require().InDelta(3.0, o.GetFloat(keys.VideoThumbnailAnimationDuration, 0), 0.0001)
+	s.Require().InDelta(12.0, o.GetFloat(keys.VideoThumbnailAnimationFPS, 0), 0.0001)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathVideoThumbnailAnimationInvalidFPS() {
+	path := "/vta:3:0/plain/http://images.dev/lorem/ipsum.mp4"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &optionsparser.OptionArgumentError{})
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathAutoquality() {
+	path := "/aq:dssim:0.015:50:85:0.002/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(
+		processing.AutoqualityDssim,
+		options.Get(o, keys.AutoqualityMethod, processing.AutoqualityNone),
+	)
+	s.Require().InDelta(0.015, o.GetFloat(keys.AutoqualityTarget, 0), 0.0001)
+	s.Require().Equal(50, o.GetInt(keys.AutoqualityMinQuality, 0))
+	s.Require().Equal(85, o.GetInt(keys.AutoqualityMaxQuality, 0))
+	s.Require().InDelta(0.002, o.GetFloat(keys.AutoqualityAllowedError, 0), 0.0001)
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathAutoqualityMethodOnly() {
+	path := "/aq:dssim/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(
+		processing.AutoqualityDssim,
+		options.Get(o, keys.AutoqualityMethod, processing.AutoqualityNone),
+	)
+	s.Require().False(o.Has(keys.AutoqualityTarget))
+	s.Require().False(o.Has(keys.AutoqualityMinQuality))
+	s.Require().False(o.Has(keys.AutoqualityMaxQuality))
+	s.Require().False(o.Has(keys.AutoqualityAllowedError))
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathAutoqualityInvalidMethod() {
+	path := "/aq:ssimulacra/plain/http://images.dev/lorem/ipsum.jpg"
+	_, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &optionsparser.OptionArgumentError{})
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathChainedPipelines() {
+	path := "/c:100:200/q:70/-/w:50/f:png/-/c:20:30/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().InDelta(100.0, o.GetFloat(keys.CropWidth, 0), 0.0001)
+	s.Require().InDelta(200.0, o.GetFloat(keys.CropHeight, 0), 0.0001)
+	s.Require().False(o.Has(keys.Width))
+
+	// Saving options are always stored in the main options
+	s.Require().Equal(70, o.GetInt(keys.Quality, 0))
+	s.Require().Equal(imagetype.PNG, options.Get(o, keys.Format, imagetype.Unknown))
+
+	child := o.Child()
+	s.Require().NotNil(child)
+	s.Require().Equal(50, child.GetInt(keys.Width, 0))
+	s.Require().False(child.Has(keys.CropWidth))
+	s.Require().False(child.Has(keys.Format))
+
+	grandChild := child.Child()
+	s.Require().NotNil(grandChild)
+	s.Require().InDelta(20.0, grandChild.GetFloat(keys.CropWidth, 0), 0.0001)
+	s.Require().InDelta(30.0, grandChild.GetFloat(keys.CropHeight, 0), 0.0001)
+	s.Require().False(grandChild.HasChild())
+}
+
+func (s *ProcessingOptionsTestSuite) TestParsePathChainedPipelinesPreset() {
+	s.config().Presets = []string{
+		"chain=w:100/-/blur:2",
+	}
+
+	path := "/pr:chain/sh:1/-/h:50/plain/http://images.dev/lorem/ipsum.jpg"
+	o, _, err := s.parser().ParsePath(s.T().Context(), path, nil)
+
+	s.Require().NoError(err)
+
+	s.Require().Equal(100, o.GetInt(keys.Width, 0))
+
+	// Options following the preset are applied to the last pipeline of the preset
+	child := o.Child()
+	s.Require().NotNil(child)
+	s.Require().InDelta(2.0, child.GetFloat(keys.Blur, 0), 0.0001)
+	s.Require().InDelta(1.0, child.GetFloat(keys.Sharpen, 0), 0.0001)
+
+	grandChild := child.Child()
+	s.Require().NotNil(grandChild)
+	s.Require().Equal(50, grandChild.GetInt(keys.Height, 0))
+}
+
 func (s *ProcessingOptionsTestSuite) TestParsePathPreset() {
 	s.config().Presets = []string{
 		"test1=resizing_type:fill",
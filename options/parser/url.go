@@ -8,7 +8,10 @@ import (
 	"strings"
 )
 
-const urlTokenPlain = "plain"
+const (
+	urlTokenPlain     = "plain"
+	urlTokenEncrypted = "enc"
+)
 
 func (p *Parser) preprocessURL(u string) string {
 	for _, repl := range p.config.URLReplacements {
@@ -78,6 +81,48 @@ func (p *Parser) decodePlainURL(ctx context.Context, parts []string) (string, st
 	return p.preprocessURL(unescaped), format, nil
 }
 
+func (p *Parser) decodeEncryptedURL(ctx context.Context, parts []string) (string, string, error) {
+	var format string
+
+	if len(p.config.SourceURLEncryptionKeys) == 0 {
+		return "", "", newInvalidURLError(ctx, "Source URL encryption key is not configured")
+	}
+
+	if len(parts) > 1 && p.config.Base64URLIncludesFilename {
+		parts = parts[:len(parts)-1]
+	}
+
+	encoded := strings.Join(parts, "")
+
+	// Encrypted URL is base64-encoded, so both "." and "@" can be used
+	// to separate the format
+	urlParts := strings.Split(strings.ReplaceAll(encoded, "@", "."), ".")
+
+	if len(urlParts[0]) == 0 {
+		return "", "", newInvalidURLError(ctx, "Image URL is empty")
+	}
+
+	if len(urlParts) > 2 {
+		return "", "", newInvalidURLError(ctx, "Multiple formats are specified: %s", encoded)
+	}
+
+	if len(urlParts) == 2 && len(urlParts[1]) > 0 {
+		format = urlParts[1]
+	}
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(urlParts[0], "="))
+	if err != nil {
+		return "", "", newInvalidURLError(ctx, "Invalid url encoding: %s", encoded)
+	}
+
+	imageURL, err := decryptSourceURL(ciphertext, p.config.SourceURLEncryptionKeys)
+	if err != nil {
+		return "", "", newInvalidURLError(ctx, "Invalid encrypted url: %s", err)
+	}
+
+	return p.preprocessURL(imageURL), format, nil
+}
+
 func (p *Parser) DecodeURL(
 	ctx context.Context,
 	parts []string,
@@ -90,5 +135,9 @@ func (p *Parser) DecodeURL(
 		return p.decodePlainURL(ctx, parts[1:])
 	}
 
+	if parts[0] == urlTokenEncrypted && len(parts) > 1 {
+		return p.decodeEncryptedURL(ctx, parts[1:])
+	}
+
 	return p.decodeBase64URL(ctx, parts)
 }
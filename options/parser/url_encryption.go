@@ -0,0 +1,65 @@
+package optionsparser
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"unicode/utf8"
+)
+
+var (
+	errInvalidCiphertextSize = errors.New("invalid ciphertext size")
+	errCantDecrypt           = errors.New("can't decrypt with any of the configured keys")
+)
+
+// decryptSourceURL decrypts an AES-CBC encrypted source URL.
+// The ciphertext is expected to be prefixed with the IV and to be PKCS#7 padded.
+// Keys are tried in order to support keys rotation.
+func decryptSourceURL(ciphertext []byte, keys [][]byte) (string, error) {
+	// We need at least the IV and a single block of data
+	if len(ciphertext) < 2*aes.BlockSize || len(ciphertext)%aes.BlockSize != 0 {
+		return "", errInvalidCiphertextSize
+	}
+
+	iv := ciphertext[:aes.BlockSize]
+	data := ciphertext[aes.BlockSize:]
+	plaintext := make([]byte, len(data))
+
+	for _, key := range keys {
+		c, err := aes.NewCipher(key)
+		if err != nil {
+			return "", err
+		}
+
+		cipher.NewCBCDecrypter(c, iv).CryptBlocks(plaintext, data)
+
+		// A wrong key produces garbage that almost certainly has invalid padding.
+		// We also check that the result is a valid UTF-8 string to make
+		// a false positive even less likely.
+		if res, ok := pkcs7Unpad(plaintext); ok && utf8.Valid(res) {
+			return string(res), nil
+		}
+	}
+
+	return "", errCantDecrypt
+}
+
+// pkcs7Unpad removes PKCS#7 padding from the data.
+// It returns false if the padding is invalid.
+func pkcs7Unpad(data []byte) ([]byte, bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+
+	padLen := int(data[len(data)-1])
+	if padLen == 0 || padLen > aes.BlockSize || padLen > len(data) {
+		return nil, false
+	}
+
+	if !bytes.Equal(data[len(data)-padLen:], bytes.Repeat([]byte{byte(padLen)}, padLen)) {
+		return nil, false
+	}
+
+	return data[:len(data)-padLen], true
+}
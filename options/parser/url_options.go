@@ -4,6 +4,9 @@ import (
 	"strings"
 )
 
+// pipelineSeparator separates chained processing pipelines in the URL path
+const pipelineSeparator = "-"
+
 type urlOption struct {
 	Name string
 	Args []string
@@ -14,6 +17,11 @@ func (p *Parser) parseURLOptions(opts []string) ([]urlOption, []string) {
 	urlStart := len(opts) + 1
 
 	for i, opt := range opts {
+		if opt == pipelineSeparator {
+			parsed = append(parsed, urlOption{Name: pipelineSeparator})
+			continue
+		}
+
 		args := strings.Split(opt, p.config.ArgumentsSeparator)
 
 		if len(args) == 1 {
@@ -0,0 +1,14 @@
+package processing
+
+func (p *Processor) adjust(c *Context) error {
+	brightness := c.PO.Brightness()
+	contrast := c.PO.Contrast()
+	saturation := c.PO.Saturation()
+	gamma := c.PO.Gamma()
+
+	if brightness == 0 && contrast == 1 && saturation == 1 && gamma == 1 {
+		return nil
+	}
+
+	return c.Img.Adjust(brightness, contrast, saturation, gamma)
+}
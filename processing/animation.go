@@ -0,0 +1,204 @@
+package processing
+
+import (
+	"math"
+	"slices"
+
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+const (
+	// defaultAnimationDelay is the frame delay used when the image doesn't provide delay info (25 FPS)
+	defaultAnimationDelay = 40
+
+	// minAnimationDelay is the minimal frame delay we set when changing the playback speed.
+	// Browsers show GIF frames with lower delays for 100ms, which would slow the animation down.
+	minAnimationDelay = 20
+)
+
+// animationDelays returns frame delays of the animated image.
+// If the image doesn't have delay info for some frames, the default delay is used for them.
+func animationDelays(img *vips.Image, framesCount int) ([]int, error) {
+	delay, err := img.GetIntSliceDefault("delay", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(delay) > framesCount {
+		// if we have more delay entries than frames, truncate it.
+		return delay[:framesCount], nil
+	}
+
+	// if we have less delay entries than frames, pad it with the default delay.
+	for len(delay) < framesCount {
+		delay = append(delay, defaultAnimationDelay)
+	}
+
+	return delay, nil
+}
+
+// adjustAnimation drops animation frames and changes frame delays and loop count
+// as requested by the processing options
+func (p *Processor) adjustAnimation(img *vips.Image, po ProcessingOptions) error {
+	dropFrames := po.AnimationDropFrames()
+	maxFPS := po.AnimationMaxFPS()
+	speed := po.AnimationSpeed()
+
+	if po.AnimationLoopOverridden() {
+		img.SetInt("loop", po.AnimationLoop())
+	}
+
+	if dropFrames <= 1 && maxFPS <= 0 && speed == 1 {
+		return nil
+	}
+
+	framesCount := img.PagesLoaded()
+
+	delay, err := animationDelays(img, framesCount)
+	if err != nil {
+		return err
+	}
+
+	frames, delay := adjustFrames(delay, dropFrames, maxFPS, speed)
+
+	if len(frames) < framesCount {
+		if err = selectAnimationFrames(img, frames); err != nil {
+			return err
+		}
+	}
+
+	img.SetIntSlice("delay", delay)
+
+	return nil
+}
+
+// adjustFrames returns indices and delays of the frames that are left
+// after dropping every Nth frame, capping the frame rate, and changing the playback speed.
+// dropFrames <= 1, maxFPS <= 0, and speed == 1 disable the respective adjustments.
+func adjustFrames(delay []int, dropFrames int, maxFPS, speed float64) ([]int, []int) {
+	frames := make([]int, len(delay))
+	for i := range frames {
+		frames[i] = i
+	}
+
+	if dropFrames > 1 {
+		frames, delay = reduceFramesIndexed(frames, delay, dropEveryNthFrame(dropFrames))
+	}
+
+	if maxFPS > 0 {
+		frames, delay = reduceFramesIndexed(frames, delay, capFrameRate(maxFPS))
+	}
+
+	if speed != 1 {
+		delay = slices.Clone(delay)
+		for i, d := range delay {
+			delay[i] = max(int(math.Round(float64(d)/speed)), minAnimationDelay)
+		}
+	}
+
+	return frames, delay
+}
+
+// dropAnimationFrames drops every Nth frame of the animated image
+// adding the delays of the dropped frames to the previous ones
+func dropAnimationFrames(img *vips.Image, n int) error {
+	delay, err := animationDelays(img, img.PagesLoaded())
+	if err != nil {
+		return err
+	}
+
+	frames, delay := reduceFrames(delay, dropEveryNthFrame(n))
+
+	if err = selectAnimationFrames(img, frames); err != nil {
+		return err
+	}
+
+	img.SetIntSlice("delay", delay)
+
+	return nil
+}
+
+// selectAnimationFrames leaves only the frames with the given indices in the animated image
+func selectAnimationFrames(img *vips.Image, indices []int) error {
+	imgWidth := img.Width()
+	frameHeight := img.PageHeight()
+
+	frames := make([]*vips.Image, 0, len(indices))
+	defer func() {
+		for _, frame := range frames {
+			frame.Clear()
+		}
+	}()
+
+	for _, i := range indices {
+		frame := new(vips.Image)
+
+		if err := img.Extract(frame, 0, i*frameHeight, imgWidth, frameHeight); err != nil {
+			return err
+		}
+
+		frames = append(frames, frame)
+	}
+
+	if err := img.Arrayjoin(frames); err != nil {
+		return err
+	}
+
+	img.SetInt("page-height", frameHeight)
+	img.SetInt("n-pages", len(frames))
+
+	return nil
+}
+
+// keepFrameFunc checks if the frame with the given index should be kept.
+// shown is the duration in milliseconds the previous kept frame is shown for so far.
+type keepFrameFunc func(i, shown int) bool
+
+// dropEveryNthFrame returns a function that drops every Nth frame
+func dropEveryNthFrame(n int) keepFrameFunc {
+	return func(i, _ int) bool {
+		return (i+1)%n != 0
+	}
+}
+
+// capFrameRate returns a function that drops frames that would be shown
+// sooner than the given frame rate allows
+func capFrameRate(fps float64) keepFrameFunc {
+	minDelay := int(math.Ceil(1000 / fps))
+
+	return func(_, shown int) bool {
+		return shown >= minDelay
+	}
+}
+
+// reduceFrames returns indices and delays of the frames that are kept by the keep function.
+// The first frame is always kept. Delays of the dropped frames are added
+// to the previous kept frame so the total duration of the animation stays the same.
+func reduceFrames(delay []int, keep keepFrameFunc) ([]int, []int) {
+	frames := make([]int, 0, len(delay))
+	newDelay := make([]int, 0, len(delay))
+
+	for i, d := range delay {
+		if i == 0 || keep(i, newDelay[len(newDelay)-1]) {
+			frames = append(frames, i)
+			newDelay = append(newDelay, d)
+			continue
+		}
+
+		newDelay[len(newDelay)-1] += d
+	}
+
+	return frames, newDelay
+}
+
+// reduceFramesIndexed is the same as reduceFrames but maps the result
+// to the given frame indices
+func reduceFramesIndexed(indices, delay []int, keep keepFrameFunc) ([]int, []int) {
+	frames, delay := reduceFrames(delay, keep)
+
+	for i, f := range frames {
+		frames[i] = indices[f]
+	}
+
+	return frames, delay
+}
@@ -0,0 +1,150 @@
+package processing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReduceFrames(t *testing.T) {
+	testCases := []struct {
+		name   string
+		delay  []int
+		keep   keepFrameFunc
+		frames []int
+		result []int
+	}{
+		{
+			name:   "KeepAll",
+			delay:  []int{10, 20, 30},
+			keep:   func(_, _ int) bool { return true },
+			frames: []int{0, 1, 2},
+			result: []int{10, 20, 30},
+		},
+		{
+			// The first frame is kept even if the keep function drops it
+			name:   "DropAll",
+			delay:  []int{10, 20, 30},
+			keep:   func(_, _ int) bool { return false },
+			frames: []int{0},
+			result: []int{60},
+		},
+		{
+			name:   "DropEverySecond",
+			delay:  []int{10, 20, 30, 40, 50},
+			keep:   dropEveryNthFrame(2),
+			frames: []int{0, 2, 4},
+			result: []int{30, 70, 50},
+		},
+		{
+			name:   "DropEveryThird",
+			delay:  []int{10, 10, 10, 10, 10, 10},
+			keep:   dropEveryNthFrame(3),
+			frames: []int{0, 1, 3, 4},
+			result: []int{10, 20, 10, 20},
+		},
+		{
+			name:   "CapFrameRate",
+			delay:  []int{20, 20, 20, 20, 20},
+			keep:   capFrameRate(25),
+			frames: []int{0, 2, 4},
+			result: []int{40, 40, 20},
+		},
+		{
+			name:   "CapFrameRateSlowFrames",
+			delay:  []int{100, 100, 100},
+			keep:   capFrameRate(25),
+			frames: []int{0, 1, 2},
+			result: []int{100, 100, 100},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frames, delay := reduceFrames(tc.delay, tc.keep)
+
+			require.Equal(t, tc.frames, frames)
+			require.Equal(t, tc.result, delay)
+
+			// The total duration of the animation stays the same
+			var total, resultTotal int
+			for _, d := range tc.delay {
+				total += d
+			}
+			for _, d := range delay {
+				resultTotal += d
+			}
+			require.Equal(t, total, resultTotal)
+		})
+	}
+}
+
+func TestAdjustFrames(t *testing.T) {
+	testCases := []struct {
+		name       string
+		delay      []int
+		dropFrames int
+		maxFPS     float64
+		speed      float64
+		frames     []int
+		result     []int
+	}{
+		{
+			name:   "NoChanges",
+			delay:  []int{100, 100, 100},
+			speed:  1,
+			frames: []int{0, 1, 2},
+			result: []int{100, 100, 100},
+		},
+		{
+			// Frames left after dropping every second frame are mapped
+			// to the source frame indices when capping the frame rate
+			name:       "DropFramesAndMaxFPS",
+			delay:      []int{30, 30, 30, 30, 30, 30, 30, 30},
+			dropFrames: 2,
+			maxFPS:     10,
+			speed:      1,
+			frames:     []int{0, 4},
+			result:     []int{120, 120},
+		},
+		{
+			name:   "SpeedUp",
+			delay:  []int{100, 50},
+			speed:  2,
+			frames: []int{0, 1},
+			result: []int{50, 25},
+		},
+		{
+			name:   "SlowDown",
+			delay:  []int{100, 50},
+			speed:  0.5,
+			frames: []int{0, 1},
+			result: []int{200, 100},
+		},
+		{
+			// Delays are not set lower than minAnimationDelay
+			name:   "SpeedUpClamped",
+			delay:  []int{100, 30},
+			speed:  2,
+			frames: []int{0, 1},
+			result: []int{50, minAnimationDelay},
+		},
+		{
+			name:       "DropFramesAndSpeed",
+			delay:      []int{40, 40, 40},
+			dropFrames: 2,
+			speed:      4,
+			frames:     []int{0, 2},
+			result:     []int{minAnimationDelay, minAnimationDelay},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frames, delay := adjustFrames(tc.delay, tc.dropFrames, tc.maxFPS, tc.speed)
+
+			require.Equal(t, tc.frames, frames)
+			require.Equal(t, tc.result, delay)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package processing_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+)
+
+type ApngTestSuite struct {
+	testSuite
+}
+
+// apngChunk creates a PNG chunk with the given type and data
+func apngChunk(typ string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// pngChunks encodes the image as PNG and returns its IHDR chunk data
+// and the concatenated IDAT chunks data
+func (s *ApngTestSuite) pngChunks(img image.Image) ([]byte, []byte) {
+	var buf bytes.Buffer
+	s.Require().NoError(png.Encode(&buf, img))
+
+	data := buf.Bytes()[8:]
+
+	var ihdr, idat []byte
+
+	for len(data) >= 12 {
+		l := binary.BigEndian.Uint32(data)
+		typ := string(data[4:8])
+		chunk := data[8 : 8+l]
+
+		switch typ {
+		case "IHDR":
+			ihdr = bytes.Clone(chunk)
+		case "IDAT":
+			idat = append(idat, chunk...)
+		}
+
+		data = data[12+l:]
+	}
+
+	return ihdr, idat
+}
+
+// makeAPNG creates an APNG of the given canvas size with solid color frames
+// of the given size placed at the top left corner. Delays are set in milliseconds.
+func (s *ApngTestSuite) makeAPNG(
+	width, height, frameWidth, frameHeight int,
+	colors []color.RGBA,
+	delays []int,
+) []byte {
+	data := []byte("\x89PNG\r\n\x1a\n")
+
+	seq := uint32(0)
+
+	for i, c := range colors {
+		frame := image.NewNRGBA(image.Rect(0, 0, frameWidth, frameHeight))
+		for y := range frameHeight {
+			for x := range frameWidth {
+				frame.Set(x, y, c)
+			}
+		}
+
+		ihdr, idat := s.pngChunks(frame)
+
+		if i == 0 {
+			binary.BigEndian.PutUint32(ihdr, uint32(width))
+			binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+			data = append(data, apngChunk("IHDR", ihdr)...)
+
+			actl := binary.BigEndian.AppendUint32(nil, uint32(len(colors)))
+			actl = binary.BigEndian.AppendUint32(actl, 0)
+			data = append(data, apngChunk("acTL", actl)...)
+		}
+
+		fctl := binary.BigEndian.AppendUint32(nil, seq)
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(frameWidth))
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(frameHeight))
+		fctl = binary.BigEndian.AppendUint32(fctl, 0)
+		fctl = binary.BigEndian.AppendUint32(fctl, 0)
+		fctl = binary.BigEndian.AppendUint16(fctl, uint16(delays[i]))
+		fctl = binary.BigEndian.AppendUint16(fctl, 1000)
+		fctl = append(fctl, 0, 0) // dispose_op: none, blend_op: source
+		data = append(data, apngChunk("fcTL", fctl)...)
+		seq++
+
+		if i == 0 {
+			data = append(data, apngChunk("IDAT", idat)...)
+		} else {
+			fdat := binary.BigEndian.AppendUint32(nil, seq)
+			fdat = append(fdat, idat...)
+			data = append(data, apngChunk("fdAT", fdat)...)
+			seq++
+		}
+	}
+
+	return append(data, apngChunk("IEND", nil)...)
+}
+
+// requireGIFFrames checks that the GIF has frames of the given colors and delays
+func (s *ApngTestSuite) requireGIFFrames(data []byte, colors []color.RGBA, delays []int) {
+	s.T().Helper()
+
+	result, err := gif.DecodeAll(bytes.NewReader(data))
+	s.Require().NoError(err)
+
+	s.Require().Len(result.Image, len(colors), "Frames count mismatch")
+
+	for i, c := range colors {
+		s.requireColor(c, result.Image[i].At(4, 4), "Frame %d color mismatch", i)
+		s.Require().Equal(delays[i]/10, result.Delay[i], "Frame %d delay mismatch", i)
+	}
+}
+
+func (s *ApngTestSuite) TestRoundTrip() {
+	colors := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+	}
+	delays := []int{100, 200, 300}
+
+	imagePath := s.useTempImage("anim.png", s.makeAPNG(8, 8, 8, 8, colors, delays))
+
+	// Load the APNG and save it as APNG
+	resultData := s.processImage(rawOpts{imagePath: imagePath, urlOptions: "format:png"})
+	defer resultData.Close()
+
+	s.Require().Equal(imagetype.PNG, resultData.Format())
+
+	animated, err := imagetype.IsAnimatedPNG(resultData.Reader())
+	s.Require().NoError(err)
+	s.Require().True(animated, "The result is not an animated PNG")
+
+	saved, err := io.ReadAll(resultData.Reader())
+	s.Require().NoError(err)
+
+	savedPath := "saved.png"
+	err = os.WriteFile(filepath.Join(s.Config().Fetcher.Transport.Local.Root, savedPath), saved, 0o600)
+	s.Require().NoError(err)
+
+	// Load the saved APNG and check its frames
+	gifData := s.processImage(rawOpts{imagePath: savedPath, urlOptions: "format:gif"})
+	defer gifData.Close()
+
+	gifBytes, err := io.ReadAll(gifData.Reader())
+	s.Require().NoError(err)
+
+	s.requireGIFFrames(gifBytes, colors, delays)
+}
+
+func (s *ApngTestSuite) TestHugeCanvas() {
+	colors := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+	}
+	delays := []int{100, 100}
+
+	// The canvas is huge while the frames are tiny, so the file itself is small.
+	// The canvas size should be checked before it's allocated.
+	imagePath := s.useTempImage("huge.png", s.makeAPNG(100000, 100000, 1, 1, colors, delays))
+
+	resp := s.GET("/unsafe/plain/local:///" + imagePath)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func (s *ApngTestSuite) TestPreferredFormat() {
+	s.Config().Processing.PreferredFormats = []imagetype.Type{
+		imagetype.JPEG,
+		imagetype.PNG,
+		imagetype.WEBP,
+	}
+
+	colors := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+	}
+	delays := []int{100, 100}
+
+	imagePath := s.useTempImage("anim.gif", s.makeAnimatedGIF(8, 8, colors, delays))
+
+	testCases := []struct {
+		name       string
+		urlOptions string
+		format     imagetype.Type
+	}{
+		// PNG is skipped for animated images when the format is not specified
+		{name: "Preferred", urlOptions: "quality:80", format: imagetype.WEBP},
+		{name: "Explicit", urlOptions: "format:png", format: imagetype.PNG},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			resultData := s.processImage(rawOpts{imagePath: imagePath, urlOptions: tc.urlOptions})
+			defer resultData.Close()
+
+			s.Require().Equal(tc.format, resultData.Format())
+		})
+	}
+}
+
+func TestApng(t *testing.T) {
+	suite.Run(t, new(ApngTestSuite))
+}
@@ -0,0 +1,115 @@
+package processing
+
+import (
+	"context"
+
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/server"
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+// saveImageAutoquality saves the image with the lowest quality that keeps
+// the perceptual difference between the processed image and the saved one within the target.
+// It returns the saved image data and the selected quality.
+//
+// The quality is searched with a binary search within the min/max quality bounds.
+// If none of the attempts fits the target, the image is saved with the max quality.
+func (p *Processor) saveImageAutoquality(
+	ctx context.Context,
+	img *vips.Image,
+	format imagetype.Type,
+	po ProcessingOptions,
+) (imagedata.ImageData, int, error) {
+	target := po.AutoqualityTarget(format)
+	allowedError := po.AutoqualityAllowedError()
+
+	maxQuality := po.AutoqualityMaxQuality(format)
+	minQuality := min(po.AutoqualityMinQuality(format), maxQuality)
+
+	// We will save the image multiple times and compare the results with it,
+	// so we need to process its pixels to ensure that it is in random access mode.
+	if err := img.CopyMemory(); err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		best        imagedata.ImageData
+		bestQuality int
+	)
+
+	lo, hi := minQuality, maxQuality
+
+	for attempt := 0; lo <= hi && attempt < p.config.AutoqualityMaxAttempts; attempt++ {
+		// Check for timeout or cancellation before each attempt as we might spend too much
+		// time processing the image or making previous attempts.
+		if err := server.CheckTimeout(ctx); err != nil {
+			closeImageData(best)
+			return nil, 0, err
+		}
+
+		quality := (lo + hi) / 2
+
+		imgdata, err := img.Save(format, quality, po.Options)
+		if err != nil {
+			closeImageData(best)
+			return nil, 0, err
+		}
+
+		dssim, err := savedImageDSSIM(img, imgdata)
+		if err != nil {
+			imgdata.Close()
+			closeImageData(best)
+			return nil, 0, err
+		}
+
+		if dssim > target {
+			// The result is too different, we need a higher quality
+			imgdata.Close()
+			lo = quality + 1
+			continue
+		}
+
+		// The result fits the target. Keep it and try a lower quality
+		closeImageData(best)
+		best, bestQuality = imgdata, quality
+
+		// The result is close enough to the target, no need to search further
+		if target-dssim <= allowedError {
+			break
+		}
+
+		hi = quality - 1
+	}
+
+	if best != nil {
+		return best, bestQuality, nil
+	}
+
+	imgdata, err := img.Save(format, maxQuality, po.Options)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return imgdata, maxQuality, nil
+}
+
+// savedImageDSSIM loads the saved image data and calculates its DSSIM
+// relative to the image it was saved from
+func savedImageDSSIM(img *vips.Image, imgdata imagedata.ImageData) (float64, error) {
+	saved := new(vips.Image)
+	defer saved.Clear()
+
+	if err := saved.Load(imgdata, 1.0, 0, img.PagesLoaded()); err != nil {
+		return 0, err
+	}
+
+	return img.DSSIM(saved)
+}
+
+// closeImageData closes the image data if it's not nil
+func closeImageData(imgdata imagedata.ImageData) {
+	if imgdata != nil {
+		imgdata.Close()
+	}
+}
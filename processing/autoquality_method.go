@@ -0,0 +1,40 @@
+package processing
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+type AutoqualityMethod int
+
+const (
+	AutoqualityNone AutoqualityMethod = iota
+	AutoqualityDssim
+)
+
+var AutoqualityMethods = map[string]AutoqualityMethod{
+	"none":  AutoqualityNone,
+	"dssim": AutoqualityDssim,
+}
+
+func (m AutoqualityMethod) String() string {
+	for k, v := range AutoqualityMethods {
+		if v == m {
+			return k
+		}
+	}
+	return ""
+}
+
+func (m AutoqualityMethod) MarshalJSON() ([]byte, error) {
+	for k, v := range AutoqualityMethods {
+		if v == m {
+			return fmt.Appendf([]byte{}, "%q", k), nil
+		}
+	}
+	return []byte("null"), nil
+}
+
+func (m AutoqualityMethod) LogValue() slog.Value {
+	return slog.StringValue(m.String())
+}
@@ -0,0 +1,104 @@
+package processing
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/security"
+	"github.com/imgproxy/imgproxy/v4/testutil"
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+type AutoqualityTestSuite struct {
+	suite.Suite
+
+	config Config
+}
+
+func (s *AutoqualityTestSuite) SetupTest() {
+	s.config = NewDefaultConfig()
+	s.config.AutoqualityMethod = AutoqualityDssim
+	s.config.AutoqualityMin = 10
+	s.config.AutoqualityMax = 90
+	s.config.AutoqualityFormatMin = map[imagetype.Type]int{}
+	s.config.AutoqualityFormatMax = map[imagetype.Type]int{}
+	s.config.AutoqualityAllowedError = 0
+}
+
+// saveAutoquality saves the test image as JPEG with the automatically selected quality
+// and returns the selected quality
+func (s *AutoqualityTestSuite) saveAutoquality() int {
+	securityConfig := security.NewDefaultConfig()
+
+	checker, err := security.New(&securityConfig)
+	s.Require().NoError(err)
+
+	p, err := New(&s.config, checker, nil)
+	s.Require().NoError(err)
+
+	data, err := os.ReadFile(testutil.NewTestDataProvider(s.T).Path("test1.jpg"))
+	s.Require().NoError(err)
+
+	imgdata := imagedata.NewFromBytesWithFormat(imagetype.JPEG, data)
+	defer imgdata.Close()
+
+	img := new(vips.Image)
+	defer img.Clear()
+
+	s.Require().NoError(img.Load(imgdata, 1.0, 0, 1))
+
+	po := p.NewProcessingOptions(options.New())
+	s.Require().True(po.AutoqualityEnabled(imagetype.JPEG))
+
+	result, quality, err := p.saveImageAutoquality(s.T().Context(), img, imagetype.JPEG, po)
+	s.Require().NoError(err)
+	defer result.Close()
+
+	s.Require().Equal(imagetype.JPEG, result.Format())
+
+	return quality
+}
+
+func (s *AutoqualityTestSuite) TestQualityInRange() {
+	quality := s.saveAutoquality()
+
+	s.Require().GreaterOrEqual(quality, s.config.AutoqualityMin)
+	s.Require().LessOrEqual(quality, s.config.AutoqualityMax)
+}
+
+func (s *AutoqualityTestSuite) TestTargetNotReached() {
+	// No quality can reach such a small difference, so the max quality is used
+	s.config.AutoqualityTarget = 1e-12
+
+	s.Require().Equal(s.config.AutoqualityMax, s.saveAutoquality())
+}
+
+func (s *AutoqualityTestSuite) TestMaxAttempts() {
+	// Every quality fits such a large difference, so each attempt
+	// halves the quality range down to the min quality
+	s.config.AutoqualityTarget = 1
+
+	testCases := []struct {
+		attempts int
+		quality  int
+	}{
+		{attempts: 1, quality: 50},
+		{attempts: 2, quality: 29},
+		{attempts: 3, quality: 19},
+		{attempts: 100, quality: 10},
+	}
+
+	for _, tc := range testCases {
+		s.config.AutoqualityMaxAttempts = tc.attempts
+		s.Require().Equal(tc.quality, s.saveAutoquality(), "attempts: %d", tc.attempts)
+	}
+}
+
+func TestAutoquality(t *testing.T) {
+	suite.Run(t, new(AutoqualityTestSuite))
+}
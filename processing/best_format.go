@@ -0,0 +1,182 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/server"
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+// saveImageBestFormat saves the image in each of the best format candidates
+// and returns the smallest result. The selected format is stored in po[KeyFormat].
+//
+// If there is nothing to choose from, or the image is too large to be saved multiple times,
+// the image is saved in the fallback format.
+func (p *Processor) saveImageBestFormat(
+	ctx context.Context,
+	img *vips.Image,
+	fallback imagetype.Type,
+	po ProcessingOptions,
+) (imagedata.ImageData, error) {
+	candidates, err := p.bestFormatCandidates(img, po)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(candidates) {
+	case 0:
+		return p.saveImageWithFormat(ctx, img, fallback, po)
+	case 1:
+		po.SetFormat(candidates[0])
+		return p.saveImageWithFormat(ctx, img, candidates[0], po)
+	}
+
+	// We will save the image multiple times,
+	// so we need to process its pixels to ensure that it is in random access mode.
+	if err = img.CopyMemory(); err != nil {
+		return nil, err
+	}
+
+	var (
+		best       imagedata.ImageData
+		bestSize   int
+		bestFormat imagetype.Type
+	)
+
+	for _, format := range candidates {
+		// Check for timeout or cancellation before each attempt as we might spend too much
+		// time processing the image or saving it in previous formats.
+		if err = server.CheckTimeout(ctx); err != nil {
+			closeImageData(best)
+			return nil, err
+		}
+
+		// Saving may modify the options (e.g., fitting the max bytes lowers the quality),
+		// so each candidate gets its own copy of the options to start from the same state
+		candidatePo := po
+		candidatePo.Options = po.Options.Clone()
+
+		imgdata, err := p.saveImageWithFormat(ctx, img, format, candidatePo)
+		if err != nil {
+			closeImageData(best)
+			return nil, err
+		}
+
+		size, err := imgdata.Size()
+		if err != nil {
+			imgdata.Close()
+			closeImageData(best)
+			return nil, err
+		}
+
+		if best != nil && size >= bestSize {
+			imgdata.Close()
+			continue
+		}
+
+		closeImageData(best)
+		best, bestSize, bestFormat = imgdata, size, format
+	}
+
+	po.SetFormat(bestFormat)
+
+	return best, nil
+}
+
+// bestFormatCandidates returns the formats the image can be saved in
+// to find the best one.
+//
+// Candidates are limited to the formats that the client accepts and that are compatible
+// with the image. Lossless formats are skipped for complex images as they are unlikely
+// to win and are expensive to encode.
+func (p *Processor) bestFormatCandidates(
+	img *vips.Image,
+	po ProcessingOptions,
+) ([]imagetype.Type, error) {
+	width, height, frames := p.getImageSize(img)
+
+	if maxRes := p.config.BestFormatMaxResolution; maxRes > 0 && width*height*frames > maxRes {
+		slog.Debug(fmt.Sprintf(
+			"Image resolution %dx%dx%d is too large to pick the best format",
+			width, height, frames,
+		))
+		return nil, nil
+	}
+
+	animated := img.IsAnimated()
+	hasAlpha := img.HasAlpha()
+
+	candidates := make([]imagetype.Type, 0, len(p.config.BestFormatCandidates))
+
+	for _, t := range p.config.BestFormatCandidates {
+		if !isImageTypeAccepted(t, po) || !p.isImageTypeCompatible(t, animated, hasAlpha) {
+			continue
+		}
+
+		// AVIF has a minimal dimension of 16 pixels
+		if t == imagetype.AVIF && (img.Width() < 16 || img.Height() < 16) {
+			continue
+		}
+
+		candidates = append(candidates, t)
+	}
+
+	if len(candidates) < 2 || p.config.BestFormatComplexityThreshold == 0 {
+		return candidates, nil
+	}
+
+	hasLossless := false
+	for _, t := range candidates {
+		if !po.SupportsQuality(t) {
+			hasLossless = true
+			break
+		}
+	}
+
+	if !hasLossless {
+		return candidates, nil
+	}
+
+	complexity, err := img.Complexity()
+	if err != nil {
+		return nil, err
+	}
+
+	if complexity <= p.config.BestFormatComplexityThreshold {
+		return candidates, nil
+	}
+
+	lossy := candidates[:0]
+	for _, t := range candidates {
+		if po.SupportsQuality(t) {
+			lossy = append(lossy, t)
+		}
+	}
+
+	// If there are no lossy candidates, we have no choice but to try lossless ones
+	if len(lossy) == 0 {
+		return candidates, nil
+	}
+
+	return lossy, nil
+}
+
+// isImageTypeAccepted checks if the client accepts the given image type.
+// Formats that are not universally supported are accepted only when the client
+// explicitly declares their support.
+func isImageTypeAccepted(imgtype imagetype.Type, po ProcessingOptions) bool {
+	switch imgtype {
+	case imagetype.WEBP:
+		return po.PreferWebP()
+	case imagetype.AVIF:
+		return po.PreferAvif()
+	case imagetype.JXL:
+		return po.PreferJxl()
+	default:
+		return true
+	}
+}
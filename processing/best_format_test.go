@@ -0,0 +1,117 @@
+package processing_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+)
+
+type BestFormatTestSuite struct {
+	testSuite
+}
+
+func (s *BestFormatTestSuite) SetupTest() {
+	s.Config().Processing.BestFormatCandidates = []imagetype.Type{imagetype.JPEG, imagetype.PNG}
+}
+
+// makeImage creates an image of the given size filled by the color function
+// and encodes it in the given format
+func (s *BestFormatTestSuite) makeImage(
+	format imagetype.Type,
+	size int,
+	fill func(x, y int) color.Color,
+) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := range size {
+		for x := range size {
+			img.Set(x, y, fill(x, y))
+		}
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case imagetype.JPEG:
+		s.Require().NoError(jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}))
+	default:
+		s.Require().NoError(png.Encode(&buf, img))
+	}
+
+	return buf.Bytes()
+}
+
+// solid fills the image with a single color.
+// Lossless formats compress such images much better than lossy ones.
+func solid(_, _ int) color.Color {
+	return color.RGBA{R: 200, G: 100, B: 50, A: 255}
+}
+
+// stripes fills the image with contrast vertical stripes.
+// Such images are considered complex, but lossless formats still compress them better.
+func stripes(x, _ int) color.Color {
+	if (x/4)%2 == 0 {
+		return color.Black
+	}
+	return color.White
+}
+
+// processBest processes the image with the best format selection
+// and returns the selected format
+func (s *BestFormatTestSuite) processBest(imagePath string) imagetype.Type {
+	resultData := s.processImage(rawOpts{imagePath: imagePath, urlOptions: "format:best"})
+	defer resultData.Close()
+
+	return resultData.Format()
+}
+
+func (s *BestFormatTestSuite) TestSmallestCandidate() {
+	imagePath := s.useTempImage("solid.jpg", s.makeImage(imagetype.JPEG, 64, solid))
+
+	s.Require().Equal(imagetype.PNG, s.processBest(imagePath))
+}
+
+func (s *BestFormatTestSuite) TestSingleCandidate() {
+	s.Config().Processing.BestFormatCandidates = []imagetype.Type{imagetype.JPEG}
+
+	imagePath := s.useTempImage("solid.png", s.makeImage(imagetype.PNG, 64, solid))
+
+	s.Require().Equal(imagetype.JPEG, s.processBest(imagePath))
+}
+
+func (s *BestFormatTestSuite) TestComplexityThresholdDisabled() {
+	s.Config().Processing.BestFormatComplexityThreshold = 0
+
+	imagePath := s.useTempImage("stripes.png", s.makeImage(imagetype.PNG, 64, stripes))
+
+	// Without the complexity guard, the lossless format wins
+	s.Require().Equal(imagetype.PNG, s.processBest(imagePath))
+}
+
+func (s *BestFormatTestSuite) TestComplexityThreshold() {
+	s.Config().Processing.BestFormatComplexityThreshold = 1
+
+	imagePath := s.useTempImage("stripes.png", s.makeImage(imagetype.PNG, 64, stripes))
+
+	// Complex images are not saved in lossless formats
+	s.Require().Equal(imagetype.JPEG, s.processBest(imagePath))
+}
+
+func (s *BestFormatTestSuite) TestMaxResolution() {
+	s.Config().Processing.BestFormatMaxResolution = 64*64 - 1
+
+	imagePath := s.useTempImage("solid.jpg", s.makeImage(imagetype.JPEG, 64, solid))
+
+	// The image is too large to pick the best format, so it's saved in the source format
+	s.Require().Equal(imagetype.JPEG, s.processBest(imagePath))
+}
+
+func TestBestFormat(t *testing.T) {
+	suite.Run(t, new(BestFormatTestSuite))
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/imgproxy/imgproxy/v4/ensure"
 	"github.com/imgproxy/imgproxy/v4/env"
 	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/processing/palette"
 	"github.com/imgproxy/imgproxy/v4/processing/svg"
+	"github.com/imgproxy/imgproxy/v4/processing/video"
 	"github.com/imgproxy/imgproxy/v4/vips"
 )
 
@@ -15,8 +17,11 @@ var (
 	IMGPROXY_PREFERRED_FORMATS       = env.ImageTypes("IMGPROXY_PREFERRED_FORMATS")
 	IMGPROXY_SKIP_PROCESSING_FORMATS = env.ImageTypes("IMGPROXY_SKIP_PROCESSING_FORMATS")
 	IMGPROXY_WATERMARK_OPACITY       = env.Float("IMGPROXY_WATERMARK_OPACITY")
+	IMGPROXY_WATERMARK_FONT          = env.String("IMGPROXY_WATERMARK_FONT")
+	IMGPROXY_WATERMARK_FONT_SIZE     = env.Int("IMGPROXY_WATERMARK_FONT_SIZE")
 	IMGPROXY_DISABLE_SHRINK_ON_LOAD  = env.Bool("IMGPROXY_DISABLE_SHRINK_ON_LOAD")
 	IMGPROXY_USE_LINEAR_COLORSPACE   = env.Bool("IMGPROXY_USE_LINEAR_COLORSPACE")
+	IMGPROXY_RESIZING_ALGORITHM      = env.Enum("IMGPROXY_RESIZING_ALGORITHM", vips.Kernels)
 	IMGPROXY_ALWAYS_RASTERIZE_SVG    = env.Bool("IMGPROXY_ALWAYS_RASTERIZE_SVG")
 	IMGPROXY_QUALITY                 = env.Int("IMGPROXY_QUALITY")
 	IMGPROXY_FORMAT_QUALITY          = env.ImageTypesQuality("IMGPROXY_FORMAT_QUALITY")
@@ -26,6 +31,22 @@ var (
 	IMGPROXY_AUTO_ROTATE             = env.Bool("IMGPROXY_AUTO_ROTATE")
 	IMGPROXY_ENFORCE_THUMBNAIL       = env.Bool("IMGPROXY_ENFORCE_THUMBNAIL")
 	IMGPROXY_PRESERVE_HDR            = env.Bool("IMGPROXY_PRESERVE_HDR")
+	IMGPROXY_PALETTE_COLORS          = env.Int("IMGPROXY_PALETTE_COLORS")
+
+	IMGPROXY_AUTOQUALITY_METHOD        = env.Enum("IMGPROXY_AUTOQUALITY_METHOD", AutoqualityMethods)
+	IMGPROXY_AUTOQUALITY_TARGET        = env.Float("IMGPROXY_AUTOQUALITY_TARGET")
+	IMGPROXY_AUTOQUALITY_FORMAT_TARGET = env.ImageTypesFloat("IMGPROXY_AUTOQUALITY_FORMAT_TARGET")
+	IMGPROXY_AUTOQUALITY_MIN           = env.Int("IMGPROXY_AUTOQUALITY_MIN")
+	IMGPROXY_AUTOQUALITY_FORMAT_MIN    = env.ImageTypesQuality("IMGPROXY_AUTOQUALITY_FORMAT_MIN")
+	IMGPROXY_AUTOQUALITY_MAX           = env.Int("IMGPROXY_AUTOQUALITY_MAX")
+	IMGPROXY_AUTOQUALITY_FORMAT_MAX    = env.ImageTypesQuality("IMGPROXY_AUTOQUALITY_FORMAT_MAX")
+	IMGPROXY_AUTOQUALITY_ALLOWED_ERROR = env.Float("IMGPROXY_AUTOQUALITY_ALLOWED_ERROR")
+	IMGPROXY_AUTOQUALITY_MAX_ATTEMPTS  = env.Int("IMGPROXY_AUTOQUALITY_MAX_ATTEMPTS")
+
+	IMGPROXY_BEST_FORMAT_CANDIDATES           = env.ImageTypes("IMGPROXY_BEST_FORMAT_CANDIDATES")
+	IMGPROXY_BEST_FORMAT_BY_DEFAULT           = env.Bool("IMGPROXY_BEST_FORMAT_BY_DEFAULT")
+	IMGPROXY_BEST_FORMAT_MAX_RESOLUTION       = env.MegaInt("IMGPROXY_BEST_FORMAT_MAX_RESOLUTION")
+	IMGPROXY_BEST_FORMAT_COMPLEXITY_THRESHOLD = env.Float("IMGPROXY_BEST_FORMAT_COMPLEXITY_THRESHOLD")
 )
 
 // Config holds pipeline-related configuration.
@@ -33,8 +54,11 @@ type Config struct {
 	PreferredFormats      []imagetype.Type
 	SkipProcessingFormats []imagetype.Type
 	WatermarkOpacity      float64
+	WatermarkFont         string
+	WatermarkFontSize     int
 	DisableShrinkOnLoad   bool
 	UseLinearColorspace   bool
+	ResizingAlgorithm     vips.Kernel
 	AlwaysRasterizeSvg    bool
 	Quality               int
 	FormatQuality         map[imagetype.Type]int
@@ -44,20 +68,40 @@ type Config struct {
 	AutoRotate            bool
 	EnforceThumbnail      bool
 	PreserveHDR           bool
+	PaletteColors         int
+
+	AutoqualityMethod       AutoqualityMethod
+	AutoqualityTarget       float64
+	AutoqualityFormatTarget map[imagetype.Type]float64
+	AutoqualityMin          int
+	AutoqualityFormatMin    map[imagetype.Type]int
+	AutoqualityMax          int
+	AutoqualityFormatMax    map[imagetype.Type]int
+	AutoqualityAllowedError float64
+	AutoqualityMaxAttempts  int
 
-	Svg svg.Config
+	BestFormatCandidates          []imagetype.Type
+	BestFormatByDefault           bool
+	BestFormatMaxResolution       int
+	BestFormatComplexityThreshold float64
+
+	Svg   svg.Config
+	Video video.Config
 }
 
 // NewDefaultConfig creates a new Config instance with the given parameters.
 func NewDefaultConfig() Config {
 	return Config{
-		WatermarkOpacity: 1,
+		WatermarkOpacity:  1,
+		WatermarkFont:     "sans",
+		WatermarkFontSize: 24,
 		PreferredFormats: []imagetype.Type{
 			imagetype.JPEG,
 			imagetype.PNG,
 			imagetype.GIF,
 		},
-		Quality: 80,
+		ResizingAlgorithm: vips.KernelLanczos3,
+		Quality:           80,
 		FormatQuality: map[imagetype.Type]int{
 			imagetype.WEBP: 79,
 			imagetype.AVIF: 63,
@@ -69,8 +113,34 @@ func NewDefaultConfig() Config {
 		AutoRotate:        true,
 		EnforceThumbnail:  false,
 		PreserveHDR:       false,
+		PaletteColors:     5,
 
-		Svg: svg.NewDefaultConfig(),
+		AutoqualityMethod:       AutoqualityNone,
+		AutoqualityTarget:       0.02,
+		AutoqualityFormatTarget: map[imagetype.Type]float64{},
+		AutoqualityMin:          70,
+		AutoqualityFormatMin: map[imagetype.Type]int{
+			imagetype.AVIF: 60,
+		},
+		AutoqualityMax: 80,
+		AutoqualityFormatMax: map[imagetype.Type]int{
+			imagetype.AVIF: 65,
+		},
+		AutoqualityAllowedError: 0.001,
+		AutoqualityMaxAttempts:  8,
+
+		BestFormatCandidates: []imagetype.Type{
+			imagetype.JPEG,
+			imagetype.PNG,
+			imagetype.WEBP,
+			imagetype.AVIF,
+		},
+		BestFormatByDefault:           false,
+		BestFormatMaxResolution:       8_000_000,
+		BestFormatComplexityThreshold: 5.5,
+
+		Svg:   svg.NewDefaultConfig(),
+		Video: video.NewDefaultConfig(),
 	}
 }
 
@@ -79,14 +149,25 @@ func LoadConfigFromEnv(c *Config) (*Config, error) {
 	c = ensure.Ensure(c, NewDefaultConfig)
 
 	_, svgErr := svg.LoadConfigFromEnv(&c.Svg)
+	_, videoErr := video.LoadConfigFromEnv(&c.Video)
 
-	var fq map[imagetype.Type]int
+	var (
+		fq map[imagetype.Type]int
+
+		aqTarget map[imagetype.Type]float64
+		aqMin    map[imagetype.Type]int
+		aqMax    map[imagetype.Type]int
+	)
 
 	err := errors.Join(
 		svgErr,
+		videoErr,
 		IMGPROXY_WATERMARK_OPACITY.Parse(&c.WatermarkOpacity),
+		IMGPROXY_WATERMARK_FONT.Parse(&c.WatermarkFont),
+		IMGPROXY_WATERMARK_FONT_SIZE.Parse(&c.WatermarkFontSize),
 		IMGPROXY_DISABLE_SHRINK_ON_LOAD.Parse(&c.DisableShrinkOnLoad),
 		IMGPROXY_USE_LINEAR_COLORSPACE.Parse(&c.UseLinearColorspace),
+		IMGPROXY_RESIZING_ALGORITHM.Parse(&c.ResizingAlgorithm),
 		IMGPROXY_ALWAYS_RASTERIZE_SVG.Parse(&c.AlwaysRasterizeSvg),
 		IMGPROXY_QUALITY.Parse(&c.Quality),
 		IMGPROXY_FORMAT_QUALITY.Parse(&fq),
@@ -96,12 +177,31 @@ func LoadConfigFromEnv(c *Config) (*Config, error) {
 		IMGPROXY_AUTO_ROTATE.Parse(&c.AutoRotate),
 		IMGPROXY_ENFORCE_THUMBNAIL.Parse(&c.EnforceThumbnail),
 		IMGPROXY_PRESERVE_HDR.Parse(&c.PreserveHDR),
+		IMGPROXY_PALETTE_COLORS.Parse(&c.PaletteColors),
+
+		IMGPROXY_AUTOQUALITY_METHOD.Parse(&c.AutoqualityMethod),
+		IMGPROXY_AUTOQUALITY_TARGET.Parse(&c.AutoqualityTarget),
+		IMGPROXY_AUTOQUALITY_FORMAT_TARGET.Parse(&aqTarget),
+		IMGPROXY_AUTOQUALITY_MIN.Parse(&c.AutoqualityMin),
+		IMGPROXY_AUTOQUALITY_FORMAT_MIN.Parse(&aqMin),
+		IMGPROXY_AUTOQUALITY_MAX.Parse(&c.AutoqualityMax),
+		IMGPROXY_AUTOQUALITY_FORMAT_MAX.Parse(&aqMax),
+		IMGPROXY_AUTOQUALITY_ALLOWED_ERROR.Parse(&c.AutoqualityAllowedError),
+		IMGPROXY_AUTOQUALITY_MAX_ATTEMPTS.Parse(&c.AutoqualityMaxAttempts),
+
+		IMGPROXY_BEST_FORMAT_CANDIDATES.Parse(&c.BestFormatCandidates),
+		IMGPROXY_BEST_FORMAT_BY_DEFAULT.Parse(&c.BestFormatByDefault),
+		IMGPROXY_BEST_FORMAT_MAX_RESOLUTION.Parse(&c.BestFormatMaxResolution),
+		IMGPROXY_BEST_FORMAT_COMPLEXITY_THRESHOLD.Parse(&c.BestFormatComplexityThreshold),
 
 		IMGPROXY_PREFERRED_FORMATS.Parse(&c.PreferredFormats),
 		IMGPROXY_SKIP_PROCESSING_FORMATS.Parse(&c.SkipProcessingFormats),
 	)
 
 	maps.Copy(c.FormatQuality, fq)
+	maps.Copy(c.AutoqualityFormatTarget, aqTarget)
+	maps.Copy(c.AutoqualityFormatMin, aqMin)
+	maps.Copy(c.AutoqualityFormatMax, aqMax)
 
 	return c, err
 }
@@ -112,6 +212,14 @@ func (c *Config) Validate() error {
 		return IMGPROXY_WATERMARK_OPACITY.Errorf("must be between 0 and 1")
 	}
 
+	if len(c.WatermarkFont) == 0 {
+		return IMGPROXY_WATERMARK_FONT.ErrorEmpty()
+	}
+
+	if c.WatermarkFontSize <= 0 {
+		return IMGPROXY_WATERMARK_FONT_SIZE.ErrorZeroOrNegative()
+	}
+
 	if c.Quality < 1 || c.Quality > 100 {
 		return IMGPROXY_QUALITY.Errorf("must be between 1 and 100")
 	}
@@ -122,6 +230,88 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.PaletteColors < 1 || c.PaletteColors > palette.MaxColors {
+		return IMGPROXY_PALETTE_COLORS.Errorf("must be between 1 and %d", palette.MaxColors)
+	}
+
+	if c.AutoqualityTarget <= 0 {
+		return IMGPROXY_AUTOQUALITY_TARGET.ErrorZeroOrNegative()
+	}
+
+	for imgtype, target := range c.AutoqualityFormatTarget {
+		if target <= 0 {
+			return IMGPROXY_AUTOQUALITY_FORMAT_TARGET.Errorf("format %s: cannot be zero or negative", imgtype.String())
+		}
+	}
+
+	if c.AutoqualityMin < 1 || c.AutoqualityMin > 100 {
+		return IMGPROXY_AUTOQUALITY_MIN.Errorf("must be between 1 and 100")
+	}
+
+	if c.AutoqualityMax < 1 || c.AutoqualityMax > 100 {
+		return IMGPROXY_AUTOQUALITY_MAX.Errorf("must be between 1 and 100")
+	}
+
+	if c.AutoqualityMin > c.AutoqualityMax {
+		return IMGPROXY_AUTOQUALITY_MIN.Errorf("must be less than or equal to IMGPROXY_AUTOQUALITY_MAX")
+	}
+
+	for imgtype, minQ := range c.AutoqualityFormatMin {
+		if minQ < 1 || minQ > 100 {
+			return IMGPROXY_AUTOQUALITY_FORMAT_MIN.Errorf("format %s: must be between 1 and 100", imgtype.String())
+		}
+	}
+
+	for imgtype, maxQ := range c.AutoqualityFormatMax {
+		if maxQ < 1 || maxQ > 100 {
+			return IMGPROXY_AUTOQUALITY_FORMAT_MAX.Errorf("format %s: must be between 1 and 100", imgtype.String())
+		}
+	}
+
+	// Check the effective per-format limits, falling back to the global ones
+	// the same way autoquality does
+	for imgtype, minQ := range c.AutoqualityFormatMin {
+		maxQ, ok := c.AutoqualityFormatMax[imgtype]
+		if !ok {
+			maxQ = c.AutoqualityMax
+		}
+
+		if minQ > maxQ {
+			return IMGPROXY_AUTOQUALITY_FORMAT_MIN.Errorf("format %s: must be less than or equal to the format max quality", imgtype.String())
+		}
+	}
+
+	for imgtype, maxQ := range c.AutoqualityFormatMax {
+		if _, ok := c.AutoqualityFormatMin[imgtype]; ok {
+			// Already checked above
+			continue
+		}
+
+		if c.AutoqualityMin > maxQ {
+			return IMGPROXY_AUTOQUALITY_FORMAT_MAX.Errorf("format %s: must be greater than or equal to IMGPROXY_AUTOQUALITY_MIN", imgtype.String())
+		}
+	}
+
+	if c.AutoqualityAllowedError < 0 {
+		return IMGPROXY_AUTOQUALITY_ALLOWED_ERROR.ErrorNegative()
+	}
+
+	if c.AutoqualityMaxAttempts <= 0 {
+		return IMGPROXY_AUTOQUALITY_MAX_ATTEMPTS.ErrorZeroOrNegative()
+	}
+
+	if c.BestFormatMaxResolution < 0 {
+		return IMGPROXY_BEST_FORMAT_MAX_RESOLUTION.ErrorNegative()
+	}
+
+	if c.BestFormatComplexityThreshold < 0 {
+		return IMGPROXY_BEST_FORMAT_COMPLEXITY_THRESHOLD.ErrorNegative()
+	}
+
+	if err := c.Video.Validate(); err != nil {
+		return err
+	}
+
 	filtered := c.PreferredFormats[:0]
 
 	for _, t := range c.PreferredFormats {
@@ -138,5 +328,17 @@ func (c *Config) Validate() error {
 
 	c.PreferredFormats = filtered
 
+	filteredCandidates := c.BestFormatCandidates[:0]
+
+	for _, t := range c.BestFormatCandidates {
+		if !vips.SupportsSave(t) {
+			IMGPROXY_BEST_FORMAT_CANDIDATES.Warn("can't be a best format candidate as it's saving is not supported", "format", t)
+		} else {
+			filteredCandidates = append(filteredCandidates, t)
+		}
+	}
+
+	c.BestFormatCandidates = filteredCandidates
+
 	return nil
 }
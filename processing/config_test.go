@@ -0,0 +1,87 @@
+package processing_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/processing"
+)
+
+func TestValidateAutoqualityFormatLimits(t *testing.T) {
+	testCases := []struct {
+		name    string
+		min     int
+		max     int
+		formMin map[imagetype.Type]int
+		formMax map[imagetype.Type]int
+		valid   bool
+	}{
+		{
+			name:    "Valid",
+			min:     70,
+			max:     80,
+			formMin: map[imagetype.Type]int{imagetype.AVIF: 60},
+			formMax: map[imagetype.Type]int{imagetype.AVIF: 65},
+			valid:   true,
+		},
+		{
+			name:    "FormatMinOutOfRange",
+			min:     70,
+			max:     80,
+			formMin: map[imagetype.Type]int{imagetype.AVIF: 0},
+		},
+		{
+			name:    "FormatMaxOutOfRange",
+			min:     70,
+			max:     80,
+			formMax: map[imagetype.Type]int{imagetype.AVIF: 101},
+		},
+		{
+			name:    "FormatMinGreaterThanFormatMax",
+			min:     70,
+			max:     80,
+			formMin: map[imagetype.Type]int{imagetype.AVIF: 66},
+			formMax: map[imagetype.Type]int{imagetype.AVIF: 65},
+		},
+		{
+			name:    "FormatMinGreaterThanGlobalMax",
+			min:     70,
+			max:     80,
+			formMin: map[imagetype.Type]int{imagetype.WEBP: 85},
+		},
+		{
+			name:    "GlobalMinGreaterThanFormatMax",
+			min:     70,
+			max:     80,
+			formMax: map[imagetype.Type]int{imagetype.WEBP: 65},
+		},
+		{
+			name:    "FormatMinBelowGlobalMin",
+			min:     70,
+			max:     80,
+			formMin: map[imagetype.Type]int{imagetype.WEBP: 50},
+			formMax: map[imagetype.Type]int{imagetype.WEBP: 60},
+			valid:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := processing.NewDefaultConfig()
+			c.AutoqualityMin = tc.min
+			c.AutoqualityMax = tc.max
+			c.AutoqualityFormatMin = tc.formMin
+			c.AutoqualityFormatMax = tc.formMax
+
+			err := c.Validate()
+
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 type (
 	SaveFormatError struct{ *errctx.TextError }
+	PageError       struct{ *errctx.TextError }
 )
 
 func newSaveFormatError(format imagetype.Type) error {
@@ -21,3 +22,13 @@ func newSaveFormatError(format imagetype.Type) error {
 		errctx.WithShouldReport(false),
 	)}
 }
+
+func newPageError(page, pages int) error {
+	return PageError{errctx.NewTextError(
+		fmt.Sprintf("Page %d is out of range, the image has %d page(s)", page, pages),
+		1,
+		errctx.WithStatusCode(http.StatusUnprocessableEntity),
+		errctx.WithPublicMessage("Invalid page"),
+		errctx.WithShouldReport(false),
+	)}
+}
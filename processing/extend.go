@@ -1,6 +1,26 @@
 package processing
 
-func extendImage(c *Context, width, height int, gravity *GravityOptions) error {
+// extendBlurSigmaRatio is the ratio of the blur sigma to the larger canvas side
+// used to blur the image copy when the extend fill mode is blur
+const extendBlurSigmaRatio = 0.02
+
+// embedImage places the image on the canvas of the given size
+// and fills the rest of the canvas according to the fill mode
+func embedImage(c *Context, width, height, offX, offY int, fill ExtendFill) error {
+	switch fill {
+	case ExtendFillBlur:
+		sigma := max(float64(max(width, height))*extendBlurSigmaRatio, 1.0)
+		return c.Img.EmbedBlur(width, height, offX, offY, sigma)
+	case ExtendFillEdge:
+		return c.Img.EmbedEdge(width, height, offX, offY)
+	case ExtendFillMirror:
+		return c.Img.EmbedMirror(width, height, offX, offY)
+	default:
+		return c.Img.Embed(width, height, offX, offY)
+	}
+}
+
+func extendImage(c *Context, width, height int, gravity *GravityOptions, fill ExtendFill) error {
 	imgWidth := c.Img.Width()
 	imgHeight := c.Img.Height()
 
@@ -16,7 +36,7 @@ func extendImage(c *Context, width, height int, gravity *GravityOptions) error {
 	}
 
 	offX, offY := calcPosition(width, height, imgWidth, imgHeight, gravity, c.DprScale, false)
-	return c.Img.Embed(width, height, offX, offY)
+	return embedImage(c, width, height, offX, offY, fill)
 }
 
 func (p *Processor) extend(c *Context) error {
@@ -26,7 +46,7 @@ func (p *Processor) extend(c *Context) error {
 
 	width, height := c.TargetWidth, c.TargetHeight
 	gravity := c.PO.ExtendGravity()
-	return extendImage(c, width, height, &gravity)
+	return extendImage(c, width, height, &gravity, c.PO.ExtendFill())
 }
 
 func (p *Processor) extendAspectRatio(c *Context) error {
@@ -40,5 +60,5 @@ func (p *Processor) extendAspectRatio(c *Context) error {
 	}
 
 	gravity := c.PO.ExtendAspectRatioGravity()
-	return extendImage(c, width, height, &gravity)
+	return extendImage(c, width, height, &gravity, c.PO.ExtendAspectRatioFill())
 }
@@ -0,0 +1,44 @@
+package processing
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+type ExtendFill int
+
+const (
+	ExtendFillBackground ExtendFill = iota
+	ExtendFillBlur
+	ExtendFillEdge
+	ExtendFillMirror
+)
+
+var ExtendFills = map[string]ExtendFill{
+	"background": ExtendFillBackground,
+	"blur":       ExtendFillBlur,
+	"edge":       ExtendFillEdge,
+	"mirror":     ExtendFillMirror,
+}
+
+func (ef ExtendFill) String() string {
+	for k, v := range ExtendFills {
+		if v == ef {
+			return k
+		}
+	}
+	return ""
+}
+
+func (ef ExtendFill) MarshalJSON() ([]byte, error) {
+	for k, v := range ExtendFills {
+		if v == ef {
+			return fmt.Appendf([]byte{}, "%q", k), nil
+		}
+	}
+	return []byte("null"), nil
+}
+
+func (ef ExtendFill) LogValue() slog.Value {
+	return slog.StringValue(ef.String())
+}
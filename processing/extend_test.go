@@ -1,7 +1,11 @@
 package processing_test
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
 	"strings"
 	"testing"
 
@@ -449,6 +453,82 @@ func (s *ExtendTestSuite) TestExtendAspectRatio() {
 	}
 }
 
+func (s *ExtendTestSuite) TestExtendFill() {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+
+	// 100x100 image with the red left quarter and the blue rest
+	src := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := range 100 {
+		for x := range 100 {
+			if x < 25 {
+				src.Set(x, y, red)
+			} else {
+				src.Set(x, y, blue)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	s.Require().NoError(png.Encode(&buf, src))
+
+	imagePath := s.useTempImage("fill.png", buf.Bytes())
+
+	// extendFill extends the image to 200x100 with the given fill mode
+	// and returns the result. The image is placed at the canvas center,
+	// so canvas columns 0-49 and 150-199 are filled.
+	extendFill := func(fill string) image.Image {
+		resultData := s.processImage(rawOpts{
+			imagePath:  imagePath,
+			urlOptions: "format:png/width:200/height:100/extend:1:ce:0:0:" + fill,
+		})
+		defer resultData.Close()
+
+		result, err := png.Decode(resultData.Reader())
+		s.Require().NoError(err)
+		s.Require().Equal(image.Rect(0, 0, 200, 100), result.Bounds())
+
+		// The image itself stays untouched
+		s.requireColor(red, result.At(60, 50))
+		s.requireColor(blue, result.At(140, 50))
+
+		return result
+	}
+
+	s.Run("Blur", func() {
+		result := extendFill("blur")
+
+		// The image copy is scaled to cover the canvas and blurred,
+		// so the red quarter covers the left side of the canvas
+		s.requireColor(red, result.At(10, 50))
+		s.requireColor(blue, result.At(190, 50))
+
+		// Colors are mixed near the red and blue border
+		r, _, b, _ := result.At(48, 50).RGBA()
+		s.Require().Less(r>>8, uint32(240))
+		s.Require().Greater(b>>8, uint32(15))
+	})
+
+	s.Run("Edge", func() {
+		result := extendFill("edge")
+
+		// The average color of the edges: 150 red and 250 blue edge pixels
+		avg := color.RGBA{R: 96, B: 159, A: 255}
+
+		s.requireColor(avg, result.At(10, 50))
+		s.requireColor(avg, result.At(190, 10))
+	})
+
+	s.Run("Mirror", func() {
+		result := extendFill("mirror")
+
+		// The image is mirrored at its left edge
+		s.requireColor(red, result.At(40, 50))
+		s.requireColor(blue, result.At(10, 50))
+		s.requireColor(blue, result.At(190, 50))
+	})
+}
+
 func TestExtend(t *testing.T) {
 	suite.Run(t, new(ExtendTestSuite))
 }
@@ -25,7 +25,7 @@ func fixWebpSize(img *vips.Image) error {
 	}
 
 	scale := 1.0 / webpLimitShrink
-	if err := img.Resize(scale, scale); err != nil {
+	if err := img.Resize(scale, scale, vips.KernelLanczos3); err != nil {
 		return err
 	}
 
@@ -45,7 +45,7 @@ func fixHeifSize(img *vips.Image) error {
 	}
 
 	scale := 1.0 / heifLimitShrink
-	if err := img.Resize(scale, scale); err != nil {
+	if err := img.Resize(scale, scale, vips.KernelLanczos3); err != nil {
 		return err
 	}
 
@@ -69,7 +69,7 @@ func fixGifSize(img *vips.Image) error {
 	}
 
 	scale := math.Sqrt(1.0 / gifLimitShrink)
-	if err := img.Resize(scale, scale); err != nil {
+	if err := img.Resize(scale, scale, vips.KernelLanczos3); err != nil {
 		return err
 	}
 
@@ -89,7 +89,7 @@ func fixIcoSize(img *vips.Image) error {
 	}
 
 	scale := 1.0 / icoLimitShrink
-	if err := img.Resize(scale, scale); err != nil {
+	if err := img.Resize(scale, scale, vips.KernelLanczos3); err != nil {
 		return err
 	}
 
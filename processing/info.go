@@ -0,0 +1,114 @@
+package processing
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+
+	"github.com/trimmer-io/go-xmp/xmp"
+
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagemeta/iptc"
+	"github.com/imgproxy/imgproxy/v4/imagemeta/photoshop"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/server"
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+// Info holds the source image metadata
+type Info struct {
+	Format          imagetype.Type    `json:"format"`
+	Width           int               `json:"width"`
+	Height          int               `json:"height"`
+	Pages           int               `json:"pages"`
+	Orientation     int               `json:"orientation"`
+	HasAlpha        bool              `json:"has_alpha"`
+	HasColorProfile bool              `json:"has_color_profile"`
+	IPTC            iptc.IptcMap      `json:"iptc,omitempty"`
+	XMP             *xmp.Document     `json:"xmp,omitempty"`
+	EXIF            map[string]string `json:"exif,omitempty"`
+}
+
+// Close releases resources held by the info
+func (i *Info) Close() {
+	if i.XMP != nil {
+		i.XMP.Close()
+	}
+}
+
+// ImageInfo reads the source image metadata.
+// It loads only the image header and doesn't run any processing.
+func (p *Processor) ImageInfo(
+	ctx context.Context,
+	imgdata imagedata.ImageData,
+) (*Info, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	defer vips.Cleanup()
+
+	img := new(vips.Image)
+	defer img.Clear()
+
+	// libvips loads images lazily, so this reads only the header
+	if err := img.Load(imgdata, 1.0, 0, 1); err != nil {
+		return nil, err
+	}
+
+	if err := server.CheckTimeout(ctx); err != nil {
+		return nil, err
+	}
+
+	width, height, _ := p.getImageSize(img)
+
+	info := Info{
+		Format:          imgdata.Format(),
+		Width:           width,
+		Height:          height,
+		Pages:           img.Pages(),
+		Orientation:     int(img.Orientation()),
+		HasAlpha:        img.HasAlpha(),
+		HasColorProfile: img.HasEmbeddedColourProfile(),
+		IPTC:            readIPTC(img),
+		XMP:             readXMP(img),
+		EXIF:            img.ExifFields(),
+	}
+
+	return &info, nil
+}
+
+func readIPTC(img *vips.Image) iptc.IptcMap {
+	ps3Data, err := img.GetBlob("iptc-data")
+	if err != nil || len(ps3Data) == 0 {
+		return nil
+	}
+
+	ps3Map := make(photoshop.PhotoshopMap)
+	photoshop.Parse(ps3Data, ps3Map)
+
+	iptcData, found := ps3Map[photoshop.IptcKey]
+	if !found {
+		return nil
+	}
+
+	iptcMap := make(iptc.IptcMap)
+	if err = iptc.Parse(iptcData, iptcMap); err != nil || len(iptcMap) == 0 {
+		return nil
+	}
+
+	return iptcMap
+}
+
+func readXMP(img *vips.Image) *xmp.Document {
+	xmpData, err := img.GetBlob("xmp-data")
+	if err != nil || len(xmpData) == 0 {
+		return nil
+	}
+
+	xmpDoc, err := xmp.Read(bytes.NewReader(xmpData))
+	if err != nil {
+		return nil
+	}
+
+	return xmpDoc
+}
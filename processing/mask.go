@@ -0,0 +1,18 @@
+package processing
+
+func (p *Processor) mask(c *Context) error {
+	if !c.PO.MaskEnabled() {
+		return nil
+	}
+
+	switch c.PO.MaskType() {
+	case MaskRoundedRect:
+		return c.Img.MaskRoundedRect(c.PO.MaskRadius() * c.DprScale)
+	case MaskCircle:
+		return c.Img.MaskCircle()
+	case MaskEllipse:
+		return c.Img.MaskEllipse()
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package processing_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+type MaskTestSuite struct {
+	testSuite
+}
+
+// maskPoint is a point of the masked image and whether it should be opaque
+type maskPoint struct {
+	x, y   int
+	opaque bool
+}
+
+// useSolidImage creates a solid red PNG image of the given size
+func (s *MaskTestSuite) useSolidImage(width, height int) string {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	s.Require().NoError(png.Encode(&buf, img))
+
+	return s.useTempImage("solid.png", buf.Bytes())
+}
+
+func (s *MaskTestSuite) TestShapes() {
+	// The image is 100x50, so the circle and the ellipse differ
+	imagePath := s.useSolidImage(100, 50)
+
+	testCases := []struct {
+		name   string
+		mask   string
+		points []maskPoint
+	}{
+		{
+			name: "Rounded",
+			mask: "rounded:10",
+			points: []maskPoint{
+				{x: 0, y: 0},
+				{x: 99, y: 49},
+				{x: 50, y: 25, opaque: true},
+				// Edges outside the corners are not masked
+				{x: 50, y: 0, opaque: true},
+				{x: 0, y: 25, opaque: true},
+			},
+		},
+		{
+			name: "Circle",
+			mask: "circle",
+			points: []maskPoint{
+				{x: 0, y: 0},
+				{x: 99, y: 49},
+				{x: 50, y: 25, opaque: true},
+				// Outside of the inscribed circle but inside of the inscribed ellipse
+				{x: 10, y: 25},
+				{x: 89, y: 25},
+			},
+		},
+		{
+			name: "Ellipse",
+			mask: "ellipse",
+			points: []maskPoint{
+				{x: 0, y: 0},
+				{x: 99, y: 49},
+				{x: 8, y: 4},
+				{x: 50, y: 25, opaque: true},
+				{x: 10, y: 25, opaque: true},
+				{x: 89, y: 25, opaque: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			resultData := s.processImage(rawOpts{
+				imagePath:  imagePath,
+				urlOptions: "format:png/mask:" + tc.mask,
+			})
+			defer resultData.Close()
+
+			result, err := png.Decode(resultData.Reader())
+			s.Require().NoError(err)
+
+			for _, p := range tc.points {
+				c := color.NRGBAModel.Convert(result.At(p.x, p.y)).(color.NRGBA)
+
+				if p.opaque {
+					s.Require().Equal(uint8(255), c.A, "Point %dx%d should be opaque", p.x, p.y)
+					s.requireColor(color.RGBA{R: 255, A: 255}, c, "Point %dx%d color mismatch", p.x, p.y)
+				} else {
+					s.Require().Equal(uint8(0), c.A, "Point %dx%d should be transparent", p.x, p.y)
+				}
+			}
+		})
+	}
+}
+
+func (s *MaskTestSuite) TestFlattenJPEG() {
+	imagePath := s.useSolidImage(50, 50)
+
+	// JPEG doesn't support transparency, so the masked areas are filled with the background color
+	resultData := s.processImage(rawOpts{
+		imagePath:  imagePath,
+		urlOptions: "format:jpg/mask:circle/background:0:0:255",
+	})
+	defer resultData.Close()
+
+	result, err := jpeg.Decode(resultData.Reader())
+	s.Require().NoError(err)
+
+	s.requireColor(color.RGBA{B: 255, A: 255}, result.At(1, 1), "Corner color mismatch")
+	s.requireColor(color.RGBA{R: 255, A: 255}, result.At(25, 25), "Center color mismatch")
+}
+
+func (s *MaskTestSuite) TestAnimation() {
+	colors := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+	}
+	delays := []int{100, 100, 100}
+
+	imagePath := s.useTempImage("anim.gif", s.makeAnimatedGIF(32, 32, colors, delays))
+
+	resultData := s.processImage(rawOpts{
+		imagePath:  imagePath,
+		urlOptions: "format:gif/mask:circle",
+	})
+	defer resultData.Close()
+
+	result := new(vips.Image)
+	defer result.Clear()
+
+	s.Require().NoError(result.Load(resultData, 1.0, 0, -1))
+	s.Require().Equal(len(colors), result.PagesLoaded(), "Frames count mismatch")
+
+	// Frames are stacked vertically
+	pixels, width, height, err := result.RGBAPixels(32 * len(colors))
+	s.Require().NoError(err)
+	s.Require().Equal(32, width)
+	s.Require().Equal(32*len(colors), height)
+
+	pixel := func(x, y int) color.RGBA {
+		i := (y*width + x) * 4
+		return color.RGBA{R: pixels[i], G: pixels[i+1], B: pixels[i+2], A: pixels[i+3]}
+	}
+
+	for i, c := range colors {
+		top := i * 32
+
+		// Each frame is masked, not only the first one
+		s.Require().Equal(uint8(0), pixel(0, top).A, "Frame %d top left corner should be transparent", i)
+		s.Require().Equal(uint8(0), pixel(31, top+31).A, "Frame %d bottom right corner should be transparent", i)
+
+		center := pixel(16, top+16)
+		s.Require().Equal(uint8(255), center.A, "Frame %d center should be opaque", i)
+		s.requireColor(c, center, "Frame %d center color mismatch", i)
+	}
+}
+
+func TestMask(t *testing.T) {
+	suite.Run(t, new(MaskTestSuite))
+}
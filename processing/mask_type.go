@@ -0,0 +1,44 @@
+package processing
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+type MaskType int
+
+const (
+	MaskNone MaskType = iota
+	MaskRoundedRect
+	MaskCircle
+	MaskEllipse
+)
+
+var MaskTypes = map[string]MaskType{
+	"none":    MaskNone,
+	"rounded": MaskRoundedRect,
+	"circle":  MaskCircle,
+	"ellipse": MaskEllipse,
+}
+
+func (mt MaskType) String() string {
+	for k, v := range MaskTypes {
+		if v == mt {
+			return k
+		}
+	}
+	return ""
+}
+
+func (mt MaskType) MarshalJSON() ([]byte, error) {
+	for k, v := range MaskTypes {
+		if v == mt {
+			return fmt.Appendf([]byte{}, "%q", k), nil
+		}
+	}
+	return []byte("null"), nil
+}
+
+func (mt MaskType) LogValue() slog.Value {
+	return slog.StringValue(mt.String())
+}
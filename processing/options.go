@@ -7,6 +7,7 @@ import (
 	"github.com/imgproxy/imgproxy/v4/options"
 	"github.com/imgproxy/imgproxy/v4/options/keys"
 	"github.com/imgproxy/imgproxy/v4/security"
+	"github.com/imgproxy/imgproxy/v4/vips"
 	"github.com/imgproxy/imgproxy/v4/vips/color"
 )
 
@@ -30,6 +31,20 @@ func (p *Processor) NewProcessingOptions(o *options.Options) ProcessingOptions {
 	}
 }
 
+// Chain returns the processing options of each chained pipeline
+// starting from the current one
+func (po ProcessingOptions) Chain() []ProcessingOptions {
+	chain := []ProcessingOptions{po}
+
+	for c := range po.Descendants() {
+		cpo := po
+		cpo.Options = c
+		chain = append(chain, cpo)
+	}
+
+	return chain
+}
+
 func (po ProcessingOptions) Width() int {
 	return po.GetInt(keys.Width, 0)
 }
@@ -50,6 +65,10 @@ func (po ProcessingOptions) ResizingType() ResizeType {
 	return options.Get(po.Options, keys.ResizingType, ResizeFit)
 }
 
+func (po ProcessingOptions) ResizingAlgorithm() vips.Kernel {
+	return options.Get(po.Options, keys.ResizingAlgorithm, po.config.ResizingAlgorithm)
+}
+
 func (po ProcessingOptions) ZoomWidth() float64 {
 	return po.GetFloat(keys.ZoomWidth, 1.0)
 }
@@ -66,6 +85,45 @@ func (po ProcessingOptions) EnforceThumbnail() bool {
 	return po.Main().GetBool(keys.EnforceThumbnail, po.config.EnforceThumbnail)
 }
 
+// Page returns the zero-based index of the source image page to process
+func (po ProcessingOptions) Page() int {
+	return po.Main().GetInt(keys.Page, 0)
+}
+
+// Pages returns the number of the source image pages to process starting from [Page].
+// 0 means all the pages up to the max animation frames limit.
+func (po ProcessingOptions) Pages() int {
+	return po.Main().GetInt(keys.Pages, 0)
+}
+
+// AnimationDropFrames returns N to drop every Nth frame of the animation.
+// 0 means no frames are dropped.
+func (po ProcessingOptions) AnimationDropFrames() int {
+	return po.Main().GetInt(keys.AnimationDropFrames, 0)
+}
+
+// AnimationMaxFPS returns the maximum frame rate of the animation.
+// 0 means no limit.
+func (po ProcessingOptions) AnimationMaxFPS() float64 {
+	return po.Main().GetFloat(keys.AnimationMaxFPS, 0)
+}
+
+// AnimationSpeed returns the animation playback speed multiplier
+func (po ProcessingOptions) AnimationSpeed() float64 {
+	return po.Main().GetFloat(keys.AnimationSpeed, 1)
+}
+
+// AnimationLoopOverridden checks if the animation loop count should be overridden
+func (po ProcessingOptions) AnimationLoopOverridden() bool {
+	return po.Main().Has(keys.AnimationLoop)
+}
+
+// AnimationLoop returns the animation loop count override.
+// 0 means infinite looping.
+func (po ProcessingOptions) AnimationLoop() int {
+	return po.Main().GetInt(keys.AnimationLoop, 0)
+}
+
 func (po ProcessingOptions) Enlarge() bool {
 	return po.GetBool(keys.Enlarge, false)
 }
@@ -82,6 +140,10 @@ func (po ProcessingOptions) ExtendGravity() GravityOptions {
 	return NewGravityOptions(po.Options, keys.ExtendGravity, GravityCenter)
 }
 
+func (po ProcessingOptions) ExtendFill() ExtendFill {
+	return options.Get(po.Options, keys.ExtendFill, ExtendFillBackground)
+}
+
 func (po ProcessingOptions) ExtendAspectRatioEnabled() bool {
 	return po.GetBool(keys.ExtendAspectRatioEnabled, false)
 }
@@ -90,10 +152,22 @@ func (po ProcessingOptions) ExtendAspectRatioGravity() GravityOptions {
 	return NewGravityOptions(po.Options, keys.ExtendAspectRatioGravity, GravityCenter)
 }
 
+func (po ProcessingOptions) ExtendAspectRatioFill() ExtendFill {
+	return options.Get(po.Options, keys.ExtendAspectRatioFill, ExtendFillBackground)
+}
+
 func (po ProcessingOptions) Rotate() int {
 	return po.GetInt(keys.Rotate, 0)
 }
 
+func (po ProcessingOptions) RotateFree() float64 {
+	return po.GetFloat(keys.RotateFree, 0.0)
+}
+
+func (po ProcessingOptions) RotateAutoCrop() bool {
+	return po.GetBool(keys.RotateAutoCrop, false)
+}
+
 func (po ProcessingOptions) FlipHorizontal() bool {
 	return po.GetBool(keys.FlipHorizontal, false)
 }
@@ -126,6 +200,31 @@ func (po ProcessingOptions) SetFormat(format imagetype.Type) {
 	po.Set(keys.Format, format)
 }
 
+// BestFormat checks if the output format should be selected
+// by saving the image in several candidate formats and picking the smallest result
+func (po ProcessingOptions) BestFormat() bool {
+	return po.Main().GetBool(keys.BestFormat, false)
+}
+
+func (po ProcessingOptions) SetBestFormat(bestFormat bool) {
+	po.Set(keys.BestFormat, bestFormat)
+}
+
+// PaletteColors returns the number of dominant colors to extract
+func (po ProcessingOptions) PaletteColors() int {
+	if n := po.Main().GetInt(keys.Palette, 0); n > 0 {
+		return n
+	}
+
+	return po.config.PaletteColors
+}
+
+// PaletteHeaderEnabled checks if the dominant colors should be returned
+// in the response header along with the processed image
+func (po ProcessingOptions) PaletteHeaderEnabled() bool {
+	return po.Main().GetInt(keys.Palette, 0) > 0 && !po.Format().IsPseudo()
+}
+
 func (po ProcessingOptions) ShouldSkipFormatProcessing(inFormat imagetype.Type) bool {
 	return slices.Contains(po.config.SkipProcessingFormats, inFormat) ||
 		options.SliceContains(po.Main(), keys.SkipProcessing, inFormat)
@@ -162,6 +261,29 @@ func (po ProcessingOptions) PaddingLeft() int {
 	return po.GetInt(keys.PaddingLeft, 0)
 }
 
+func (po ProcessingOptions) PaddingFill() ExtendFill {
+	return options.Get(po.Options, keys.PaddingFill, ExtendFillBackground)
+}
+
+func (po ProcessingOptions) MaskEnabled() bool {
+	switch po.MaskType() {
+	case MaskNone:
+		return false
+	case MaskRoundedRect:
+		return po.MaskRadius() > 0
+	default:
+		return true
+	}
+}
+
+func (po ProcessingOptions) MaskType() MaskType {
+	return options.Get(po.Options, keys.MaskType, MaskNone)
+}
+
+func (po ProcessingOptions) MaskRadius() float64 {
+	return po.GetFloat(keys.MaskRadius, 0.0)
+}
+
 func (po ProcessingOptions) Blur() float64 {
 	return po.GetFloat(keys.Blur, 0.0)
 }
@@ -174,6 +296,22 @@ func (po ProcessingOptions) Pixelate() int {
 	return po.GetInt(keys.Pixelate, 1)
 }
 
+func (po ProcessingOptions) Brightness() int {
+	return po.GetInt(keys.Brightness, 0)
+}
+
+func (po ProcessingOptions) Contrast() float64 {
+	return po.GetFloat(keys.Contrast, 1.0)
+}
+
+func (po ProcessingOptions) Saturation() float64 {
+	return po.GetFloat(keys.Saturation, 1.0)
+}
+
+func (po ProcessingOptions) Gamma() float64 {
+	return po.GetFloat(keys.Gamma, 1.0)
+}
+
 func (po ProcessingOptions) PreferWebP() bool {
 	return po.GetBool(keys.PreferWebP, false)
 }
@@ -226,32 +364,66 @@ func (po ProcessingOptions) TrimEqualVer() bool {
 	return po.GetBool(keys.TrimEqualVer, false)
 }
 
-func (po ProcessingOptions) WatermarkOpacity() float64 {
-	return po.GetFloat(keys.WatermarkOpacity, 0.0)
+// WatermarkLayers returns the indexes of the watermark layers that should be applied
+// in the order they should be composited
+func (po ProcessingOptions) WatermarkLayers() []int {
+	var layers []int
+
+	for layer := range WatermarkLayersCount {
+		if po.WatermarkOpacity(layer) > 0 {
+			layers = append(layers, layer)
+		}
+	}
+
+	return layers
+}
+
+func (po ProcessingOptions) WatermarkOpacity(layer int) float64 {
+	return po.GetFloat(keys.WatermarkLayer(layer, keys.WatermarkOpacity), 0.0)
+}
+
+func (po ProcessingOptions) SetWatermarkOpacity(layer int, opacity float64) {
+	po.Set(keys.WatermarkLayer(layer, keys.WatermarkOpacity), opacity)
+}
+
+func (po ProcessingOptions) DeleteWatermarkOpacity(layer int) {
+	po.Delete(keys.WatermarkLayer(layer, keys.WatermarkOpacity))
 }
 
-func (po ProcessingOptions) SetWatermarkOpacity(opacity float64) {
-	po.Set(keys.WatermarkOpacity, opacity)
+func (po ProcessingOptions) WatermarkPosition(layer int) GravityType {
+	return options.Get(po.Options, keys.WatermarkLayer(layer, keys.WatermarkPosition), GravityCenter)
 }
 
-func (po ProcessingOptions) DeleteWatermarkOpacity() {
-	po.Delete(keys.WatermarkOpacity)
+func (po ProcessingOptions) WatermarkXOffset(layer int) float64 {
+	return po.GetFloat(keys.WatermarkLayer(layer, keys.WatermarkXOffset), 0.0)
 }
 
-func (po ProcessingOptions) WatermarkPosition() GravityType {
-	return options.Get(po.Options, keys.WatermarkPosition, GravityCenter)
+func (po ProcessingOptions) WatermarkYOffset(layer int) float64 {
+	return po.GetFloat(keys.WatermarkLayer(layer, keys.WatermarkYOffset), 0.0)
 }
 
-func (po ProcessingOptions) WatermarkXOffset() float64 {
-	return po.GetFloat(keys.WatermarkXOffset, 0.0)
+func (po ProcessingOptions) WatermarkScale(layer int) float64 {
+	return po.GetFloat(keys.WatermarkLayer(layer, keys.WatermarkScale), 0.0)
 }
 
-func (po ProcessingOptions) WatermarkYOffset() float64 {
-	return po.GetFloat(keys.WatermarkYOffset, 0.0)
+func (po ProcessingOptions) WatermarkURL(layer int) string {
+	return po.GetString(keys.WatermarkLayer(layer, keys.WatermarkURL), "")
 }
 
-func (po ProcessingOptions) WatermarkScale() float64 {
-	return po.GetFloat(keys.WatermarkScale, 0.0)
+func (po ProcessingOptions) WatermarkText(layer int) string {
+	return po.GetString(keys.WatermarkLayer(layer, keys.WatermarkText), "")
+}
+
+func (po ProcessingOptions) WatermarkTextFont(layer int) string {
+	return po.GetString(keys.WatermarkLayer(layer, keys.WatermarkTextFont), po.config.WatermarkFont)
+}
+
+func (po ProcessingOptions) WatermarkTextSize(layer int) int {
+	return po.GetInt(keys.WatermarkLayer(layer, keys.WatermarkTextSize), po.config.WatermarkFontSize)
+}
+
+func (po ProcessingOptions) WatermarkTextColor(layer int) color.RGB {
+	return options.Get(po.Options, keys.WatermarkLayer(layer, keys.WatermarkTextColor), color.Black)
 }
 
 func (po ProcessingOptions) PreserveHDR() bool {
@@ -281,6 +453,89 @@ func (po ProcessingOptions) Quality(format imagetype.Type) int {
 	return po.config.Quality
 }
 
+// Lossless checks if the image should be saved losslessly in the given format.
+// Near-lossless WebP is not considered lossless since its quality still matters.
+func (po ProcessingOptions) Lossless(format imagetype.Type) bool {
+	lossless := po.Main().GetBool(keys.Lossless, false)
+
+	switch format {
+	case imagetype.WEBP:
+		return po.Main().GetBool(keys.WebpLossless, lossless) &&
+			!po.Main().GetBool(keys.LosslessNear, false)
+	case imagetype.AVIF:
+		return po.Main().GetBool(keys.AvifLossless, lossless)
+	case imagetype.JXL:
+		return po.Main().GetBool(keys.JxlLossless, lossless)
+	default:
+		return false
+	}
+}
+
+// SupportsQuality checks if the quality affects the result of saving in the given format
+func (po ProcessingOptions) SupportsQuality(format imagetype.Type) bool {
+	return format.SupportsQuality() && !po.Lossless(format)
+}
+
+// AutoqualityMethod returns the method of automatic quality selection
+func (po ProcessingOptions) AutoqualityMethod() AutoqualityMethod {
+	return options.Get(po.Main(), keys.AutoqualityMethod, po.config.AutoqualityMethod)
+}
+
+// AutoqualityEnabled checks if the quality should be selected automatically for the given format.
+// Automatic quality selection is not applied when the quality is explicitly set in options.
+func (po ProcessingOptions) AutoqualityEnabled(format imagetype.Type) bool {
+	return po.AutoqualityMethod() != AutoqualityNone &&
+		po.SupportsQuality(format) &&
+		po.Main().GetInt(keys.Quality, 0) == 0 &&
+		po.Main().GetInt(keys.FormatQuality(format), 0) == 0
+}
+
+// AutoqualityTarget returns the maximum allowed perceptual difference
+// between the processed image and the saved one for the given format
+func (po ProcessingOptions) AutoqualityTarget(format imagetype.Type) float64 {
+	if t := po.Main().GetFloat(keys.AutoqualityTarget, 0); t > 0 {
+		return t
+	}
+
+	if t, ok := po.config.AutoqualityFormatTarget[format]; ok {
+		return t
+	}
+
+	return po.config.AutoqualityTarget
+}
+
+// AutoqualityMinQuality returns the minimal quality that can be selected for the given format
+func (po ProcessingOptions) AutoqualityMinQuality(format imagetype.Type) int {
+	if q := po.Main().GetInt(keys.AutoqualityMinQuality, 0); q > 0 {
+		return q
+	}
+
+	if q := po.config.AutoqualityFormatMin[format]; q > 0 {
+		return q
+	}
+
+	return po.config.AutoqualityMin
+}
+
+// AutoqualityMaxQuality returns the maximal quality that can be selected for the given format
+func (po ProcessingOptions) AutoqualityMaxQuality(format imagetype.Type) int {
+	if q := po.Main().GetInt(keys.AutoqualityMaxQuality, 0); q > 0 {
+		return q
+	}
+
+	if q := po.config.AutoqualityFormatMax[format]; q > 0 {
+		return q
+	}
+
+	return po.config.AutoqualityMax
+}
+
+// AutoqualityAllowedError returns how much the perceptual difference
+// can be lower than the target to stop searching for a lower quality
+func (po ProcessingOptions) AutoqualityAllowedError() float64 {
+	return po.Main().GetFloat(keys.AutoqualityAllowedError, po.config.AutoqualityAllowedError)
+}
+
 func (po ProcessingOptions) MaxBytes() int {
 	return po.Main().GetInt(keys.MaxBytes, 0)
 }
@@ -14,10 +14,12 @@ func (p *Processor) padding(c *Context) error {
 	paddingBottom := imath.ScaleToEven(c.PO.PaddingBottom(), c.DprScale)
 	paddingLeft := imath.ScaleToEven(c.PO.PaddingLeft(), c.DprScale)
 
-	return c.Img.Embed(
+	return embedImage(
+		c,
 		c.Img.Width()+paddingLeft+paddingRight,
 		c.Img.Height()+paddingTop+paddingBottom,
 		paddingLeft,
 		paddingTop,
+		c.PO.PaddingFill(),
 	)
 }
@@ -0,0 +1,46 @@
+package processing
+
+import (
+	"encoding/json"
+
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/processing/palette"
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+// paletteResult is the JSON representation of the extracted palette
+type paletteResult struct {
+	Colors []palette.Color `json:"colors"`
+}
+
+// extractPalette extracts the dominant colors of the processed image
+func (p *Processor) extractPalette(img *vips.Image, po ProcessingOptions) ([]palette.Color, error) {
+	pixels, _, _, err := img.RGBAPixels(palette.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return palette.Extract(pixels, po.PaletteColors()), nil
+}
+
+// savePalette extracts the dominant colors of the processed image
+// and returns them as the result data in JSON
+func (p *Processor) savePalette(img *vips.Image, po ProcessingOptions) (imagedata.ImageData, error) {
+	colors, err := p.extractPalette(img, po)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure we return an empty array rather than null
+	if colors == nil {
+		colors = []palette.Color{}
+	}
+
+	data, err := json.Marshal(paletteResult{Colors: colors})
+	if err != nil {
+		return nil, err
+	}
+
+	return imagedata.NewFromBytesWithFormat(imagetype.PALETTE, data), nil
+}
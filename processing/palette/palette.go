@@ -0,0 +1,181 @@
+package palette
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/imgproxy/imgproxy/v4/vips/color"
+)
+
+const (
+	// MaxSize is the maximum size of the image side used to extract the palette.
+	// Dominant colors don't depend on details, so there is no need to analyze larger images.
+	MaxSize = 100
+
+	// MaxColors is the maximum number of colors that can be extracted
+	MaxColors = 32
+)
+
+// minAlpha is the minimal alpha value of a pixel to be taken into account.
+// Mostly transparent pixels don't contribute to the visible colors.
+const minAlpha = 128
+
+// Color is a dominant color of the image
+type Color struct {
+	Color color.RGB
+	Share float64 // Share of the image pixels that have this color, from 0 to 1
+}
+
+// MarshalJSON implements the json.Marshaler interface for Color
+func (c Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Color string  `json:"color"`
+		Share float64 `json:"share"`
+	}{
+		Color: c.Color.String(),
+		Share: roundShare(c.Share),
+	})
+}
+
+// box is a set of pixels that represents a single color of the palette
+type box struct {
+	pixels [][3]uint8
+}
+
+// Extract extracts up to n dominant colors of the image represented by 8-bit RGBA pixels
+// using the median cut algorithm. Colors are sorted by their share in descending order.
+// Transparent pixels are ignored.
+func Extract(pixels []byte, n int) []Color {
+	n = min(n, MaxColors)
+
+	opaque := make([][3]uint8, 0, len(pixels)/4)
+
+	for i := 0; i+3 < len(pixels); i += 4 {
+		if pixels[i+3] >= minAlpha {
+			opaque = append(opaque, [3]uint8{pixels[i], pixels[i+1], pixels[i+2]})
+		}
+	}
+
+	if len(opaque) == 0 || n <= 0 {
+		return nil
+	}
+
+	boxes := []box{{pixels: opaque}}
+
+	for len(boxes) < n {
+		// Split the box with the largest population-weighted color range
+		idx, channel, score := -1, 0, 0
+		for i, b := range boxes {
+			ch, rng := b.widestChannel()
+			if s := rng * len(b.pixels); rng > 0 && s > score {
+				idx, channel, score = i, ch, s
+			}
+		}
+
+		// All boxes contain a single color, nothing to split
+		if idx < 0 {
+			break
+		}
+
+		lo, hi := boxes[idx].split(channel)
+		boxes[idx] = lo
+		boxes = append(boxes, hi)
+	}
+
+	colors := make([]Color, len(boxes))
+	for i, b := range boxes {
+		colors[i] = Color{
+			Color: b.average(),
+			Share: float64(len(b.pixels)) / float64(len(opaque)),
+		}
+	}
+
+	slices.SortStableFunc(colors, func(a, b Color) int {
+		return cmp.Compare(b.Share, a.Share)
+	})
+
+	return colors
+}
+
+// Header formats the colors as a response header value
+func Header(colors []Color) string {
+	parts := make([]string, len(colors))
+	for i, c := range colors {
+		parts[i] = fmt.Sprintf("%s %g", c.Color, roundShare(c.Share))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// widestChannel returns the channel with the widest range of values and the range
+func (b box) widestChannel() (int, int) {
+	lo, hi := b.pixels[0], b.pixels[0]
+
+	for _, p := range b.pixels[1:] {
+		for c := range 3 {
+			lo[c] = min(lo[c], p[c])
+			hi[c] = max(hi[c], p[c])
+		}
+	}
+
+	channel, rng := 0, 0
+	for c := range 3 {
+		if r := int(hi[c]) - int(lo[c]); r > rng {
+			channel, rng = c, r
+		}
+	}
+
+	return channel, rng
+}
+
+// split splits the box at the median of the given channel
+func (b box) split(channel int) (box, box) {
+	slices.SortFunc(b.pixels, func(p1, p2 [3]uint8) int {
+		return cmp.Compare(p1[channel], p2[channel])
+	})
+
+	median := len(b.pixels) / 2
+
+	// Don't split pixels with the same value
+	// so the same color doesn't end up in both boxes
+	v := b.pixels[median][channel]
+	for median > 0 && b.pixels[median-1][channel] == v {
+		median--
+	}
+
+	if median == 0 {
+		for median < len(b.pixels) && b.pixels[median][channel] == v {
+			median++
+		}
+	}
+
+	return box{pixels: b.pixels[:median]}, box{pixels: b.pixels[median:]}
+}
+
+// average returns the average color of the box
+func (b box) average() color.RGB {
+	var r, g, bl int
+
+	for _, p := range b.pixels {
+		r += int(p[0])
+		g += int(p[1])
+		bl += int(p[2])
+	}
+
+	n := len(b.pixels)
+
+	return color.RGB{
+		R: uint8((r + n/2) / n),
+		G: uint8((g + n/2) / n),
+		B: uint8((bl + n/2) / n),
+	}
+}
+
+// roundShare rounds the share to 3 decimal places
+func roundShare(share float64) float64 {
+	return math.Round(share*1000) / 1000
+}
@@ -0,0 +1,79 @@
+package palette_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/imgproxy/imgproxy/v4/processing/palette"
+	"github.com/imgproxy/imgproxy/v4/vips/color"
+)
+
+// pixelsOf creates RGBA pixels repeating each color the given number of times
+func pixelsOf(colors map[color.RGB]int, alpha byte) []byte {
+	var pixels []byte
+
+	for c, n := range colors {
+		for range n {
+			pixels = append(pixels, c.R, c.G, c.B, alpha)
+		}
+	}
+
+	return pixels
+}
+
+func TestExtract(t *testing.T) {
+	pixels := pixelsOf(map[color.RGB]int{
+		color.Red:  30,
+		color.Blue: 10,
+	}, 255)
+
+	colors := palette.Extract(pixels, 5)
+
+	require.Equal(t, []palette.Color{
+		{Color: color.Red, Share: 0.75},
+		{Color: color.Blue, Share: 0.25},
+	}, colors)
+}
+
+func TestExtractLimit(t *testing.T) {
+	pixels := pixelsOf(map[color.RGB]int{
+		{R: 200, G: 0, B: 0}: 20,
+		{R: 0, G: 0, B: 100}: 20,
+		color.Green:          60,
+	}, 255)
+
+	colors := palette.Extract(pixels, 2)
+
+	require.Equal(t, []palette.Color{
+		{Color: color.Green, Share: 0.6},
+		{Color: color.RGB{R: 100, G: 0, B: 50}, Share: 0.4},
+	}, colors)
+}
+
+func TestExtractTransparent(t *testing.T) {
+	pixels := append(
+		pixelsOf(map[color.RGB]int{color.Red: 10}, 255),
+		pixelsOf(map[color.RGB]int{color.Blue: 30}, 0)...,
+	)
+
+	require.Equal(t, []palette.Color{{Color: color.Red, Share: 1}}, palette.Extract(pixels, 5))
+	require.Empty(t, palette.Extract(pixelsOf(map[color.RGB]int{color.Blue: 30}, 0), 5))
+}
+
+func TestHeader(t *testing.T) {
+	header := palette.Header([]palette.Color{
+		{Color: color.Red, Share: 2.0 / 3},
+		{Color: color.Blue, Share: 1.0 / 3},
+	})
+
+	require.Equal(t, "#ff0000 0.667, #0000ff 0.333", header)
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := json.Marshal([]palette.Color{{Color: color.Red, Share: 0.75}})
+
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"color":"#ff0000","share":0.75}]`, string(data))
+}
@@ -0,0 +1,98 @@
+package processing_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/httpheaders"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+)
+
+type PaletteTestSuite struct {
+	testSuite
+}
+
+// useTwoColorImage creates a 40x40 PNG image where 3/4 of the pixels are red
+// and the rest are blue
+func (s *PaletteTestSuite) useTwoColorImage() string {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	for y := range 40 {
+		for x := range 40 {
+			if x < 30 {
+				img.Set(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	s.Require().NoError(png.Encode(&buf, img))
+
+	return s.useTempImage("palette.png", buf.Bytes())
+}
+
+func (s *PaletteTestSuite) TestFormat() {
+	imagePath := s.useTwoColorImage()
+
+	resultData := s.processImage(rawOpts{imagePath: imagePath, urlOptions: "format:palette/palette:2"})
+	defer resultData.Close()
+
+	s.Require().Equal(imagetype.PALETTE, resultData.Format())
+
+	var result struct {
+		Colors []struct {
+			Color string  `json:"color"`
+			Share float64 `json:"share"`
+		} `json:"colors"`
+	}
+
+	s.Require().NoError(json.NewDecoder(resultData.Reader()).Decode(&result))
+
+	s.Require().Len(result.Colors, 2)
+
+	// Colors are sorted by their share
+	s.Require().Equal("#ff0000", result.Colors[0].Color)
+	s.Require().InDelta(0.75, result.Colors[0].Share, 0.001)
+	s.Require().Equal("#0000ff", result.Colors[1].Color)
+	s.Require().InDelta(0.25, result.Colors[1].Share, 0.001)
+}
+
+func (s *PaletteTestSuite) TestHeader() {
+	imagePath := s.useTwoColorImage()
+
+	resp := s.GET("/unsafe/format:png/palette:2/plain/local:///" + imagePath)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("#ff0000 0.75, #0000ff 0.25", resp.Header.Get(httpheaders.XPalette))
+
+	// The image itself is returned
+	body, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+
+	_, err = png.Decode(bytes.NewReader(body))
+	s.Require().NoError(err)
+}
+
+func (s *PaletteTestSuite) TestNoHeader() {
+	imagePath := s.useTwoColorImage()
+
+	resp := s.GET("/unsafe/format:png/plain/local:///" + imagePath)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Empty(resp.Header.Get(httpheaders.XPalette))
+}
+
+func TestPalette(t *testing.T) {
+	suite.Run(t, new(PaletteTestSuite))
+}
@@ -0,0 +1,40 @@
+package processing
+
+import (
+	"encoding/base64"
+
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/processing/placeholder"
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+// savePlaceholder calculates the placeholder hash of the processed image
+// and returns it as the result data of the given pseudo-format
+func (p *Processor) savePlaceholder(
+	img *vips.Image,
+	format imagetype.Type,
+) (imagedata.ImageData, error) {
+	var hash string
+
+	switch format {
+	case imagetype.BLURHASH:
+		pixels, width, height, err := img.RGBAPixels(placeholder.BlurHashMaxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		hash = placeholder.BlurHash(pixels, width, height)
+	case imagetype.THUMBHASH:
+		pixels, width, height, err := img.RGBAPixels(placeholder.ThumbHashMaxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		hash = base64.StdEncoding.EncodeToString(placeholder.ThumbHash(pixels, width, height))
+	default:
+		return nil, newSaveFormatError(format)
+	}
+
+	return imagedata.NewFromBytesWithFormat(format, []byte(hash)), nil
+}
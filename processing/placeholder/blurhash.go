@@ -0,0 +1,142 @@
+package placeholder
+
+import (
+	"math"
+	"strings"
+)
+
+// BlurHashMaxSize is the maximum size of the image side used to calculate BlurHash.
+// BlurHash represents only the low frequencies of the image, so there is no need
+// to calculate it for larger images.
+const BlurHashMaxSize = 32
+
+const (
+	// blurHashComponents is the number of components along the longer side of the image
+	blurHashComponents = 4
+	// blurHashShortComponents is the number of components along the shorter side of the image
+	blurHashShortComponents = 3
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash calculates the BlurHash (https://blurha.sh) of the image
+// represented by 8-bit RGBA pixels. The alpha channel is ignored.
+func BlurHash(pixels []byte, width, height int) string {
+	xComponents, yComponents := blurHashComponents, blurHashShortComponents
+	if height > width {
+		xComponents, yComponents = yComponents, xComponents
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+
+	for j := range yComponents {
+		for i := range xComponents {
+			factors = append(factors, blurHashFactor(pixels, width, height, i, j))
+		}
+	}
+
+	var b strings.Builder
+
+	b.WriteString(encodeBase83((xComponents-1)+(yComponents-1)*9, 1))
+
+	dc, ac := factors[0], factors[1:]
+
+	maxValue := 1.0
+
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = max(actualMax, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+		}
+
+		quantisedMax := int(max(0, min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+
+		b.WriteString(encodeBase83(quantisedMax, 1))
+	} else {
+		b.WriteString(encodeBase83(0, 1))
+	}
+
+	b.WriteString(encodeBase83(blurHashEncodeDC(dc), 4))
+
+	for _, f := range ac {
+		b.WriteString(encodeBase83(blurHashEncodeAC(f, maxValue), 2))
+	}
+
+	return b.String()
+}
+
+// blurHashFactor calculates the DCT factor of the image for the given component
+func blurHashFactor(pixels []byte, width, height, i, j int) [3]float64 {
+	var r, g, b float64
+
+	normalisation := 2.0
+	if i == 0 && j == 0 {
+		normalisation = 1.0
+	}
+
+	for y := range height {
+		cosY := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+
+		for x := range width {
+			basis := normalisation * cosY * math.Cos(math.Pi*float64(i)*float64(x)/float64(width))
+
+			offset := (y*width + x) * 4
+
+			r += basis * srgbToLinear(pixels[offset])
+			g += basis * srgbToLinear(pixels[offset+1])
+			b += basis * srgbToLinear(pixels[offset+2])
+		}
+	}
+
+	scale := 1.0 / float64(width*height)
+
+	return [3]float64{r * scale, g * scale, b * scale}
+}
+
+func blurHashEncodeDC(f [3]float64) int {
+	return linearToSrgb(f[0])<<16 | linearToSrgb(f[1])<<8 | linearToSrgb(f[2])
+}
+
+func blurHashEncodeAC(f [3]float64, maxValue float64) int {
+	quant := func(v float64) int {
+		return int(max(0, min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+	}
+
+	return quant(f[0])*19*19 + quant(f[1])*19 + quant(f[2])
+}
+
+func encodeBase83(value, length int) string {
+	buf := make([]byte, length)
+
+	for i := length - 1; i >= 0; i-- {
+		buf[i] = base83Chars[value%83]
+		value /= 83
+	}
+
+	return string(buf)
+}
+
+func srgbToLinear(v byte) float64 {
+	x := float64(v) / 255
+
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(v float64) int {
+	x := max(0, min(1, v))
+
+	if x <= 0.0031308 {
+		return int(x*12.92*255 + 0.5)
+	}
+
+	return int((1.055*math.Pow(x, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
@@ -0,0 +1,136 @@
+package placeholder_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/imgproxy/imgproxy/v4/processing/placeholder"
+)
+
+// solidPixels creates RGBA pixels of the image filled with the given color
+func solidPixels(width, height int, r, g, b, a byte) []byte {
+	pixels := make([]byte, 0, width*height*4)
+
+	for range width * height {
+		pixels = append(pixels, r, g, b, a)
+	}
+
+	return pixels
+}
+
+// patternPixels creates RGBA pixels of the image filled with a non-symmetric color pattern.
+// The pattern is chosen so none of the hash components is close to a rounding boundary.
+// If withAlpha is false, the image is opaque.
+func patternPixels(width, height int, withAlpha bool) []byte {
+	pixels := make([]byte, 0, width*height*4)
+
+	for y := range height {
+		for x := range width {
+			a := byte(255)
+			if withAlpha {
+				a = byte(x*x*7 + y*31 + 64)
+			}
+
+			pixels = append(pixels, byte(x*37+y*y*11), byte(x*x*5+y*23), byte(x*y*17+41), a)
+		}
+	}
+
+	return pixels
+}
+
+func TestBlurHashSolid(t *testing.T) {
+	hash := placeholder.BlurHash(solidPixels(8, 6, 255, 255, 255, 255), 8, 6)
+
+	// 4x3 components flag, AC max, white DC, and 11 AC components
+	require.Len(t, hash, 4+2*12)
+	require.Equal(t, byte('L'), hash[0])
+	require.Equal(t, "TSUA", hash[2:6])
+}
+
+func TestBlurHashComponents(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		flag   byte
+	}{
+		{"landscape", 8, 6, 'L'},
+		{"square", 6, 6, 'L'},
+		{"portrait", 6, 8, 'T'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := placeholder.BlurHash(solidPixels(tt.width, tt.height, 10, 20, 30, 255), tt.width, tt.height)
+
+			require.Len(t, hash, 4+2*12)
+			require.Equal(t, tt.flag, hash[0])
+		})
+	}
+}
+
+func TestBlurHashReference(t *testing.T) {
+	// Expected hashes are produced by the reference implementation (https://github.com/woltapp/blurhash)
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		hash   string
+	}{
+		{"landscape", 16, 12, "LAHCAf-;D|?t=MQCM2L-54Q:MkW["},
+		{"portrait", 12, 16, "TDHU|LxaVD%MVrIpL$UzQIS*OTR;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := placeholder.BlurHash(patternPixels(tt.width, tt.height, false), tt.width, tt.height)
+			require.Equal(t, tt.hash, hash)
+		})
+	}
+}
+
+func TestThumbHashSolid(t *testing.T) {
+	hash := placeholder.ThumbHash(solidPixels(4, 4, 255, 255, 255, 255), 4, 4)
+
+	// 5 header bytes and 37 AC components packed into 19 bytes
+	require.Len(t, hash, 24)
+	// White luminance DC, neutral P and Q DCs, no alpha
+	require.Equal(t, []byte{0x3f, 0x08, 0x02}, hash[:3])
+}
+
+func TestThumbHashAlpha(t *testing.T) {
+	pixels := solidPixels(4, 4, 255, 0, 0, 255)
+	// Make the first pixel transparent
+	pixels[3] = 0
+
+	hash := placeholder.ThumbHash(pixels, 4, 4)
+
+	// Alpha flag is set in the header
+	require.NotZero(t, hash[2]&0x80)
+	// 6 header bytes and 14 + 5 + 5 + 14 AC components packed into 19 bytes
+	require.Len(t, hash, 25)
+}
+
+func TestThumbHashReference(t *testing.T) {
+	// Expected hashes are produced by the reference implementation (https://github.com/evanw/thumbhash)
+	tests := []struct {
+		name      string
+		width     int
+		height    int
+		withAlpha bool
+		hash      string
+	}{
+		{"landscape", 16, 12, false, "5ef80515842608574344936195566755f64a8ab65f"},
+		{"portrait", 12, 16, false, "5e08060d044241666609444487855755f9759c6df8"},
+		{"alpha", 16, 12, true, "5e08820c8208062a5375825707ded4e9fe60699799b7b42005"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := placeholder.ThumbHash(patternPixels(tt.width, tt.height, tt.withAlpha), tt.width, tt.height)
+			require.Equal(t, tt.hash, hex.EncodeToString(hash))
+		})
+	}
+}
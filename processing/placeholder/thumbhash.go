@@ -0,0 +1,168 @@
+package placeholder
+
+import "math"
+
+// ThumbHashMaxSize is the maximum size of the image side supported by ThumbHash
+const ThumbHashMaxSize = 100
+
+// ThumbHash calculates the ThumbHash (https://evanw.github.io/thumbhash/) of the image
+// represented by 8-bit RGBA pixels. The image sides must not exceed [ThumbHashMaxSize].
+func ThumbHash(pixels []byte, width, height int) []byte {
+	count := width * height
+
+	// Determine the average color
+	var avgR, avgG, avgB, avgA float64
+
+	for i := range count {
+		alpha := float64(pixels[i*4+3]) / 255
+
+		avgR += alpha / 255 * float64(pixels[i*4])
+		avgG += alpha / 255 * float64(pixels[i*4+1])
+		avgB += alpha / 255 * float64(pixels[i*4+2])
+		avgA += alpha
+	}
+
+	if avgA > 0 {
+		avgR /= avgA
+		avgG /= avgA
+		avgB /= avgA
+	}
+
+	hasAlpha := avgA < float64(count)
+
+	// Use fewer luminance bits if there's alpha
+	lLimit := 7.0
+	if hasAlpha {
+		lLimit = 5
+	}
+
+	maxSide := float64(max(width, height))
+	lx := max(1, int(jsRound(lLimit*float64(width)/maxSide)))
+	ly := max(1, int(jsRound(lLimit*float64(height)/maxSide)))
+
+	l := make([]float64, count) // luminance
+	p := make([]float64, count) // yellow - blue
+	q := make([]float64, count) // red - green
+	a := make([]float64, count) // alpha
+
+	// Convert the image from RGBA to LPQA (composite atop the average color)
+	for i := range count {
+		alpha := float64(pixels[i*4+3]) / 255
+
+		r := avgR*(1-alpha) + alpha/255*float64(pixels[i*4])
+		g := avgG*(1-alpha) + alpha/255*float64(pixels[i*4+1])
+		b := avgB*(1-alpha) + alpha/255*float64(pixels[i*4+2])
+
+		l[i] = (r + g + b) / 3
+		p[i] = (r+g)/2 - b
+		q[i] = r - g
+		a[i] = alpha
+	}
+
+	lDC, lAC, lScale := thumbHashEncodeChannel(l, width, height, max(3, lx), max(3, ly))
+	pDC, pAC, pScale := thumbHashEncodeChannel(p, width, height, 3, 3)
+	qDC, qAC, qScale := thumbHashEncodeChannel(q, width, height, 3, 3)
+
+	// Write the constants
+	isLandscape := width > height
+
+	header24 := int(jsRound(63*lDC)) |
+		int(jsRound(31.5+31.5*pDC))<<6 |
+		int(jsRound(31.5+31.5*qDC))<<12 |
+		int(jsRound(31*lScale))<<18
+
+	header16 := int(jsRound(63*pScale))<<3 | int(jsRound(63*qScale))<<9
+
+	if isLandscape {
+		header16 |= ly | 1<<15
+	} else {
+		header16 |= lx
+	}
+
+	acs := [][]float64{lAC, pAC, qAC}
+
+	if hasAlpha {
+		header24 |= 1 << 23
+	}
+
+	hash := []byte{
+		byte(header24), byte(header24 >> 8), byte(header24 >> 16),
+		byte(header16), byte(header16 >> 8),
+	}
+
+	if hasAlpha {
+		aDC, aAC, aScale := thumbHashEncodeChannel(a, width, height, 5, 5)
+		hash = append(hash, byte(int(jsRound(15*aDC))|int(jsRound(15*aScale))<<4))
+		acs = append(acs, aAC)
+	}
+
+	// Write the varying factors
+	acStart := len(hash)
+	acIndex := 0
+
+	for _, ac := range acs {
+		for _, f := range ac {
+			pos := acStart + acIndex>>1
+			if pos >= len(hash) {
+				hash = append(hash, 0)
+			}
+
+			hash[pos] |= byte(int(jsRound(15*f)) << ((acIndex & 1) << 2))
+			acIndex++
+		}
+	}
+
+	return hash
+}
+
+// thumbHashEncodeChannel encodes the channel using the DCT into DC (constant)
+// and normalized AC (varying) terms
+func thumbHashEncodeChannel(channel []float64, width, height, nx, ny int) (float64, []float64, float64) {
+	var (
+		dc, scale float64
+		ac        []float64
+	)
+
+	fx := make([]float64, width)
+
+	for cy := range ny {
+		for cx := 0; cx*ny < nx*(ny-cy); cx++ {
+			for x := range width {
+				fx[x] = math.Cos(math.Pi / float64(width) * float64(cx) * (float64(x) + 0.5))
+			}
+
+			f := 0.0
+
+			for y := range height {
+				fy := math.Cos(math.Pi / float64(height) * float64(cy) * (float64(y) + 0.5))
+
+				for x := range width {
+					f += channel[x+y*width] * fx[x] * fy
+				}
+			}
+
+			f /= float64(width * height)
+
+			if cx > 0 || cy > 0 {
+				ac = append(ac, f)
+				scale = max(scale, math.Abs(f))
+			} else {
+				dc = f
+			}
+		}
+	}
+
+	if scale > 0 {
+		for i := range ac {
+			ac[i] = 0.5 + 0.5/scale*ac[i]
+		}
+	}
+
+	return dc, ac, scale
+}
+
+// jsRound rounds the value the same way JavaScript's Math.round does,
+// so the results match the reference implementation
+func jsRound(v float64) float64 {
+	return math.Floor(v + 0.5)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/imgproxy/imgproxy/v4/imagedata"
 	"github.com/imgproxy/imgproxy/v4/imagetype"
 	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/processing/palette"
 	"github.com/imgproxy/imgproxy/v4/server"
 	"github.com/imgproxy/imgproxy/v4/vips"
 )
@@ -20,16 +21,19 @@ func (p *Processor) mainPipeline() Pipeline {
 	return Pipeline{
 		p.vectorGuardScale,
 		p.trim,
+		p.rotateFree,
 		p.scaleOnLoad,
 		p.colorspaceToProcessing,
 		p.crop,
 		p.scale,
 		p.rotateAndFlip,
 		p.cropToResult,
+		p.adjust,
 		p.applyFilters,
 		p.extend,
 		p.extendAspectRatio,
 		p.padding,
+		p.mask,
 		p.fixSize,
 		p.flatten,
 		p.watermark,
@@ -52,6 +56,7 @@ type Result struct {
 	OriginHeight int
 	ResultWidth  int
 	ResultHeight int
+	Palette      []palette.Color
 }
 
 // ProcessImage processes the image according to the provided processing options
@@ -73,9 +78,22 @@ func (p *Processor) ProcessImage(
 
 	po := p.NewProcessingOptions(o)
 
+	// Video sources can't be loaded directly.
+	// Extract a frame or an animated preview and process it instead.
+	srcFormat := imgdata.Format()
+	if srcFormat.IsVideo() {
+		frames, err := p.video.Extract(ctx, po.Options, imgdata)
+		if err != nil {
+			return nil, err
+		}
+		defer frames.Close()
+
+		imgdata = frames
+	}
+
 	// Load a single page/frame of the image so we can analyze it
 	// and decide how to process it further
-	thumbnailLoaded, err := p.initialLoadImage(img, imgdata, po.EnforceThumbnail())
+	thumbnailLoaded, err := p.initialLoadImage(img, imgdata, po)
 	if err != nil {
 		return nil, err
 	}
@@ -86,20 +104,20 @@ func (p *Processor) ProcessImage(
 	}
 
 	// Check if we expect image to be processed as animated.
-	// If MaxAnimationFrames is 1, we never process as animated since we can only
-	// process a single frame.
-	animated := po.MaxAnimationFrames() > 1 && img.IsAnimated()
+	// If MaxAnimationFrames is 1 or a single page is requested, we never process
+	// as animated since we can only process a single frame.
+	animated := po.MaxAnimationFrames() > 1 && po.Pages() != 1 && img.IsAnimated()
 
 	// Determine output format and check if it's supported.
 	// The determined format is stored in po[KeyFormat].
-	outFormat, err := p.determineOutputFormat(img, imgdata, po, animated)
+	outFormat, err := p.determineOutputFormat(img, srcFormat, po, animated)
 	if err != nil {
 		return nil, err
 	}
 
 	// Now, as we know the output format, we know for sure if the image
 	// should be processed as animated
-	animated = animated && outFormat.SupportsAnimationSave()
+	animated = animated && vips.SupportsAnimationSave(outFormat)
 
 	// Load required number of frames/pages for processing
 	// and remove animation-related data if not animated.
@@ -116,6 +134,14 @@ func (p *Processor) ProcessImage(
 		return nil, err
 	}
 
+	// Drop animation frames and change the animation timing if requested.
+	// We do this before transforming the image so we don't process dropped frames
+	if animated {
+		if err = p.adjustAnimation(img, po); err != nil {
+			return nil, err
+		}
+	}
+
 	// Transform the image (resize, crop, etc)
 	if err = p.transformImage(ctx, img, po, imgdata, animated); err != nil {
 		return nil, err
@@ -126,6 +152,14 @@ func (p *Processor) ProcessImage(
 		return nil, err
 	}
 
+	// Extract the dominant colors if they are requested along with the image
+	var colors []palette.Color
+	if po.PaletteHeaderEnabled() {
+		if colors, err = p.extractPalette(img, po); err != nil {
+			return nil, err
+		}
+	}
+
 	outData, err := p.saveImage(ctx, img, po)
 	if err != nil {
 		return nil, err
@@ -139,18 +173,25 @@ func (p *Processor) ProcessImage(
 		OriginHeight: originHeight,
 		ResultWidth:  resultWidth,
 		ResultHeight: resultHeight,
+		Palette:      colors,
 	}, nil
 }
 
 // initialLoadImage loads a single page/frame of the image.
 // If the image format supports thumbnails and thumbnail loading is enforced,
 // it tries to load the thumbnail first.
+// If a page other than the first one is requested, it checks that the image has it
+// and loads it.
 func (p *Processor) initialLoadImage(
 	img *vips.Image,
 	imgdata imagedata.ImageData,
-	enforceThumbnail bool,
+	po ProcessingOptions,
 ) (bool, error) {
-	if enforceThumbnail && imgdata.Format().SupportsThumbnail() {
+	page := po.Page()
+
+	// Embedded thumbnails belong to the primary image, so we don't use them
+	// if another page is requested
+	if po.EnforceThumbnail() && page == 0 && imgdata.Format().SupportsThumbnail() {
 		if err := img.LoadThumbnail(imgdata); err == nil {
 			return true, nil
 		} else {
@@ -158,11 +199,37 @@ func (p *Processor) initialLoadImage(
 		}
 	}
 
-	return false, img.Load(imgdata, 1.0, 0, 1)
+	if err := img.Load(imgdata, 1.0, 0, 1); err != nil {
+		return false, err
+	}
+
+	if imgdata.Format() == imagetype.PDF {
+		if err := p.securityChecker.CheckPdfPages(po.Main(), img.Pages()); err != nil {
+			return false, err
+		}
+	}
+
+	if page == 0 {
+		return false, nil
+	}
+
+	// The first page is loaded, so we know the number of pages
+	// and can check if the requested page exists
+	if pages := img.Pages(); page >= pages {
+		return false, newPageError(page, pages)
+	}
+
+	// Some loaders need to render all the preceding pages to get the requested one,
+	// so we check the image dimensions before loading it
+	if _, _, err := p.checkImageSize(img, imgdata.Format(), po); err != nil {
+		return false, err
+	}
+
+	return false, img.Load(imgdata, 1.0, page, 1)
 }
 
 // reloadImageForProcessing reloads the image for processing.
-// For animated images, it loads all frames up to MaxAnimationFrames.
+// For animated images, it loads the requested frames up to MaxAnimationFrames.
 func (p *Processor) reloadImageForProcessing(
 	img *vips.Image,
 	imgdata imagedata.ImageData,
@@ -170,10 +237,22 @@ func (p *Processor) reloadImageForProcessing(
 	asAnimated bool,
 ) error {
 	// If we are going to process the image as animated, we need to load all frames
-	// up to MaxAnimationFrames
+	// starting from the requested page up to MaxAnimationFrames
 	if asAnimated {
-		frames := min(img.Pages(), po.MaxAnimationFrames())
-		return img.Load(imgdata, 1.0, 0, frames)
+		page := po.Page()
+		frames := min(img.Pages()-page, po.MaxAnimationFrames())
+
+		if pages := po.Pages(); pages > 0 {
+			frames = min(frames, pages)
+		}
+
+		// Some loaders render all the frames on load,
+		// so we check the dimensions of all the frames before loading them
+		if err := p.securityChecker.CheckDimensions(po.Options, img.Width(), img.Height(), frames); err != nil {
+			return err
+		}
+
+		return img.Load(imgdata, 1.0, page, frames)
 	}
 
 	// Otherwise, we just need to remove any animation-related data
@@ -284,36 +363,56 @@ func (p *Processor) skipStandardProcessing(
 // It modifies the ProcessingOptions in place to set the output format.
 func (p *Processor) determineOutputFormat(
 	img *vips.Image,
-	imgdata imagedata.ImageData,
+	srcFormat imagetype.Type,
 	po ProcessingOptions,
 	animated bool,
 ) (imagetype.Type, error) {
-	// Check if the image may have transparency
-	expectTransparency := !po.ShouldFlatten() &&
-		(img.HasAlpha() || po.PaddingEnabled() || po.ExtendEnabled())
+	// Check if the image may have transparency after all the chained pipelines
+	expectTransparency := img.HasAlpha()
+	for _, cpo := range po.Chain() {
+		expectTransparency = !cpo.ShouldFlatten() &&
+			(expectTransparency ||
+				(cpo.PaddingEnabled() && cpo.PaddingFill() == ExtendFillBackground) ||
+				(cpo.ExtendEnabled() && cpo.ExtendFill() == ExtendFillBackground) ||
+				(cpo.RotateFree() != 0 && !cpo.RotateAutoCrop()) ||
+				cpo.MaskEnabled())
+	}
 
 	format := po.Format()
 
+	// When the format is not specified, we may need to pick the best one.
+	// We still determine the format as usual as it affects processing,
+	// and use it as a fallback when we can't try the candidates.
+	if format == imagetype.Unknown && p.config.BestFormatByDefault {
+		po.SetBestFormat(true)
+	}
+
 	switch {
 	case format == imagetype.SVG:
 		// At this point we can't allow requested format to be SVG as we can't save SVGs
 		return imagetype.Unknown, newSaveFormatError(format)
+	case format.IsPseudo():
+		// Pseudo-formats are calculated from the processed image,
+		// so we don't need to check if we can save them
+		return format, nil
 	case format == imagetype.Unknown:
 		switch {
-		case po.PreferJxl() && !animated:
+		case po.PreferJxl() && (!animated || vips.SupportsAnimationSave(imagetype.JXL)):
 			format = imagetype.JXL
-		case po.PreferAvif() && !animated:
+		case po.PreferAvif() && (!animated || vips.SupportsAnimationSave(imagetype.AVIF)):
 			format = imagetype.AVIF
 		case po.PreferWebP():
 			format = imagetype.WEBP
-		case p.isImageTypePreferred(imgdata.Format()):
-			format = imgdata.Format()
+		case srcFormat.IsVideo() && animated:
+			format = imagetype.WEBP
+		case p.isImageTypePreferred(srcFormat):
+			format = srcFormat
 		default:
 			format = p.findPreferredFormat(animated, expectTransparency)
 		}
-	case po.EnforceJxl() && !animated:
+	case po.EnforceJxl() && (!animated || vips.SupportsAnimationSave(imagetype.JXL)):
 		format = imagetype.JXL
-	case po.EnforceAvif() && !animated:
+	case po.EnforceAvif() && (!animated || vips.SupportsAnimationSave(imagetype.AVIF)):
 		format = imagetype.AVIF
 	case po.EnforceWebP():
 		format = imagetype.WEBP
@@ -338,7 +437,7 @@ func (p *Processor) isImageTypeCompatible(
 	imgtype imagetype.Type,
 	animated, expectTransparency bool,
 ) bool {
-	if animated && !imgtype.SupportsAnimationSave() {
+	if animated && !vips.SupportsAnimationSave(imgtype) {
 		return false
 	}
 
@@ -352,6 +451,12 @@ func (p *Processor) isImageTypeCompatible(
 // findPreferredFormat finds a suitable preferred format based on image's properties.
 func (p *Processor) findPreferredFormat(animated, expectTransparency bool) imagetype.Type {
 	for _, t := range p.config.PreferredFormats {
+		// Animated PNG is not as widely supported as other animated formats,
+		// so we don't pick PNG for animated images unless it's requested explicitly
+		if animated && t == imagetype.PNG {
+			continue
+		}
+
 		if p.isImageTypeCompatible(t, animated, expectTransparency) {
 			return t
 		}
@@ -367,11 +472,28 @@ func (p *Processor) transformImage(
 	imgdata imagedata.ImageData,
 	asAnimated bool,
 ) error {
-	if asAnimated {
-		return p.transformAnimated(ctx, img, po)
+	// Run the main pipeline for each of the chained pipelines.
+	// Saving options are stored in the main options and are applied
+	// only once after the last pipeline.
+	for _, cpo := range po.Chain() {
+		var err error
+
+		if asAnimated {
+			err = p.transformAnimated(ctx, img, cpo)
+		} else {
+			err = p.mainPipeline().Run(ctx, img, cpo, imgdata)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Chained pipelines process the result of the previous one,
+		// so we don't provide imgdata to them to prevent scale-on-load
+		imgdata = nil
 	}
 
-	return p.mainPipeline().Run(ctx, img, po, imgdata)
+	return nil
 }
 
 func (p *Processor) transformAnimated(
@@ -389,8 +511,7 @@ func (p *Processor) transformAnimated(
 	framesCount := img.PagesLoaded()
 
 	// Get frame delays. We'll need to set them back later.
-	// If we don't have delay info, we'll set a default delay later.
-	delay, err := img.GetIntSliceDefault("delay", nil)
+	delay, err := animationDelays(img, framesCount)
 	if err != nil {
 		return err
 	}
@@ -403,13 +524,22 @@ func (p *Processor) transformAnimated(
 	}
 
 	// Disable watermarking for individual frames.
-	// It's more efficient to apply watermark to all frames at once after they are processed.
-	watermarkOpacity := po.WatermarkOpacity()
-	if watermarkOpacity > 0 {
-		po.DeleteWatermarkOpacity()
-		defer func() { po.SetWatermarkOpacity(watermarkOpacity) }()
+	// It's more efficient to apply watermarks to all frames at once after they are processed.
+	watermarkLayers := po.WatermarkLayers()
+	watermarkOpacities := make([]float64, len(watermarkLayers))
+
+	for i, layer := range watermarkLayers {
+		watermarkOpacities[i] = po.WatermarkOpacity(layer)
+		po.DeleteWatermarkOpacity(layer)
 	}
 
+	restoreWatermarkOpacities := func() {
+		for i, layer := range watermarkLayers {
+			po.SetWatermarkOpacity(layer, watermarkOpacities[i])
+		}
+	}
+	defer restoreWatermarkOpacities()
+
 	// Make a slice to hold processed frames and ensure they are cleared on function exit
 	frames := make([]*vips.Image, 0, framesCount)
 	defer func() {
@@ -456,9 +586,12 @@ func (p *Processor) transformAnimated(
 		return err
 	}
 
-	// Apply watermark to all frames at once if it was requested.
-	// This is much more efficient than applying watermark to individual frames.
-	if watermarkOpacity > 0 && p.watermarkProvider != nil {
+	// Set watermark opacities back
+	restoreWatermarkOpacities()
+
+	// Apply watermarks to all frames at once if they were requested.
+	// This is much more efficient than applying watermarks to individual frames.
+	if p.hasWatermarks(po) {
 		// Get DPR scale to apply watermark correctly on HiDPI images.
 		// `imgproxy-dpr-scale` is set by the pipeline.
 		dprScale, derr := img.GetDoubleDefault("imgproxy-dpr-scale", 1.0)
@@ -466,25 +599,11 @@ func (p *Processor) transformAnimated(
 			dprScale = 1.0
 		}
 
-		// Set watermark opacity back
-		po.SetWatermarkOpacity(watermarkOpacity)
-
-		if err = p.applyWatermark(ctx, img, po, dprScale, framesCount); err != nil {
+		if err = p.applyWatermarks(ctx, img, po, dprScale, framesCount); err != nil {
 			return err
 		}
 	}
 
-	if len(delay) == 0 {
-		// if we don't have delay info, set it to 40ms for each frame (25 FPS).
-		delay = make([]int, framesCount)
-		for i := range delay {
-			delay[i] = 40
-		}
-	} else if len(delay) > framesCount {
-		// if we have more delay entries than frames, truncate it.
-		delay = delay[:framesCount]
-	}
-
 	// Mark the image as animated so img.Strip() doesn't remove animation data.
 	img.SetInt("imgproxy-is-animated", 1)
 	// Set animation data back.
@@ -503,12 +622,24 @@ func (p *Processor) saveImage(
 ) (imagedata.ImageData, error) {
 	outFormat := po.Format()
 
+	switch {
+	case outFormat == imagetype.PALETTE:
+		return p.savePalette(img, po)
+	case outFormat.IsPseudo():
+		return p.savePlaceholder(img, outFormat)
+	}
+
 	// AVIF has a minimal dimension of 16 pixels.
 	// If one of the dimensions is less, we need to switch to another format.
-	if outFormat == imagetype.AVIF && (img.Width() < 16 || img.Height() < 16) {
-		if img.HasAlpha() {
+	// Frames of animated images are stacked vertically, so we check the page height.
+	if outFormat == imagetype.AVIF && (img.Width() < 16 || img.PageHeight() < 16) {
+		switch {
+		case img.IsAnimated():
+			// Keep the animation
+			outFormat = imagetype.WEBP
+		case img.HasAlpha():
 			outFormat = imagetype.PNG
-		} else {
+		default:
 			outFormat = imagetype.JPEG
 		}
 
@@ -516,16 +647,67 @@ func (p *Processor) saveImage(
 
 		slog.Warn(fmt.Sprintf(
 			"Minimal dimension of AVIF is 16, current image size is %dx%d. Image will be saved as %s",
-			img.Width(), img.Height(), outFormat,
+			img.Width(), img.PageHeight(), outFormat,
 		))
 	}
 
+	if po.BestFormat() {
+		return p.saveImageBestFormat(ctx, img, outFormat, po)
+	}
+
+	return p.saveImageWithFormat(ctx, img, outFormat, po)
+}
+
+// saveImageWithFormat saves the image in the given format
+// respecting the quality and max bytes options.
+func (p *Processor) saveImageWithFormat(
+	ctx context.Context,
+	img *vips.Image,
+	outFormat imagetype.Type,
+	po ProcessingOptions,
+) (imagedata.ImageData, error) {
 	quality := po.Quality(outFormat)
+	maxBytes := po.MaxBytes()
+
+	// If automatic quality selection is enabled, find the lowest quality
+	// that keeps the result visually close to the processed image.
+	if po.AutoqualityEnabled(outFormat) {
+		imgdata, aq, err := p.saveImageAutoquality(ctx, img, outFormat, po)
+		if err != nil {
+			return nil, err
+		}
+
+		if maxBytes == 0 {
+			return imgdata, nil
+		}
+
+		size, err := imgdata.Size()
+		if err != nil {
+			imgdata.Close()
+			return nil, err
+		}
+
+		if size <= maxBytes {
+			return imgdata, nil
+		}
+
+		// The result doesn't fit the max bytes limit.
+		// Lower the quality starting from the selected one.
+		imgdata.Close()
+		quality = aq
+	}
+
+	// Animations can be fit into the specified number of bytes
+	// by dropping frames even if the format doesn't support quality.
+	if maxBytes > 0 && img.IsAnimated() {
+		return saveAnimationToFitBytes(ctx, img, outFormat, quality, maxBytes, po)
+	}
 
 	// If we want and can fit the image into the specified number of bytes,
 	// let's do it.
-	if maxBytes := po.MaxBytes(); maxBytes > 0 && outFormat.SupportsQuality() {
-		return saveImageToFitBytes(ctx, img, outFormat, quality, maxBytes, po.Options)
+	if maxBytes > 0 && po.SupportsQuality(outFormat) {
+		imgdata, _, err := saveImageToFitBytes(ctx, img, outFormat, quality, maxBytes, po.Options)
+		return imgdata, err
 	}
 
 	// Otherwise, just save the image with the specified quality.
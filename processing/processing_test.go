@@ -3,11 +3,15 @@ package processing_test
 import (
 	"bytes"
 	"fmt"
+	"image/color"
+	"image/gif"
 	"testing"
 
+	"github.com/imgproxy/imgproxy/v4/imagetype"
 	"github.com/imgproxy/imgproxy/v4/processing"
 	"github.com/imgproxy/imgproxy/v4/testutil"
 	"github.com/imgproxy/imgproxy/v4/testutil/servertest"
+	"github.com/imgproxy/imgproxy/v4/vips"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -824,6 +828,180 @@ func (s *ProcessingTestSuite) TestImageResolutionTooLarge() {
 	)
 }
 
+func (s *ProcessingTestSuite) TestPageOutOfRange() {
+	resp := s.GET("/unsafe/page:1/plain/local:///geometry.png")
+	defer resp.Body.Close()
+
+	s.Require().Equal(
+		422, resp.StatusCode,
+		"Expected status code 422 for out of range page",
+	)
+}
+
+func (s *ProcessingTestSuite) TestPages() {
+	colors := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+		{R: 255, G: 255, A: 255},
+		{R: 255, B: 255, A: 255},
+	}
+	delays := []int{100, 200, 300, 400, 500}
+
+	imagePath := s.useTempImage("anim.gif", s.makeAnimatedGIF(8, 8, colors, delays))
+
+	testCases := []struct {
+		name   string
+		opts   string
+		frames []int
+	}{
+		{name: "All", opts: "", frames: []int{0, 1, 2, 3, 4}},
+		{name: "Page", opts: "page:3", frames: []int{3, 4}},
+		{name: "Pages", opts: "pages:2", frames: []int{0, 1}},
+		{name: "PageAndPages", opts: "page:1/pages:3", frames: []int{1, 2, 3}},
+		{name: "SinglePage", opts: "page:2/pages:1", frames: []int{2}},
+		{name: "PagesOverflow", opts: "page:3/pages:10", frames: []int{3, 4}},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			urlOptions := "format:gif"
+			if len(tc.opts) > 0 {
+				urlOptions += "/" + tc.opts
+			}
+
+			resultData := s.processImage(rawOpts{imagePath: imagePath, urlOptions: urlOptions})
+			defer resultData.Close()
+
+			result, err := gif.DecodeAll(resultData.Reader())
+			s.Require().NoError(err)
+
+			s.Require().Len(result.Image, len(tc.frames), "Frames count mismatch")
+
+			for i, frame := range tc.frames {
+				s.requireColor(colors[frame], result.Image[i].At(4, 4), "Frame %d color mismatch", i)
+
+				// Single-frame results have no delay info
+				if len(tc.frames) > 1 {
+					s.Require().Equal(delays[frame]/10, result.Delay[i], "Frame %d delay mismatch", i)
+				}
+			}
+		})
+	}
+}
+
+func (s *ProcessingTestSuite) TestAnimatedSave() {
+	colors := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+	}
+	delays := []int{100, 200, 300}
+
+	// AVIF has a minimal dimension of 16 pixels
+	imagePath := s.useTempImage("anim.gif", s.makeAnimatedGIF(16, 16, colors, delays))
+
+	for _, format := range []imagetype.Type{imagetype.AVIF, imagetype.JXL} {
+		s.Run(format.String(), func() {
+			if !vips.SupportsAnimationSave(format) {
+				s.T().Skipf("libvips doesn't support animated %s saving", format)
+			}
+
+			resultData := s.processImage(rawOpts{
+				imagePath:  imagePath,
+				urlOptions: "format:" + format.String(),
+			})
+			defer resultData.Close()
+
+			s.Require().Equal(format, resultData.Format())
+
+			result := new(vips.Image)
+			defer result.Clear()
+
+			s.Require().NoError(result.Load(resultData, 1.0, 0, -1))
+
+			s.Require().Equal(len(colors), result.PagesLoaded(), "Frames count mismatch")
+
+			resultDelays, err := result.GetIntSlice("delay")
+			s.Require().NoError(err)
+			s.Require().Equal(delays, resultDelays, "Delays mismatch")
+
+			loop, err := result.GetInt("loop")
+			s.Require().NoError(err)
+			s.Require().Equal(0, loop, "Loop count mismatch")
+		})
+	}
+}
+
+func (s *ProcessingTestSuite) TestAnimatedSaveSmallAVIF() {
+	if !vips.SupportsAnimationSave(imagetype.AVIF) {
+		s.T().Skip("libvips doesn't support animated AVIF saving")
+	}
+
+	colors := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+	}
+	delays := []int{100, 200, 300}
+
+	imagePath := s.useTempImage("anim.gif", s.makeAnimatedGIF(8, 8, colors, delays))
+
+	resultData := s.processImage(rawOpts{imagePath: imagePath, urlOptions: "format:avif"})
+	defer resultData.Close()
+
+	// The image is too small for AVIF, so it's saved in a format that keeps the animation
+	s.Require().Equal(imagetype.WEBP, resultData.Format())
+
+	result := new(vips.Image)
+	defer result.Clear()
+
+	s.Require().NoError(result.Load(resultData, 1.0, 0, -1))
+	s.Require().Equal(len(colors), result.PagesLoaded(), "Frames count mismatch")
+}
+
+func (s *ProcessingTestSuite) TestAnimationOptions() {
+	colors := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+		{R: 255, G: 255, A: 255},
+	}
+	delays := []int{100, 100, 100, 100}
+
+	imagePath := s.useTempImage("anim.gif", s.makeAnimatedGIF(16, 16, colors, delays))
+
+	resultData := s.processImage(rawOpts{
+		imagePath:  imagePath,
+		urlOptions: "format:gif/drop_frames:2/animation_speed:2/animation_loop:3",
+	})
+	defer resultData.Close()
+
+	result := new(vips.Image)
+	defer result.Clear()
+
+	s.Require().NoError(result.Load(resultData, 1.0, 0, -1))
+
+	// Every second frame is dropped, and its delay is added to the previous frame.
+	// Then the delays are halved.
+	s.Require().Equal(2, result.PagesLoaded(), "Frames count mismatch")
+
+	resultDelays, err := result.GetIntSlice("delay")
+	s.Require().NoError(err)
+	s.Require().Equal([]int{100, 100}, resultDelays, "Delays mismatch")
+
+	loop, err := result.GetInt("loop")
+	s.Require().NoError(err)
+	s.Require().Equal(3, loop, "Loop count mismatch")
+
+	resultGIF, err := gif.DecodeAll(resultData.Reader())
+	s.Require().NoError(err)
+	s.Require().Len(resultGIF.Image, 2)
+
+	s.requireColor(colors[0], resultGIF.Image[0].At(8, 8), "Frame 0 color mismatch")
+	s.requireColor(colors[2], resultGIF.Image[1].At(8, 8), "Frame 1 color mismatch")
+}
+
 func TestProcessing(t *testing.T) {
 	suite.Run(t, new(ProcessingTestSuite))
 }
@@ -2,8 +2,11 @@ package processing
 
 import (
 	"github.com/imgproxy/imgproxy/v4/auximageprovider"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
 	"github.com/imgproxy/imgproxy/v4/processing/svg"
+	"github.com/imgproxy/imgproxy/v4/processing/video"
 	"github.com/imgproxy/imgproxy/v4/security"
+	"github.com/imgproxy/imgproxy/v4/vips"
 )
 
 // Processor is responsible for processing images according to the given configuration.
@@ -12,6 +15,7 @@ type Processor struct {
 	securityChecker   *security.Checker
 	watermarkProvider auximageprovider.Provider
 	svg               *svg.Processor
+	video             *video.Processor
 }
 
 // New creates a new Processor instance with the given configuration and watermark provider
@@ -29,5 +33,16 @@ func New(
 		securityChecker:   securityChecker,
 		watermarkProvider: watermark,
 		svg:               svg.New(&config.Svg),
+		video:             video.New(&config.Video, securityChecker),
 	}, nil
 }
+
+// SupportsLoad checks if the processor can load images of the given type.
+// Video sources are supported if video thumbnails are enabled.
+func (p *Processor) SupportsLoad(t imagetype.Type) bool {
+	if t.IsVideo() {
+		return p.video.Enabled()
+	}
+
+	return vips.SupportsLoad(t)
+}
@@ -0,0 +1,35 @@
+package processing
+
+// rotateFree rotates the image by an arbitrary angle.
+// Since the rotation changes the image geometry, we perform it before
+// any calculations that depend on it, like trim does.
+func (p *Processor) rotateFree(c *Context) error {
+	angle := c.PO.RotateFree()
+	if angle == 0 {
+		return nil
+	}
+
+	// EXIF orientation and rotation by a multiple of 90 are applied later.
+	// Rotations commute, but flipping reverses the rotation direction,
+	// so we need to compensate it.
+	if c.Flip {
+		angle = -angle
+	}
+
+	// We need the image in random access mode, so we copy it to memory.
+	if err := c.Img.CopyMemory(); err != nil {
+		return err
+	}
+
+	if err := c.Img.RotateFree(angle, c.PO.RotateAutoCrop()); err != nil {
+		return err
+	}
+	if err := c.Img.CopyMemory(); err != nil {
+		return err
+	}
+
+	c.ImgData = nil
+	c.CalcParams()
+
+	return nil
+}
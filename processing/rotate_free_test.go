@@ -0,0 +1,116 @@
+package processing_test
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RotateFreeTestSuite struct {
+	testSuite
+}
+
+// redPixelsCovariance returns the covariance of the x and y coordinates
+// of the red pixels of the image. It's positive when the red shapes
+// stretch from the top left to the bottom right, and negative otherwise.
+func redPixelsCovariance(img image.Image) float64 {
+	var n, sumX, sumY, sumXY float64
+
+	bounds := img.Bounds()
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, _, a := img.At(x, y).RGBA()
+			if r>>8 < 128 || g>>8 > 128 || a>>8 < 128 {
+				continue
+			}
+
+			n++
+			sumX += float64(x)
+			sumY += float64(y)
+			sumXY += float64(x * y)
+		}
+	}
+
+	if n == 0 {
+		return 0
+	}
+
+	return sumXY/n - sumX/n*sumY/n
+}
+
+func (s *RotateFreeTestSuite) TestRotateFree() {
+	// orientation-N.png images have the same 200x100 pixel data
+	// with three red faces in a row and EXIF orientation N
+	testCases := []struct {
+		orientation int
+		autoCrop    bool
+		width       int
+		height      int
+	}{
+		{orientation: 1, width: 224, height: 187},
+		{orientation: 1, autoCrop: true, width: 98, height: 55},
+		// Flipped horizontally
+		{orientation: 2, width: 224, height: 187},
+		{orientation: 2, autoCrop: true, width: 98, height: 55},
+		// Rotated by 180
+		{orientation: 3, width: 224, height: 187},
+		// Flipped vertically
+		{orientation: 4, width: 224, height: 187},
+		// Transposed
+		{orientation: 5, width: 187, height: 224},
+		{orientation: 5, autoCrop: true, width: 55, height: 98},
+		// Rotated by 90
+		{orientation: 6, width: 187, height: 224},
+		{orientation: 6, autoCrop: true, width: 55, height: 98},
+		// Transversed
+		{orientation: 7, width: 187, height: 224},
+		// Rotated by 270
+		{orientation: 8, width: 187, height: 224},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("orientation-%d_autocrop-%t", tc.orientation, tc.autoCrop)
+
+		s.Run(name, func() {
+			urlOptions := "format:png/rotate:30"
+			if tc.autoCrop {
+				urlOptions += ":1"
+			}
+
+			resultData := s.processImage(rawOpts{
+				imagePath:  fmt.Sprintf("orientation-%d.png", tc.orientation),
+				urlOptions: urlOptions,
+			})
+			defer resultData.Close()
+
+			result, err := png.Decode(resultData.Reader())
+			s.Require().NoError(err)
+
+			// The rotated image bounding box size may differ by a pixel due to rounding
+			s.Require().InDelta(tc.width, result.Bounds().Dx(), 2)
+			s.Require().InDelta(tc.height, result.Bounds().Dy(), 2)
+
+			if tc.autoCrop {
+				return
+			}
+
+			// The image should be rotated clockwise as displayed according to the EXIF orientation.
+			// So the row of faces stretches from the top left to the bottom right in landscape images,
+			// and from the top right to the bottom left in portrait images.
+			cov := redPixelsCovariance(result)
+			if tc.width > tc.height {
+				s.Require().Positive(cov)
+			} else {
+				s.Require().Negative(cov)
+			}
+		})
+	}
+}
+
+func TestRotateFree(t *testing.T) {
+	suite.Run(t, new(RotateFreeTestSuite))
+}
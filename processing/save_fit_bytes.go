@@ -14,7 +14,8 @@ import (
 
 // saveImageToFitBytes tries to save the image to fit into the specified max bytes
 // by lowering the quality. It returns the image data that fits the requirement
-// or the best effort data if it was not possible to fit into the limit.
+// or the best effort data if it was not possible to fit into the limit,
+// and the quality the data was saved with.
 func saveImageToFitBytes(
 	ctx context.Context,
 	img *vips.Image,
@@ -22,7 +23,7 @@ func saveImageToFitBytes(
 	startQuality int,
 	target int,
 	o *options.Options,
-) (imagedata.ImageData, error) {
+) (imagedata.ImageData, int, error) {
 	var newQuality int
 
 	// Start with the specified quality and go down from there.
@@ -31,32 +32,32 @@ func saveImageToFitBytes(
 	// We will probably save the image multiple times, so we need to process its pixels
 	// to ensure that it is in random access mode.
 	if err := img.CopyMemory(); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	for {
 		// Check for timeout or cancellation before each attempt as we might spend too much
 		// time processing the image or making previous attempts.
 		if err := server.CheckTimeout(ctx); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		o.Set(keys.Quality, quality)
 
 		imgdata, err := img.Save(format, quality, o)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		size, err := imgdata.Size()
 		if err != nil {
 			imgdata.Close()
-			return nil, err
+			return nil, 0, err
 		}
 
 		// If we fit the limit or quality is too low, return the result.
 		if size <= target || quality <= 10 {
-			return imgdata, err
+			return imgdata, quality, nil
 		}
 
 		// We don't need the image data anymore, close it to free resources.
@@ -79,3 +80,69 @@ func saveImageToFitBytes(
 		quality = max(1, min(quality-1, newQuality))
 	}
 }
+
+// saveAnimationToFitBytes tries to save the animated image to fit into the specified max bytes.
+// If lowering the quality is not enough or the format doesn't support quality,
+// it drops every second frame until the result fits or a single frame is left.
+// Frames are dropped from a copy of the image, so the image itself stays intact.
+func saveAnimationToFitBytes(
+	ctx context.Context,
+	img *vips.Image,
+	format imagetype.Type,
+	quality int,
+	target int,
+	po ProcessingOptions,
+) (imagedata.ImageData, error) {
+	anim := new(vips.Image)
+	defer anim.Clear()
+
+	src := img
+
+	for {
+		var (
+			imgdata imagedata.ImageData
+			err     error
+		)
+
+		if po.SupportsQuality(format) {
+			// Continue with the reached quality so we don't repeat
+			// the whole quality sweep after dropping frames
+			imgdata, quality, err = saveImageToFitBytes(ctx, src, format, quality, target, po.Options)
+		} else {
+			imgdata, err = src.Save(format, quality, po.Options)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := imgdata.Size()
+		if err != nil {
+			imgdata.Close()
+			return nil, err
+		}
+
+		// If we fit the limit or there is nothing to drop, return the result.
+		if size <= target || src.PagesLoaded() <= 1 {
+			return imgdata, nil
+		}
+
+		// We don't need the image data anymore, close it to free resources.
+		imgdata.Close()
+
+		if err = server.CheckTimeout(ctx); err != nil {
+			return nil, err
+		}
+
+		if src == img {
+			if err = img.Copy(anim); err != nil {
+				return nil, err
+			}
+
+			src = anim
+		}
+
+		if err = dropAnimationFrames(src, 2); err != nil {
+			return nil, err
+		}
+	}
+}
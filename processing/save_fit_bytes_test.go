@@ -0,0 +1,99 @@
+package processing_test
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/vips"
+)
+
+type SaveFitBytesTestSuite struct {
+	testSuite
+}
+
+// useAnimation creates anim.gif with the given number of solid color frames
+func (s *SaveFitBytesTestSuite) useAnimation(frames int) {
+	colors := make([]color.RGBA, frames)
+	delays := make([]int, frames)
+
+	for i := range frames {
+		colors[i] = color.RGBA{R: uint8(i * 30), G: 255 - uint8(i*30), A: 255}
+		delays[i] = 100
+	}
+
+	s.useTempImage("anim.gif", s.makeAnimatedGIF(16, 16, colors, delays))
+}
+
+// loadResult loads all the frames of the result image
+func (s *SaveFitBytesTestSuite) loadResult(urlOptions string) *vips.Image {
+	resultData := s.processImage(rawOpts{imagePath: "anim.gif", urlOptions: urlOptions})
+	defer resultData.Close()
+
+	img := new(vips.Image)
+	s.Require().NoError(img.Load(resultData, 1.0, 0, -1))
+
+	return img
+}
+
+func (s *SaveFitBytesTestSuite) TestAnimation() {
+	s.useAnimation(8)
+
+	testCases := []struct {
+		format   imagetype.Type
+		maxBytes int
+		frames   int
+	}{
+		// The limit can't be reached, so the frames are dropped down to a single one
+		{format: imagetype.GIF, maxBytes: 1, frames: 1},
+		{format: imagetype.WEBP, maxBytes: 1, frames: 1},
+		// The animation fits the limit, so no frames are dropped
+		{format: imagetype.GIF, maxBytes: 1024 * 1024, frames: 8},
+		{format: imagetype.WEBP, maxBytes: 1024 * 1024, frames: 8},
+	}
+
+	for _, tc := range testCases {
+		s.Run(fmt.Sprintf("%s_%d", tc.format, tc.maxBytes), func() {
+			img := s.loadResult(fmt.Sprintf("format:%s/max_bytes:%d", tc.format, tc.maxBytes))
+			defer img.Clear()
+
+			s.Require().Equal(tc.frames, img.PagesLoaded(), "Frames count mismatch")
+		})
+	}
+}
+
+func (s *SaveFitBytesTestSuite) TestLossless() {
+	for _, format := range []imagetype.Type{imagetype.WEBP, imagetype.AVIF} {
+		s.Run(format.String(), func() {
+			if !vips.SupportsSave(format) {
+				s.T().Skipf("libvips doesn't support %s saving", format)
+			}
+
+			urlOptions := fmt.Sprintf("format:%s/lossless:1/quality:50", format)
+
+			expected := s.processImage(rawOpts{imagePath: "test1.png", urlOptions: urlOptions})
+			defer expected.Close()
+
+			// Quality doesn't affect the size of lossless images,
+			// so the image is saved once with the requested settings
+			result := s.processImage(rawOpts{imagePath: "test1.png", urlOptions: urlOptions + "/max_bytes:1"})
+			defer result.Close()
+
+			expectedBytes, err := io.ReadAll(expected.Reader())
+			s.Require().NoError(err)
+
+			resultBytes, err := io.ReadAll(result.Reader())
+			s.Require().NoError(err)
+
+			s.Require().Equal(expectedBytes, resultBytes)
+		})
+	}
+}
+
+func TestSaveFitBytes(t *testing.T) {
+	suite.Run(t, new(SaveFitBytesTestSuite))
+}
@@ -27,7 +27,7 @@ func (p *Processor) scale(c *Context) error {
 		}
 	}
 
-	if err := c.Img.Resize(wscale, hscale); err != nil {
+	if err := c.Img.Resize(wscale, hscale, c.PO.ResizingAlgorithm()); err != nil {
 		return err
 	}
 
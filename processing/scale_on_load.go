@@ -22,9 +22,17 @@ func (p *Processor) canScaleOnLoad(c *Context, shrink float64) bool {
 		return false
 	}
 
+	// Shrink-on-load smooths the image, which defeats the purpose
+	// of the nearest neighbor resampling
+	if c.PO.ResizingAlgorithm() == vips.KernelNearest {
+		return false
+	}
+
+	// Embedded thumbnails belong to the primary image,
+	// so we can't use them if another page is requested
 	return c.ImgData.Format() == imagetype.JPEG ||
 		c.ImgData.Format() == imagetype.WEBP ||
-		c.ImgData.Format().SupportsThumbnail()
+		(c.ImgData.Format().SupportsThumbnail() && c.PO.Page() == 0)
 }
 
 func calcJpegShink(shrink float64) float64 {
@@ -57,6 +65,16 @@ func (p *Processor) scaleOnLoad(c *Context) error {
 		preshrink *= c.VectorBaseShrink
 	}
 
+	// PDF pages may be rasterized at any scale, so we need to ensure
+	// that the rasterized page doesn't exceed the max source resolution.
+	// SVG images are guarded by [Processor.vectorGuardScale].
+	// c.SrcWidth and c.SrcHeight are already scaled with the vector base shrink.
+	if c.ImgData != nil && c.ImgData.Format() == imagetype.PDF {
+		res := float64(c.SrcWidth) * float64(c.SrcHeight)
+		minPreshrink := c.VectorBaseShrink * math.Sqrt(res/float64(c.PO.MaxSrcResolution()))
+		preshrink = max(preshrink, minPreshrink)
+	}
+
 	// Check if we can and should scale the image on load
 	if !p.canScaleOnLoad(c, preshrink) {
 		return nil
@@ -90,7 +108,7 @@ func (p *Processor) scaleOnLoad(c *Context) error {
 		}
 
 		// Reload the image with preshrink
-		if err := newImg.Load(c.ImgData, preshrink, 0, 1); err != nil {
+		if err := newImg.Load(c.ImgData, preshrink, c.PO.Page(), 1); err != nil {
 			return err
 		}
 	}
@@ -1,9 +1,15 @@
 package processing_test
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 
 	"github.com/imgproxy/imgproxy/v4/imagedata"
 	"github.com/imgproxy/imgproxy/v4/logger"
@@ -55,6 +61,20 @@ func (c testCase[T]) OutInterpretation() vips.Interpretation {
 	return c.outInterpretation
 }
 
+// rawOpts is an options factory that uses the image path and the URL options as is
+type rawOpts struct {
+	imagePath  string
+	urlOptions string
+}
+
+func (o rawOpts) ImagePath() string {
+	return o.imagePath
+}
+
+func (o rawOpts) URLOptions() string {
+	return o.urlOptions
+}
+
 type testSize struct {
 	width  int
 	height int
@@ -128,3 +148,55 @@ func (s *testSuite) processImageAndCheck(tc testCaseParams) {
 
 	s.ImageMatcher().ImageMatches(s.T(), resultData.Reader(), "test", 0.0005)
 }
+
+// useTempImage writes the image data to a temporary directory and makes it
+// the local files root. It returns the image path to use in the source URL.
+func (s *testSuite) useTempImage(name string, data []byte) string {
+	s.T().Helper()
+
+	dir := s.T().TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, name), data, 0o600)
+	s.Require().NoError(err)
+
+	s.Config().Fetcher.Transport.Local.Root = dir
+
+	return name
+}
+
+// makeAnimatedGIF creates an animated GIF with solid color frames.
+// Delays are set in milliseconds.
+func (s *testSuite) makeAnimatedGIF(width, height int, colors []color.RGBA, delays []int) []byte {
+	s.T().Helper()
+
+	anim := &gif.GIF{LoopCount: 0}
+
+	for i, c := range colors {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{c})
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delays[i]/10)
+	}
+
+	var buf bytes.Buffer
+	s.Require().NoError(gif.EncodeAll(&buf, anim))
+
+	return buf.Bytes()
+}
+
+// requireColor checks that the color is close to the expected one.
+// Lossy formats and palette quantization may slightly change colors.
+func (s *testSuite) requireColor(expected color.RGBA, actual color.Color, msgAndArgs ...any) {
+	s.T().Helper()
+
+	r, g, b, _ := actual.RGBA()
+
+	const tolerance = 8
+
+	for _, ch := range [][2]int{
+		{int(expected.R), int(r >> 8)},
+		{int(expected.G), int(g >> 8)},
+		{int(expected.B), int(b >> 8)},
+	} {
+		s.Require().InDelta(ch[0], ch[1], tolerance, msgAndArgs...)
+	}
+}
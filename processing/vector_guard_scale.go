@@ -17,7 +17,7 @@ func (p *Processor) vectorGuardScale(c *Context) error {
 		shrink := math.Sqrt(float64(resolution) / float64(maxSrcRes))
 		c.VectorBaseShrink = shrink
 
-		if err := c.Img.Load(c.ImgData, shrink, 0, 1); err != nil {
+		if err := c.Img.Load(c.ImgData, shrink, c.PO.Page(), 1); err != nil {
 			return err
 		}
 	}
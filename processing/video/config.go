@@ -0,0 +1,89 @@
+package video
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/imgproxy/imgproxy/v4/ensure"
+	"github.com/imgproxy/imgproxy/v4/env"
+)
+
+var (
+	IMGPROXY_ENABLE_VIDEO_THUMBNAILS                = env.Bool("IMGPROXY_ENABLE_VIDEO_THUMBNAILS")
+	IMGPROXY_VIDEO_FFMPEG_PATH                      = env.String("IMGPROXY_VIDEO_FFMPEG_PATH")
+	IMGPROXY_VIDEO_THUMBNAIL_SECOND                 = env.Float("IMGPROXY_VIDEO_THUMBNAIL_SECOND")
+	IMGPROXY_VIDEO_THUMBNAIL_BEST_FRAME_CANDIDATES  = env.Int("IMGPROXY_VIDEO_THUMBNAIL_BEST_FRAME_CANDIDATES")
+	IMGPROXY_VIDEO_THUMBNAIL_ANIMATION_MAX_DURATION = env.Float("IMGPROXY_VIDEO_THUMBNAIL_ANIMATION_MAX_DURATION")
+	IMGPROXY_VIDEO_THUMBNAIL_ANIMATION_FPS          = env.Float("IMGPROXY_VIDEO_THUMBNAIL_ANIMATION_FPS")
+)
+
+// Config holds video-specific configuration
+type Config struct {
+	Enabled              bool    // Enable video thumbnails
+	FfmpegPath           string  // Path to the ffmpeg binary
+	Second               float64 // Default timestamp of the extracted frame, in seconds
+	BestFrameCandidates  int     // Number of frames analyzed to find the best frame
+	AnimationMaxDuration float64 // Maximum duration of the animated preview, in seconds
+	AnimationFPS         float64 // Default frame rate of the animated preview
+}
+
+// NewDefaultConfig creates a new Config instance with default values
+func NewDefaultConfig() Config {
+	return Config{
+		Enabled:              false,
+		FfmpegPath:           "ffmpeg",
+		Second:               1,
+		BestFrameCandidates:  100,
+		AnimationMaxDuration: 10,
+		AnimationFPS:         10,
+	}
+}
+
+// LoadConfigFromEnv loads configuration from environment variables
+func LoadConfigFromEnv(c *Config) (*Config, error) {
+	c = ensure.Ensure(c, NewDefaultConfig)
+
+	err := errors.Join(
+		IMGPROXY_ENABLE_VIDEO_THUMBNAILS.Parse(&c.Enabled),
+		IMGPROXY_VIDEO_FFMPEG_PATH.Parse(&c.FfmpegPath),
+		IMGPROXY_VIDEO_THUMBNAIL_SECOND.Parse(&c.Second),
+		IMGPROXY_VIDEO_THUMBNAIL_BEST_FRAME_CANDIDATES.Parse(&c.BestFrameCandidates),
+		IMGPROXY_VIDEO_THUMBNAIL_ANIMATION_MAX_DURATION.Parse(&c.AnimationMaxDuration),
+		IMGPROXY_VIDEO_THUMBNAIL_ANIMATION_FPS.Parse(&c.AnimationFPS),
+	)
+
+	return c, err
+}
+
+// Validate checks if the configuration is valid
+func (c *Config) Validate() error {
+	if c.Second < 0 {
+		return IMGPROXY_VIDEO_THUMBNAIL_SECOND.ErrorNegative()
+	}
+
+	if c.BestFrameCandidates <= 0 {
+		return IMGPROXY_VIDEO_THUMBNAIL_BEST_FRAME_CANDIDATES.ErrorZeroOrNegative()
+	}
+
+	if c.AnimationMaxDuration <= 0 {
+		return IMGPROXY_VIDEO_THUMBNAIL_ANIMATION_MAX_DURATION.ErrorZeroOrNegative()
+	}
+
+	if c.AnimationFPS <= 0 {
+		return IMGPROXY_VIDEO_THUMBNAIL_ANIMATION_FPS.ErrorZeroOrNegative()
+	}
+
+	if !c.Enabled {
+		return nil
+	}
+
+	if len(c.FfmpegPath) == 0 {
+		return IMGPROXY_VIDEO_FFMPEG_PATH.ErrorEmpty()
+	}
+
+	if _, err := exec.LookPath(c.FfmpegPath); err != nil {
+		return IMGPROXY_VIDEO_FFMPEG_PATH.Errorf("ffmpeg not found: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package video
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/imgproxy/imgproxy/v4/errctx"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+)
+
+type (
+	DisabledError     struct{ *errctx.TextError }
+	FileSizeError     struct{ *errctx.TextError }
+	ExtractFrameError struct{ *errctx.WrappedError }
+)
+
+func newDisabledError(format imagetype.Type) error {
+	return DisabledError{errctx.NewTextError(
+		fmt.Sprintf("Can't process %s: video thumbnails are disabled", format),
+		1,
+		errctx.WithStatusCode(http.StatusUnprocessableEntity),
+		errctx.WithPublicMessage("Invalid source image"),
+		errctx.WithShouldReport(false),
+	)}
+}
+
+func newFileSizeError() error {
+	return FileSizeError{errctx.NewTextError(
+		"Source video file is too big",
+		1,
+		errctx.WithStatusCode(http.StatusUnprocessableEntity),
+		errctx.WithPublicMessage("Invalid source image"),
+		errctx.WithShouldReport(false),
+	)}
+}
+
+func newExtractFrameError(err error) error {
+	return ExtractFrameError{errctx.NewWrappedError(
+		err,
+		1,
+		errctx.WithStatusCode(http.StatusUnprocessableEntity),
+		errctx.WithPublicMessage("Broken or unsupported video"),
+		errctx.WithShouldReport(true),
+	)}
+}
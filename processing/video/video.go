@@ -0,0 +1,313 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/imgproxy/imgproxy/v4/errctx"
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/options/keys"
+	"github.com/imgproxy/imgproxy/v4/security"
+	"github.com/imgproxy/imgproxy/v4/server"
+)
+
+// demuxers maps video formats to the ffmpeg demuxers that read them.
+// We pin the demuxer so ffmpeg never probes the source for other formats.
+var demuxers = map[imagetype.Type]string{
+	imagetype.MP4:  "mov",
+	imagetype.MOV:  "mov",
+	imagetype.WEBM: "matroska",
+}
+
+// input describes the ffmpeg input
+type input struct {
+	path      string // Path to the source file
+	demuxer   string // ffmpeg demuxer name
+	maxPixels int    // Maximum allowed number of pixels in a video frame
+}
+
+// Processor extracts frames from video sources using ffmpeg
+type Processor struct {
+	config          *Config
+	securityChecker *security.Checker
+}
+
+// New creates a new video processor instance
+func New(config *Config, securityChecker *security.Checker) *Processor {
+	return &Processor{
+		config:          config,
+		securityChecker: securityChecker,
+	}
+}
+
+// Enabled returns true if video thumbnails are enabled
+func (p *Processor) Enabled() bool {
+	return p.config.Enabled
+}
+
+// Extract extracts a single frame or an animated preview from the video.
+// A single frame is returned as PNG, an animated preview is returned as WebP.
+// The returned image data is owned by the caller.
+func (p *Processor) Extract(
+	ctx context.Context,
+	o *options.Options,
+	data imagedata.ImageData,
+) (imagedata.ImageData, error) {
+	if !p.config.Enabled {
+		return nil, newDisabledError(data.Format())
+	}
+
+	demuxer, ok := demuxers[data.Format()]
+	if !ok {
+		return nil, newExtractFrameError(fmt.Errorf("unsupported video format: %s", data.Format()))
+	}
+
+	// ffmpeg needs to seek through the source, so we can't pipe it
+	src, err := p.writeSource(o, data)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(src)
+
+	if err = server.CheckTimeout(ctx); err != nil {
+		return nil, err
+	}
+
+	in := input{
+		path:      src,
+		demuxer:   demuxer,
+		maxPixels: p.securityChecker.MaxSrcResolution(o),
+	}
+
+	if duration := p.animationDuration(o); duration > 0 {
+		return p.extractAnimation(ctx, o, in, duration)
+	}
+
+	return p.extractFrame(ctx, o, in)
+}
+
+// writeSource writes the video data to a temporary file
+// respecting the max source file size
+func (p *Processor) writeSource(o *options.Options, data imagedata.ImageData) (string, error) {
+	f, err := os.CreateTemp("", "imgproxy-video-*"+data.Format().Ext())
+	if err != nil {
+		return "", errctx.Wrap(err)
+	}
+
+	var r io.Reader = data.Reader()
+
+	maxSize := int64(p.securityChecker.MaxSrcFileSize(o))
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+
+	n, err := io.Copy(f, r)
+
+	switch {
+	case err != nil:
+		err = errctx.Wrap(err)
+	case maxSize > 0 && n > maxSize:
+		err = newFileSizeError()
+	default:
+		err = errctx.Wrap(f.Close())
+	}
+
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// animationDuration returns the requested animated preview duration
+// limited by the configured maximum. 0 means no animation.
+func (p *Processor) animationDuration(o *options.Options) float64 {
+	duration := o.GetFloat(keys.VideoThumbnailAnimationDuration, 0)
+	if duration <= 0 {
+		return 0
+	}
+
+	return min(duration, p.config.AnimationMaxDuration)
+}
+
+// extractFrame extracts a single frame at the requested timestamp.
+// If the best frame is requested, ffmpeg picks the most representative frame
+// among the frames following the timestamp.
+func (p *Processor) extractFrame(
+	ctx context.Context,
+	o *options.Options,
+	in input,
+) (imagedata.ImageData, error) {
+	second := o.GetFloat(keys.VideoThumbnailSecond, p.config.Second)
+	bestFrame := o.GetBool(keys.VideoThumbnailBestFrame, false)
+
+	out, err := p.run(ctx, p.frameArgs(in, second, bestFrame))
+
+	// ffmpeg doesn't produce any frame when the timestamp is beyond
+	// the video duration. Fall back to the beginning of the video in this case.
+	if err == nil && len(out) == 0 && second > 0 {
+		out, err = p.run(ctx, p.frameArgs(in, 0, bestFrame))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) == 0 {
+		return nil, newExtractFrameError(errors.New("no video frames extracted"))
+	}
+
+	return imagedata.NewFromBytesWithFormat(imagetype.PNG, out), nil
+}
+
+// extractAnimation extracts an animated WebP preview starting at the requested timestamp.
+// Unlike a single frame, the preview starts at the beginning of the video by default.
+func (p *Processor) extractAnimation(
+	ctx context.Context,
+	o *options.Options,
+	in input,
+	duration float64,
+) (imagedata.ImageData, error) {
+	second := o.GetFloat(keys.VideoThumbnailSecond, 0)
+	fps := o.GetFloat(keys.VideoThumbnailAnimationFPS, p.config.AnimationFPS)
+
+	frames := min(
+		int(math.Ceil(duration*fps)),
+		p.securityChecker.MaxAnimationFrames(o),
+	)
+
+	// Downscale the frames so the whole animation fits the resolution limits
+	maxFrameRes := p.securityChecker.MaxAnimationFrameResolution(o)
+	if maxFrameRes <= 0 {
+		maxFrameRes = in.maxPixels / max(frames, 1)
+	}
+
+	dst, err := os.CreateTemp("", "imgproxy-video-*.webp")
+	if err != nil {
+		return nil, errctx.Wrap(err)
+	}
+	dst.Close()
+	defer os.Remove(dst.Name())
+
+	if _, err = p.run(ctx, p.animationArgs(in, dst.Name(), second, duration, fps, frames, maxFrameRes)); err != nil {
+		return nil, err
+	}
+
+	out, err := os.ReadFile(dst.Name())
+	if err != nil {
+		return nil, errctx.Wrap(err)
+	}
+
+	if len(out) == 0 {
+		return nil, newExtractFrameError(errors.New("no video frames extracted"))
+	}
+
+	return imagedata.NewFromBytesWithFormat(imagetype.WEBP, out), nil
+}
+
+// frameArgs builds ffmpeg arguments to extract a single PNG frame to stdout
+func (p *Processor) frameArgs(in input, second float64, bestFrame bool) []string {
+	args := p.inputArgs(in, second, 0)
+
+	if bestFrame {
+		args = append(args, "-vf", "thumbnail="+strconv.Itoa(p.config.BestFrameCandidates))
+	}
+
+	return append(args,
+		"-frames:v", "1",
+		"-c:v", "png",
+		"-f", "image2pipe",
+		"pipe:1",
+	)
+}
+
+// animationArgs builds ffmpeg arguments to encode an animated WebP preview to dst.
+// Frames larger than maxFrameRes pixels are downscaled keeping the aspect ratio.
+func (p *Processor) animationArgs(
+	in input,
+	dst string,
+	second, duration, fps float64,
+	frames, maxFrameRes int,
+) []string {
+	args := p.inputArgs(in, second, duration)
+
+	// Scale factor to fit the frame into maxFrameRes; the frame is never upscaled
+	scale := fmt.Sprintf("sqrt(%d/(iw*ih))", maxFrameRes)
+
+	filter := fmt.Sprintf(
+		"fps=%s,scale=w='min(iw,trunc(iw*%[2]s))':h='min(ih,trunc(ih*%[2]s))'",
+		formatFloat(fps), scale,
+	)
+
+	return append(args,
+		"-vf", filter,
+		"-frames:v", strconv.Itoa(frames),
+		"-c:v", "libwebp",
+		"-lossless", "1",
+		"-loop", "0",
+		"-f", "webp",
+		"-y", dst,
+	)
+}
+
+// inputArgs builds common ffmpeg arguments to read the first video stream of the input.
+// ffmpeg is allowed to read only local files with the demuxer of the detected format,
+// and the decoder rejects frames larger than the max source resolution.
+func (p *Processor) inputArgs(in input, second, duration float64) []string {
+	args := []string{
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-protocol_whitelist", "file",
+		"-max_pixels", strconv.Itoa(in.maxPixels),
+	}
+
+	if second > 0 {
+		args = append(args, "-ss", formatFloat(second))
+	}
+
+	if duration > 0 {
+		args = append(args, "-t", formatFloat(duration))
+	}
+
+	return append(args, "-f", in.demuxer, "-i", in.path, "-map", "0:v:0", "-an")
+}
+
+// run runs ffmpeg with the provided arguments and returns its stdout
+func (p *Processor) run(ctx context.Context, args []string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, p.config.FfmpegPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	// ffmpeg is killed when the request is cancelled or timed out.
+	// Report it as such rather than as a broken video.
+	if terr := server.CheckTimeout(ctx); terr != nil {
+		return nil, terr
+	}
+
+	if err != nil {
+		return nil, newExtractFrameError(
+			fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String())),
+		)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
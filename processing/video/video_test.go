@@ -0,0 +1,137 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/options/keys"
+	"github.com/imgproxy/imgproxy/v4/security"
+)
+
+type VideoTestSuite struct {
+	suite.Suite
+
+	config         Config
+	securityConfig security.Config
+}
+
+func (s *VideoTestSuite) SetupTest() {
+	s.config = NewDefaultConfig()
+	s.config.Enabled = true
+
+	s.securityConfig = security.NewDefaultConfig()
+}
+
+func (s *VideoTestSuite) processor() *Processor {
+	checker, err := security.New(&s.securityConfig)
+	s.Require().NoError(err)
+
+	return New(&s.config, checker)
+}
+
+func (s *VideoTestSuite) input() input {
+	return input{path: "/tmp/video.mp4", demuxer: "mov", maxPixels: 1000}
+}
+
+func (s *VideoTestSuite) TestFrameArgs() {
+	args := s.processor().frameArgs(s.input(), 2.5, false)
+
+	s.Require().Equal([]string{
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-protocol_whitelist", "file",
+		"-max_pixels", "1000",
+		"-ss", "2.5",
+		"-f", "mov", "-i", "/tmp/video.mp4", "-map", "0:v:0", "-an",
+		"-frames:v", "1",
+		"-c:v", "png",
+		"-f", "image2pipe",
+		"pipe:1",
+	}, args)
+}
+
+func (s *VideoTestSuite) TestFrameArgsBestFrame() {
+	s.config.BestFrameCandidates = 50
+
+	args := s.processor().frameArgs(s.input(), 0, true)
+
+	s.Require().Equal([]string{
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-protocol_whitelist", "file",
+		"-max_pixels", "1000",
+		"-f", "mov", "-i", "/tmp/video.mp4", "-map", "0:v:0", "-an",
+		"-vf", "thumbnail=50",
+		"-frames:v", "1",
+		"-c:v", "png",
+		"-f", "image2pipe",
+		"pipe:1",
+	}, args)
+}
+
+func (s *VideoTestSuite) TestAnimationArgs() {
+	args := s.processor().animationArgs(s.input(), "/tmp/preview.webp", 1, 3, 10, 30, 500)
+
+	s.Require().Equal([]string{
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-protocol_whitelist", "file",
+		"-max_pixels", "1000",
+		"-ss", "1",
+		"-t", "3",
+		"-f", "mov", "-i", "/tmp/video.mp4", "-map", "0:v:0", "-an",
+		"-vf", "fps=10,scale=w='min(iw,trunc(iw*sqrt(500/(iw*ih))))':h='min(ih,trunc(ih*sqrt(500/(iw*ih))))'",
+		"-frames:v", "30",
+		"-c:v", "libwebp",
+		"-lossless", "1",
+		"-loop", "0",
+		"-f", "webp",
+		"-y", "/tmp/preview.webp",
+	}, args)
+}
+
+func (s *VideoTestSuite) TestAnimationDuration() {
+	s.config.AnimationMaxDuration = 5
+
+	o := options.New()
+	s.Require().Zero(s.processor().animationDuration(o))
+
+	o.Set(keys.VideoThumbnailAnimationDuration, 3.0)
+	s.Require().InDelta(3.0, s.processor().animationDuration(o), 0.0001)
+
+	o.Set(keys.VideoThumbnailAnimationDuration, 30.0)
+	s.Require().InDelta(5.0, s.processor().animationDuration(o), 0.0001)
+}
+
+func (s *VideoTestSuite) TestExtractDisabled() {
+	s.config.Enabled = false
+
+	data := imagedata.NewFromBytesWithFormat(imagetype.MP4, []byte("video"))
+	defer data.Close()
+
+	_, err := s.processor().Extract(s.T().Context(), options.New(), data)
+	s.Require().ErrorAs(err, &DisabledError{})
+}
+
+func (s *VideoTestSuite) TestExtractFileTooBig() {
+	s.securityConfig.MaxSrcFileSize = 4
+
+	data := imagedata.NewFromBytesWithFormat(imagetype.MP4, []byte("video"))
+	defer data.Close()
+
+	_, err := s.processor().Extract(s.T().Context(), options.New(), data)
+	s.Require().ErrorAs(err, &FileSizeError{})
+}
+
+func (s *VideoTestSuite) TestExtractUnsupportedFormat() {
+	data := imagedata.NewFromBytesWithFormat(imagetype.PNG, []byte("image"))
+	defer data.Close()
+
+	_, err := s.processor().Extract(s.T().Context(), options.New(), data)
+	s.Require().ErrorAs(err, &ExtractFrameError{})
+}
+
+func TestVideo(t *testing.T) {
+	suite.Run(t, new(VideoTestSuite))
+}
@@ -2,9 +2,13 @@ package processing
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/imgproxy/imgproxy/v4/imagedata"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
 	"github.com/imgproxy/imgproxy/v4/imath"
 	"github.com/imgproxy/imgproxy/v4/options"
 	"github.com/imgproxy/imgproxy/v4/options/keys"
@@ -24,44 +28,161 @@ func (p *Processor) watermarkPipeline() Pipeline {
 	}
 }
 
+// WatermarkLayersCount is the maximum number of watermark layers per request
+const WatermarkLayersCount = 10
+
 func shouldReplicateWatermark(gt GravityType) bool {
 	return gt == GravityReplicate
 }
 
-func (p *Processor) prepareWatermark(
-	ctx context.Context,
+// hasWatermark checks if there is a watermark to apply to the layer:
+// either a watermark text or a watermark image
+func (p *Processor) hasWatermark(po ProcessingOptions, layer int) bool {
+	if len(po.WatermarkText(layer)) > 0 {
+		return true
+	}
+
+	return p.watermarkProvider != nil && p.watermarkProvider.Has(watermarkProviderOptions(po, layer))
+}
+
+// hasWatermarks checks if there is at least one watermark layer to apply
+func (p *Processor) hasWatermarks(po ProcessingOptions) bool {
+	for _, layer := range po.WatermarkLayers() {
+		if p.hasWatermark(po, layer) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watermarkProviderOptions returns the options to get the layer watermark image
+// from the watermark provider.
+// The provider looks for the watermark URL under the base key, so for additional layers
+// we provide a copy of the options with the layer watermark URL moved to the base key.
+func watermarkProviderOptions(po ProcessingOptions, layer int) *options.Options {
+	if layer == 0 {
+		return po.Options
+	}
+
+	o := po.Options.Clone()
+	o.Delete(keys.WatermarkURL)
+
+	if wmURL := po.WatermarkURL(layer); len(wmURL) > 0 {
+		o.Set(keys.WatermarkURL, wmURL)
+	}
+
+	return o
+}
+
+// loadWatermark loads the watermark image.
+// If the watermark text is set, it renders the text instead.
+// It returns the watermark image format.
+func (p *Processor) loadWatermark(
 	wm *vips.Image,
 	wmData imagedata.ImageData,
 	po ProcessingOptions,
+	layer int,
 	imgWidth, imgHeight int,
 	offsetScale float64,
-	framesCount int, //nolint:unparam
-) error {
+) (imagetype.Type, error) {
+	if text := po.WatermarkText(layer); len(text) > 0 {
+		return imagetype.PNG, p.renderWatermarkText(wm, text, po, layer, imgWidth, imgHeight, offsetScale)
+	}
+
 	if err := wm.Load(wmData, 1.0, 0, 1); err != nil {
-		return err
+		return imagetype.Unknown, err
 	}
 
 	_, _, err := p.checkImageSize(wm, wmData.Format(), po)
+
+	return wmData.Format(), err
+}
+
+// renderWatermarkText renders the watermark text.
+// The font size is scaled the same way as offsets. If the watermark scale is set,
+// the font size is adjusted so the text fits the scaled image area instead.
+func (p *Processor) renderWatermarkText(
+	wm *vips.Image,
+	text string,
+	po ProcessingOptions,
+	layer int,
+	imgWidth, imgHeight int,
+	offsetScale float64,
+) error {
+	fontSize := max(imath.Round(float64(po.WatermarkTextSize(layer))*offsetScale), 1)
+	font := fmt.Sprintf("%s %d", po.WatermarkTextFont(layer), fontSize)
+
+	var width, height int
+
+	if scale := po.WatermarkScale(layer); scale > 0 {
+		width = max(imath.Scale(imgWidth, scale), 1)
+		height = max(imath.Scale(imgHeight, scale), 1)
+	} else {
+		// vips_text renders the whole text at once, so we need to check the text size
+		// before rendering it
+		estWidth, estHeight := estimateTextSize(text, fontSize)
+		if err := p.securityChecker.CheckDimensions(po.Options, estWidth, estHeight, 1); err != nil {
+			return err
+		}
+	}
+
+	if err := wm.Text(text, font, width, height, po.WatermarkTextColor(layer)); err != nil {
+		return err
+	}
+
+	_, _, err := p.checkImageSize(wm, imagetype.PNG, po)
+
+	return err
+}
+
+// estimateTextSize returns the estimated size of the rendered text.
+// It overestimates the size assuming that every glyph is as wide as the font size
+// and the line height is 1.5 of the font size.
+func estimateTextSize(text string, fontSize int) (int, int) {
+	lines := strings.Split(text, "\n")
+
+	var maxLen int
+	for _, line := range lines {
+		maxLen = max(maxLen, utf8.RuneCountInString(line))
+	}
+
+	return fontSize * maxLen, fontSize * len(lines) * 3 / 2
+}
+
+func (p *Processor) prepareWatermark(
+	ctx context.Context,
+	wm *vips.Image,
+	wmData imagedata.ImageData,
+	po ProcessingOptions,
+	layer int,
+	imgWidth, imgHeight int,
+	offsetScale float64,
+	framesCount int, //nolint:unparam
+) error {
+	wmFormat, err := p.loadWatermark(wm, wmData, po, layer, imgWidth, imgHeight, offsetScale)
 	if err != nil {
 		return err
 	}
 
 	wmPo := p.NewProcessingOptions(options.New())
 	wmPo.Set(keys.ResizingType, ResizeFit)
+	wmPo.Set(keys.ResizingAlgorithm, po.ResizingAlgorithm())
 	wmPo.Set(keys.Dpr, 1)
 	wmPo.Set(keys.Enlarge, true)
-	wmPo.Set(keys.Format, wmData.Format())
+	wmPo.Set(keys.Format, wmFormat)
 
-	if scale := po.WatermarkScale(); scale > 0 {
+	// Text watermark is already rendered to fit the scaled area
+	if scale := po.WatermarkScale(layer); scale > 0 && wmData != nil {
 		wmPo.Set(keys.Width, max(imath.ScaleToEven(imgWidth, scale), 1))
 		wmPo.Set(keys.Height, max(imath.ScaleToEven(imgHeight, scale), 1))
 	}
 
-	shouldReplicate := shouldReplicateWatermark(po.WatermarkPosition())
+	shouldReplicate := shouldReplicateWatermark(po.WatermarkPosition(layer))
 
 	if shouldReplicate {
-		offsetX := po.WatermarkXOffset()
-		offsetY := po.WatermarkYOffset()
+		offsetX := po.WatermarkXOffset(layer)
+		offsetY := po.WatermarkYOffset(layer)
 
 		var padX, padY int
 
@@ -103,25 +224,54 @@ func (p *Processor) prepareWatermark(
 	return wm.StripAll()
 }
 
-func (p *Processor) applyWatermark(
+// applyWatermarks applies the watermark layers in order
+func (p *Processor) applyWatermarks(
 	ctx context.Context,
 	img *vips.Image,
 	po ProcessingOptions,
 	offsetScale float64,
 	framesCount int,
 ) error {
-	if p.watermarkProvider == nil {
-		return nil
-	}
+	for _, layer := range po.WatermarkLayers() {
+		if !p.hasWatermark(po, layer) {
+			continue
+		}
 
-	wmData, _, err := p.watermarkProvider.Get(ctx, po.Options)
-	if err != nil {
-		return err
+		if err := p.applyWatermark(ctx, img, po, layer, offsetScale, framesCount); err != nil {
+			return err
+		}
 	}
-	if wmData == nil {
-		return nil
+
+	return nil
+}
+
+func (p *Processor) applyWatermark(
+	ctx context.Context,
+	img *vips.Image,
+	po ProcessingOptions,
+	layer int,
+	offsetScale float64,
+	framesCount int,
+) error {
+	var wmData imagedata.ImageData
+
+	// Watermark text takes precedence over the watermark image
+	if len(po.WatermarkText(layer)) == 0 {
+		if p.watermarkProvider == nil {
+			return nil
+		}
+
+		var err error
+
+		wmData, _, err = p.watermarkProvider.Get(ctx, watermarkProviderOptions(po, layer))
+		if err != nil {
+			return err
+		}
+		if wmData == nil {
+			return nil
+		}
+		defer wmData.Close()
 	}
-	defer wmData.Close()
 
 	wm := new(vips.Image)
 	defer wm.Clear()
@@ -131,14 +281,14 @@ func (p *Processor) applyWatermark(
 	frameHeight := height / framesCount
 
 	if err := p.prepareWatermark(
-		ctx, wm, wmData, po, width, frameHeight, offsetScale, framesCount,
+		ctx, wm, wmData, po, layer, width, frameHeight, offsetScale, framesCount,
 	); err != nil {
 		return err
 	}
 
-	opacity := po.WatermarkOpacity() * p.config.WatermarkOpacity
+	opacity := po.WatermarkOpacity(layer) * p.config.WatermarkOpacity
 
-	position := po.WatermarkPosition()
+	position := po.WatermarkPosition(layer)
 	shouldReplicate := shouldReplicateWatermark(position)
 
 	// If we replicated the watermark and need to apply it to an animated image,
@@ -158,8 +308,8 @@ func (p *Processor) applyWatermark(
 	if !shouldReplicate {
 		gr := GravityOptions{
 			Type: position,
-			X:    po.WatermarkXOffset(),
-			Y:    po.WatermarkYOffset(),
+			X:    po.WatermarkXOffset(layer),
+			Y:    po.WatermarkYOffset(layer),
 		}
 		left, top = calcPosition(width, frameHeight, wmWidth, wmHeight, &gr, offsetScale, true)
 	}
@@ -204,9 +354,5 @@ func (p *Processor) applyWatermark(
 }
 
 func (p *Processor) watermark(c *Context) error {
-	if p.watermarkProvider == nil || c.PO.WatermarkOpacity() == 0 {
-		return nil
-	}
-
-	return p.applyWatermark(c.Ctx, c.Img, c.PO, c.DprScale, 1)
+	return p.applyWatermarks(c.Ctx, c.Img, c.PO, c.DprScale, 1)
 }
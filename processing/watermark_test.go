@@ -2,7 +2,10 @@ package processing_test
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/imgproxy/imgproxy/v4/imagetype"
@@ -298,6 +301,19 @@ func (s *WatermarkTestSuite) TestWatermarkAnimation() {
 	}
 }
 
+func (s *WatermarkTestSuite) TestWatermarkTextTooLarge() {
+	text := base64.RawURLEncoding.EncodeToString([]byte(strings.Repeat("Lorem ipsum ", 20)))
+
+	// The text watermark would be about 240 characters * 256px wide, which exceeds 1MP
+	resp := s.GET("/unsafe/max_src_resolution:1/wm:1:ce/wmt:" + text + "::256/plain/local:///geometry.png")
+	defer resp.Body.Close()
+
+	s.Require().Equal(
+		http.StatusUnprocessableEntity, resp.StatusCode,
+		"Expected status code 422 for too large watermark text",
+	)
+}
+
 func TestWatermark(t *testing.T) {
 	suite.Run(t, new(WatermarkTestSuite))
 }
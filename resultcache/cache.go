@@ -0,0 +1,469 @@
+package resultcache
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imgproxy/imgproxy/v4/clientfeatures"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+)
+
+const (
+	// metaSizeLen is the size of the entry metadata length prefix in bytes
+	metaSizeLen = 4
+
+	// markerFileName is the name of the file that marks the directory as a result cache directory.
+	// We refuse to use non-empty directories without it so we never touch unrelated files.
+	markerFileName = ".imgproxy-result-cache"
+
+	// tmpFilePrefix is the prefix of the temporary files the entries are written to
+	tmpFilePrefix = ".tmp-"
+)
+
+var errInvalidEntry = errors.New("invalid cache entry")
+
+// Entry represents a cached processing result
+type Entry struct {
+	Format        imagetype.Type // Result image format
+	Headers       http.Header    // Source image response headers
+	ResultHeaders http.Header    // Response headers that describe the result
+	ExpiresAt     time.Time      // Time when the entry expires
+	Data          []byte         // Result image data
+}
+
+// entryMeta is the entry metadata stored in the cache file before the image data
+type entryMeta struct {
+	Format        string      `json:"format"`
+	Headers       http.Header `json:"headers,omitempty"`
+	ResultHeaders http.Header `json:"result_headers,omitempty"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+}
+
+// item is an LRU list item
+type item struct {
+	key  string
+	size int64
+}
+
+// Cache is a local filesystem cache of processing results.
+// When the total size of the cached results exceeds the limit,
+// the least recently used results are evicted.
+type Cache struct {
+	config *Config
+
+	mu    sync.Mutex
+	lru   *list.List               // LRU list, the front is the most recently used item
+	items map[string]*list.Element // Items by key
+	size  int64                    // Total size of the cached results
+}
+
+// New creates a new Cache instance.
+// It returns nil if the cache is disabled.
+func New(config *Config) (*Cache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !config.Enabled() {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(config.Path, 0o750); err != nil {
+		return nil, fmt.Errorf("can't create result cache directory: %w", err)
+	}
+
+	if err := ensureMarkerFile(config.Path); err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		config: config,
+		lru:    list.New(),
+		items:  make(map[string]*list.Element),
+	}
+
+	if err := c.loadIndex(); err != nil {
+		return nil, fmt.Errorf("can't read result cache directory: %w", err)
+	}
+
+	return c, nil
+}
+
+// Key builds a cache key from the request path and the client features
+// that may affect the result
+func Key(path string, features *clientfeatures.Features) string {
+	h := sha256.New()
+
+	h.Write([]byte(path))
+
+	if features != nil {
+		fmt.Fprintf(
+			h, "\x00%t:%t:%t:%t:%t:%t:%d:%g",
+			features.PreferWebP, features.EnforceWebP,
+			features.PreferAvif, features.EnforceAvif,
+			features.PreferJxl, features.EnforceJxl,
+			features.ClientHintsWidth, features.ClientHintsDPR,
+		)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached result by key.
+// It returns false if there is no such result or it has expired.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	c.mu.Lock()
+	el, ok := c.items[key]
+	if ok {
+		c.lru.MoveToFront(el)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.filePath(key))
+	if err != nil {
+		// The file could've been evicted or removed externally
+		c.remove(key)
+		return nil, false
+	}
+
+	entry, err := decodeEntry(data)
+	if err != nil {
+		slog.Warn("Can't read result cache entry", "key", key, "error", err)
+		c.remove(key)
+		return nil, false
+	}
+
+	if !entry.ExpiresAt.After(time.Now()) {
+		c.remove(key)
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// Set stores the result in the cache.
+// The entry lifetime is limited by the configured TTL.
+func (c *Cache) Set(key string, entry *Entry) error {
+	maxExpiresAt := time.Now().Add(time.Duration(c.config.TTL) * time.Second)
+	if entry.ExpiresAt.IsZero() || entry.ExpiresAt.After(maxExpiresAt) {
+		entry.ExpiresAt = maxExpiresAt
+	}
+
+	if !entry.ExpiresAt.After(time.Now()) {
+		return nil
+	}
+
+	data, err := encodeEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	size := int64(len(data))
+
+	// No sense to cache results that don't fit the cache at all
+	if size > int64(c.config.MaxSize) {
+		return nil
+	}
+
+	path := c.filePath(key)
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it so readers never see a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), tmpFilePrefix+"*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.add(key, size)
+	c.evict()
+
+	return nil
+}
+
+// Size returns the total size of the cached results
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// add adds an item to the LRU index or updates the existing one.
+// It should be called with the lock held.
+func (c *Cache) add(key string, size int64) {
+	if el, ok := c.items[key]; ok {
+		it := el.Value.(*item) //nolint:forcetypeassert
+		c.size += size - it.size
+		it.size = size
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.lru.PushFront(&item{key: key, size: size})
+	c.size += size
+}
+
+// evict removes the least recently used items until the cache fits the size limit.
+// It should be called with the lock held.
+func (c *Cache) evict() {
+	for c.size > int64(c.config.MaxSize) {
+		el := c.lru.Back()
+		if el == nil {
+			return
+		}
+
+		it := el.Value.(*item) //nolint:forcetypeassert
+
+		c.lru.Remove(el)
+		delete(c.items, it.key)
+		c.size -= it.size
+
+		if err := os.Remove(c.filePath(it.key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("Can't remove result cache entry", "key", it.key, "error", err)
+		}
+	}
+}
+
+// remove removes an item from the cache
+func (c *Cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return
+	}
+
+	it := el.Value.(*item) //nolint:forcetypeassert
+
+	c.lru.Remove(el)
+	delete(c.items, key)
+	c.size -= it.size
+
+	os.Remove(c.filePath(key)) //nolint:errcheck
+}
+
+// loadIndex builds the LRU index from the files in the cache directory.
+// Files are ordered by their modification time.
+// Only the files that look like cache entries are indexed; leftover temporary files
+// are removed, and anything else is left untouched.
+func (c *Cache) loadIndex() error {
+	type fileInfo struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []fileInfo
+
+	root := filepath.Clean(c.config.Path)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == root {
+			return nil
+		}
+
+		dir := filepath.Dir(path)
+
+		// Cache entries are stored only in the key prefix subdirectories
+		if d.IsDir() {
+			if dir != root || !isHex(d.Name(), 2) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if dir == root || !d.Type().IsRegular() {
+			return nil
+		}
+
+		name := d.Name()
+
+		// Remove leftover temporary files
+		if strings.HasPrefix(name, tmpFilePrefix) {
+			return os.Remove(path)
+		}
+
+		if !isHex(name, sha256.Size*2) || filepath.Base(dir) != name[:2] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, fileInfo{key: name, size: info.Size(), modTime: info.ModTime()})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(files, func(a, b fileInfo) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for _, f := range files {
+		c.add(f.key, f.size)
+	}
+
+	c.evict()
+
+	return nil
+}
+
+// filePath returns the path of the cache file for the key
+func (c *Cache) filePath(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.config.Path, key)
+	}
+
+	return filepath.Join(c.config.Path, key[:2], key)
+}
+
+// ensureMarkerFile checks that the directory is a result cache directory.
+// It creates the marker file if the directory is empty and returns an error
+// if the directory is not empty and doesn't have the marker file.
+func ensureMarkerFile(dir string) error {
+	marker := filepath.Join(dir, markerFileName)
+
+	_, err := os.Stat(marker)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("can't read result cache directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("can't read result cache directory: %w", err)
+	}
+
+	if len(entries) > 0 {
+		return fmt.Errorf(
+			"result cache directory %s is not empty and is not a result cache directory; "+
+				"use an empty directory or create the %s file in it",
+			dir, markerFileName,
+		)
+	}
+
+	if err = os.WriteFile(marker, nil, 0o640); err != nil {
+		return fmt.Errorf("can't create result cache directory marker: %w", err)
+	}
+
+	return nil
+}
+
+// isHex checks if the string is a lowercase hex string of the given length
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'a' || s[i] > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeEntry encodes the entry as a length-prefixed JSON metadata followed by the data
+func encodeEntry(entry *Entry) ([]byte, error) {
+	meta, err := json.Marshal(entryMeta{
+		Format:        entry.Format.String(),
+		Headers:       entry.Headers,
+		ResultHeaders: entry.ResultHeaders,
+		ExpiresAt:     entry.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, metaSizeLen+len(meta)+len(entry.Data)))
+
+	binary.Write(buf, binary.BigEndian, uint32(len(meta))) //nolint:errcheck,gosec
+	buf.Write(meta)
+	buf.Write(entry.Data)
+
+	return buf.Bytes(), nil
+}
+
+// decodeEntry decodes the entry encoded with [encodeEntry]
+func decodeEntry(data []byte) (*Entry, error) {
+	if len(data) < metaSizeLen {
+		return nil, errInvalidEntry
+	}
+
+	metaSize := int(binary.BigEndian.Uint32(data[:metaSizeLen]))
+	if len(data) < metaSizeLen+metaSize {
+		return nil, errInvalidEntry
+	}
+
+	var meta entryMeta
+	if err := json.Unmarshal(data[metaSizeLen:metaSizeLen+metaSize], &meta); err != nil {
+		return nil, err
+	}
+
+	format, ok := imagetype.GetTypeByName(meta.Format)
+	if !ok {
+		return nil, errInvalidEntry
+	}
+
+	return &Entry{
+		Format:        format,
+		Headers:       meta.Headers,
+		ResultHeaders: meta.ResultHeaders,
+		ExpiresAt:     meta.ExpiresAt,
+		Data:          data[metaSizeLen+metaSize:],
+	}, nil
+}
@@ -0,0 +1,217 @@
+package resultcache
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/clientfeatures"
+	"github.com/imgproxy/imgproxy/v4/imagetype"
+)
+
+type CacheTestSuite struct {
+	suite.Suite
+
+	config Config
+}
+
+func (s *CacheTestSuite) SetupTest() {
+	s.config = NewDefaultConfig()
+	s.config.Path = s.T().TempDir()
+}
+
+func (s *CacheTestSuite) newCache() *Cache {
+	c, err := New(&s.config)
+	s.Require().NoError(err)
+	s.Require().NotNil(c)
+
+	return c
+}
+
+func (s *CacheTestSuite) TestDisabled() {
+	s.config.Path = ""
+
+	c, err := New(&s.config)
+	s.Require().NoError(err)
+	s.Require().Nil(c)
+}
+
+func (s *CacheTestSuite) TestSetGet() {
+	c := s.newCache()
+
+	headers := http.Header{}
+	headers.Set("Etag", "\"test\"")
+
+	resultHeaders := http.Header{}
+	resultHeaders.Set("X-Palette", "#ff0000 1")
+
+	err := c.Set("key", &Entry{
+		Format:        imagetype.PNG,
+		Headers:       headers,
+		ResultHeaders: resultHeaders,
+		Data:          []byte("image data"),
+	})
+	s.Require().NoError(err)
+
+	entry, ok := c.Get("key")
+	s.Require().True(ok)
+	s.Require().Equal(imagetype.PNG, entry.Format)
+	s.Require().Equal("\"test\"", entry.Headers.Get("Etag"))
+	s.Require().Equal("#ff0000 1", entry.ResultHeaders.Get("X-Palette"))
+	s.Require().Equal([]byte("image data"), entry.Data)
+	s.Require().WithinDuration(
+		time.Now().Add(time.Duration(s.config.TTL)*time.Second),
+		entry.ExpiresAt,
+		time.Minute,
+	)
+
+	_, ok = c.Get("unknown")
+	s.Require().False(ok)
+}
+
+func (s *CacheTestSuite) TestExpires() {
+	c := s.newCache()
+
+	err := c.Set("key", &Entry{
+		Format:    imagetype.PNG,
+		ExpiresAt: time.Now().Add(-time.Second),
+		Data:      []byte("image data"),
+	})
+	s.Require().NoError(err)
+
+	_, ok := c.Get("key")
+	s.Require().False(ok)
+}
+
+func (s *CacheTestSuite) TestTTL() {
+	s.config.TTL = 0
+
+	c := s.newCache()
+
+	err := c.Set("key", &Entry{Format: imagetype.PNG, Data: []byte("image data")})
+	s.Require().NoError(err)
+
+	_, ok := c.Get("key")
+	s.Require().False(ok)
+}
+
+func (s *CacheTestSuite) TestEviction() {
+	c := s.newCache()
+
+	err := c.Set("key1", &Entry{Format: imagetype.PNG, Data: make([]byte, 100)})
+	s.Require().NoError(err)
+
+	// Limit the cache size to fit two entries
+	s.config.MaxSize = int(c.Size()*2 + c.Size()/2)
+
+	err = c.Set("key2", &Entry{Format: imagetype.PNG, Data: make([]byte, 100)})
+	s.Require().NoError(err)
+
+	// Touch key1 so key2 becomes the least recently used
+	_, ok := c.Get("key1")
+	s.Require().True(ok)
+
+	err = c.Set("key3", &Entry{Format: imagetype.PNG, Data: make([]byte, 100)})
+	s.Require().NoError(err)
+
+	_, ok = c.Get("key1")
+	s.Require().True(ok)
+
+	_, ok = c.Get("key2")
+	s.Require().False(ok)
+
+	_, ok = c.Get("key3")
+	s.Require().True(ok)
+
+	_, err = os.Stat(c.filePath("key2"))
+	s.Require().ErrorIs(err, os.ErrNotExist)
+}
+
+func (s *CacheTestSuite) TestLoadIndex() {
+	c := s.newCache()
+
+	key := Key("/path", nil)
+
+	err := c.Set(key, &Entry{Format: imagetype.PNG, Data: []byte("image data")})
+	s.Require().NoError(err)
+
+	size := c.Size()
+
+	// A new cache instance should pick up the existing entries
+	c = s.newCache()
+	s.Require().Equal(size, c.Size())
+
+	entry, ok := c.Get(key)
+	s.Require().True(ok)
+	s.Require().Equal([]byte("image data"), entry.Data)
+}
+
+func (s *CacheTestSuite) TestLoadIndexForeignFiles() {
+	c := s.newCache()
+
+	key := Key("/path", nil)
+
+	err := c.Set(key, &Entry{Format: imagetype.PNG, Data: []byte("image data")})
+	s.Require().NoError(err)
+
+	size := c.Size()
+
+	keyDir := filepath.Dir(c.filePath(key))
+	tmpFile := filepath.Join(keyDir, ".tmp-123")
+
+	foreignFiles := []string{
+		filepath.Join(s.config.Path, "foreign"),
+		filepath.Join(s.config.Path, "foreign-dir", Key("/path2", nil)),
+		filepath.Join(keyDir, "foreign"),
+		// A valid key in a wrong subdirectory
+		filepath.Join(keyDir, Key("/path3", nil)),
+	}
+
+	for _, f := range append(foreignFiles, tmpFile) {
+		s.Require().NoError(os.MkdirAll(filepath.Dir(f), 0o750))
+		s.Require().NoError(os.WriteFile(f, []byte("data"), 0o640))
+	}
+
+	c = s.newCache()
+	s.Require().Equal(size, c.Size())
+
+	// Leftover temporary files are removed
+	_, err = os.Stat(tmpFile)
+	s.Require().ErrorIs(err, os.ErrNotExist)
+
+	// Files that don't belong to the cache are left untouched
+	for _, f := range foreignFiles {
+		_, err = os.Stat(f)
+		s.Require().NoError(err, f)
+	}
+}
+
+func (s *CacheTestSuite) TestNonEmptyDirectory() {
+	err := os.WriteFile(filepath.Join(s.config.Path, "foreign"), []byte("data"), 0o640)
+	s.Require().NoError(err)
+
+	_, err = New(&s.config)
+	s.Require().Error(err)
+
+	// The directory is accepted once it's marked as the cache directory
+	err = os.WriteFile(filepath.Join(s.config.Path, markerFileName), nil, 0o640)
+	s.Require().NoError(err)
+
+	s.newCache()
+}
+
+func (s *CacheTestSuite) TestKey() {
+	features := clientfeatures.Features{PreferWebP: true}
+
+	s.Require().Equal(Key("/path", &features), Key("/path", &features))
+	s.Require().NotEqual(Key("/path", &features), Key("/path2", &features))
+	s.Require().NotEqual(Key("/path", &features), Key("/path", &clientfeatures.Features{}))
+}
+
+func TestCache(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
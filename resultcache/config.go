@@ -0,0 +1,62 @@
+package resultcache
+
+import (
+	"errors"
+
+	"github.com/imgproxy/imgproxy/v4/ensure"
+	"github.com/imgproxy/imgproxy/v4/env"
+	"github.com/imgproxy/imgproxy/v4/server/responsewriter"
+)
+
+var (
+	IMGPROXY_RESULT_CACHE_PATH     = env.String("IMGPROXY_RESULT_CACHE_PATH")
+	IMGPROXY_RESULT_CACHE_MAX_SIZE = env.Int("IMGPROXY_RESULT_CACHE_MAX_SIZE")
+)
+
+// Config represents [Cache] config
+type Config struct {
+	Path    string // Path to the cache directory. Empty means the cache is disabled
+	MaxSize int    // Maximum total size of the cached results in bytes
+	TTL     int    // Maximum lifetime of the cached results in seconds
+}
+
+// NewDefaultConfig creates a new configuration with defaults
+func NewDefaultConfig() Config {
+	return Config{
+		Path:    "",
+		MaxSize: 1024 * 1024 * 1024,
+		TTL:     31_536_000,
+	}
+}
+
+// LoadConfigFromEnv loads config from environment variables
+func LoadConfigFromEnv(c *Config) (*Config, error) {
+	c = ensure.Ensure(c, NewDefaultConfig)
+
+	err := errors.Join(
+		IMGPROXY_RESULT_CACHE_PATH.Parse(&c.Path),
+		IMGPROXY_RESULT_CACHE_MAX_SIZE.Parse(&c.MaxSize),
+		// Cached results should not outlive the response Cache-Control TTL
+		responsewriter.IMGPROXY_TTL.Parse(&c.TTL),
+	)
+
+	return c, err
+}
+
+// Validate checks configuration values
+func (c *Config) Validate() error {
+	if c.MaxSize <= 0 {
+		return IMGPROXY_RESULT_CACHE_MAX_SIZE.ErrorZeroOrNegative()
+	}
+
+	if c.TTL < 0 {
+		return responsewriter.IMGPROXY_TTL.ErrorNegative()
+	}
+
+	return nil
+}
+
+// Enabled returns true if the result cache is enabled
+func (c *Config) Enabled() bool {
+	return len(c.Path) > 0
+}
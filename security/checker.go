@@ -49,6 +49,21 @@ func (s *Checker) MaxResultDimension(o *options.Options) int {
 	return o.GetInt(keys.MaxResultDimension, s.config.MaxResultDimension)
 }
 
+// MaxPdfPages returns the maximum allowed number of pages in a source PDF document.
+// 0 means no limit.
+func (s *Checker) MaxPdfPages(o *options.Options) int {
+	return o.GetInt(keys.MaxPdfPages, s.config.MaxPdfPages)
+}
+
+// CheckPdfPages checks if the number of pages in a PDF document is within the allowed limit
+func (s *Checker) CheckPdfPages(o *options.Options, pages int) error {
+	if maxPages := s.MaxPdfPages(o); maxPages > 0 && pages > maxPages {
+		return newImageResolutionError("Source PDF document has too many pages")
+	}
+
+	return nil
+}
+
 // CheckDimensions checks if the given dimensions are within the allowed limits
 func (s *Checker) CheckDimensions(o *options.Options, width, height, frames int) error {
 	frames = max(frames, 1)
@@ -0,0 +1,72 @@
+package security_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/options/keys"
+	"github.com/imgproxy/imgproxy/v4/security"
+	"github.com/imgproxy/imgproxy/v4/testutil"
+)
+
+type CheckerTestSuite struct {
+	testutil.LazySuite
+
+	config  testutil.LazyObj[*security.Config]
+	checker testutil.LazyObj[*security.Checker]
+}
+
+func (s *CheckerTestSuite) SetupSuite() {
+	s.config, _ = testutil.NewLazySuiteObj(
+		s,
+		func() (*security.Config, error) {
+			c := security.NewDefaultConfig()
+			return &c, nil
+		},
+	)
+
+	s.checker, _ = testutil.NewLazySuiteObj(
+		s,
+		func() (*security.Checker, error) {
+			return security.New(s.config())
+		},
+	)
+}
+
+func (s *CheckerTestSuite) TestCheckPdfPagesUnlimited() {
+	s.config().MaxPdfPages = 0
+
+	err := s.checker().CheckPdfPages(options.New(), 1000)
+	s.Require().NoError(err)
+}
+
+func (s *CheckerTestSuite) TestCheckPdfPages() {
+	s.config().MaxPdfPages = 10
+
+	err := s.checker().CheckPdfPages(options.New(), 10)
+	s.Require().NoError(err)
+
+	err = s.checker().CheckPdfPages(options.New(), 11)
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &security.ImageResolutionError{})
+}
+
+func (s *CheckerTestSuite) TestCheckPdfPagesOption() {
+	s.config().MaxPdfPages = 10
+
+	o := options.New()
+	o.Set(keys.MaxPdfPages, 5)
+
+	err := s.checker().CheckPdfPages(o, 5)
+	s.Require().NoError(err)
+
+	err = s.checker().CheckPdfPages(o, 6)
+	s.Require().Error(err)
+	s.Require().ErrorAs(err, &security.ImageResolutionError{})
+}
+
+func TestChecker(t *testing.T) {
+	suite.Run(t, new(CheckerTestSuite))
+}
@@ -0,0 +1,115 @@
+// APNG chunk helpers shared by the APNG loader and saver
+//
+// See: https://wiki.mozilla.org/APNG_Specification
+
+#include "vips.h"
+
+#include <stdint.h>
+#include <string.h>
+
+const uint8_t apng_signature[APNG_SIGNATURE_LEN] = { 137, 'P', 'N', 'G', '\r', '\n', 26, '\n' };
+
+static uint32_t apng_crc_table[256];
+static GOnce apng_crc_once = G_ONCE_INIT;
+
+static void *
+apng_crc_init(void *data)
+{
+  for (uint32_t n = 0; n < 256; n++) {
+    uint32_t c = n;
+
+    for (int k = 0; k < 8; k++)
+      c = (c & 1) ? 0xedb88320L ^ (c >> 1) : c >> 1;
+
+    apng_crc_table[n] = c;
+  }
+
+  return NULL;
+}
+
+/**
+ * Updates the CRC with the given data.
+ * We don't rely on zlib here since libvips doesn't necessarily expose it.
+ */
+static uint32_t
+apng_crc_update(uint32_t crc, const uint8_t *data, size_t len)
+{
+  g_once(&apng_crc_once, apng_crc_init, NULL);
+
+  for (size_t i = 0; i < len; i++)
+    crc = apng_crc_table[(crc ^ data[i]) & 0xff] ^ (crc >> 8);
+
+  return crc;
+}
+
+/**
+ * Reads a big-endian 32-bit number.
+ * PNG data isn't aligned, so we don't access it through an uint32_t pointer.
+ */
+uint32_t
+apng_read_u32(const uint8_t *p)
+{
+  uint32_t v;
+  memcpy(&v, p, sizeof(v));
+  return GUINT32_FROM_BE(v);
+}
+
+/**
+ * Writes a big-endian 32-bit number
+ */
+void
+apng_write_u32(uint8_t *p, uint32_t v)
+{
+  v = GUINT32_TO_BE(v);
+  memcpy(p, &v, sizeof(v));
+}
+
+/**
+ * Reads the chunk at the given offset of the buffer and moves the offset to the next chunk.
+ * Returns 1 if the chunk is read, 0 if the buffer is over, and -1 if the chunk is truncated.
+ */
+int
+apng_read_chunk(const uint8_t *buf, size_t buf_len, size_t *offset, APNG_Chunk *chunk)
+{
+  if (*offset >= buf_len)
+    return 0;
+
+  if (buf_len - *offset < APNG_CHUNK_HEADER_LEN + APNG_CHUNK_CRC_LEN)
+    return -1;
+
+  const uint8_t *p = buf + *offset;
+
+  uint32_t len = apng_read_u32(p);
+
+  if (len > buf_len - *offset - APNG_CHUNK_HEADER_LEN - APNG_CHUNK_CRC_LEN)
+    return -1;
+
+  memcpy(chunk->type, p + 4, 4);
+  chunk->type[4] = '\0';
+  chunk->data = p + APNG_CHUNK_HEADER_LEN;
+  chunk->len = len;
+
+  *offset += APNG_CHUNK_HEADER_LEN + len + APNG_CHUNK_CRC_LEN;
+
+  return 1;
+}
+
+/**
+ * Appends the chunk with the given type and data to the buffer.
+ */
+void
+apng_append_chunk(GByteArray *buf, const char *type, const uint8_t *data, uint32_t len)
+{
+  uint32_t len_be = GUINT32_TO_BE(len);
+
+  uint32_t crc = apng_crc_update(0xffffffffL, (const uint8_t *) type, 4);
+  if (len > 0)
+    crc = apng_crc_update(crc, data, len);
+  crc = GUINT32_TO_BE(crc ^ 0xffffffffL);
+
+  g_byte_array_append(buf, (const guint8 *) &len_be, 4);
+  g_byte_array_append(buf, (const guint8 *) type, 4);
+  if (len > 0)
+    g_byte_array_append(buf, data, len);
+  g_byte_array_append(buf, (const guint8 *) &crc, 4);
+}
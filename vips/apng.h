@@ -0,0 +1,62 @@
+/*
+ * APNG save/load. APNG is a PNG with additional chunks describing animation frames.
+ */
+#ifndef __APNG_H__
+#define __APNG_H__
+
+#include <stdint.h>
+
+#define APNG_SIGNATURE_LEN 8
+#define APNG_CHUNK_HEADER_LEN 8 // length + type
+#define APNG_CHUNK_CRC_LEN 4
+#define APNG_IHDR_LEN 13
+#define APNG_ACTL_LEN 8
+#define APNG_FCTL_LEN 26
+
+#define APNG_DISPOSE_OP_NONE 0
+#define APNG_DISPOSE_OP_BACKGROUND 1
+#define APNG_DISPOSE_OP_PREVIOUS 2
+
+#define APNG_BLEND_OP_SOURCE 0
+#define APNG_BLEND_OP_OVER 1
+
+// APNG animation control chunk
+typedef struct __attribute__((packed)) _APNG_acTL {
+  uint32_t num_frames; // Number of frames
+  uint32_t num_plays;  // Number of times to loop the animation, 0 means infinite
+} APNG_acTL;
+
+// APNG frame control chunk
+typedef struct __attribute__((packed)) _APNG_fcTL {
+  uint32_t sequence_number; // Sequence number of the chunk
+  uint32_t width;           // Width of the frame
+  uint32_t height;          // Height of the frame
+  uint32_t x_offset;        // X position of the frame on the canvas
+  uint32_t y_offset;        // Y position of the frame on the canvas
+  uint16_t delay_num;       // Frame delay fraction numerator
+  uint16_t delay_den;       // Frame delay fraction denominator, 0 means 100
+  uint8_t dispose_op;       // Type of the frame area disposal after rendering
+  uint8_t blend_op;         // Type of the frame area rendering
+} APNG_fcTL;
+
+// PNG chunk reference
+typedef struct _APNG_Chunk {
+  char type[5];        // Chunk type, null-terminated
+  const uint8_t *data; // Chunk data
+  uint32_t len;        // Chunk data length
+} APNG_Chunk;
+
+// defined in apng.c
+extern const uint8_t apng_signature[APNG_SIGNATURE_LEN];
+uint32_t apng_read_u32(const uint8_t *p);
+void apng_write_u32(uint8_t *p, uint32_t v);
+int apng_read_chunk(const uint8_t *buf, size_t buf_len, size_t *offset, APNG_Chunk *chunk);
+void apng_append_chunk(GByteArray *buf, const char *type, const uint8_t *data, uint32_t len);
+
+// defined in apngsave.c
+int vips_apngsave_target_go(VipsImage *in, VipsTarget *target, ImgproxySaveOptions opts);
+
+// defined in apngload.c
+int vips_apngload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoadOptions lo);
+
+#endif
@@ -0,0 +1,454 @@
+// APNG loader
+//
+// libvips loads only the default image of APNG files, so we split APNG into
+// standalone PNG frames, load them with the regular PNG loader,
+// and render them onto the canvas as the APNG specification says.
+//
+// See: https://wiki.mozilla.org/APNG_Specification
+
+#include "vips.h"
+
+#include <stdlib.h>
+#include <stdint.h>
+#include <string.h>
+
+// APNG frame data
+typedef struct _ApngFrame {
+  APNG_fcTL fctl;   // Frame control data in host byte order
+  GByteArray *data; // IDAT chunks of the frame
+} ApngFrame;
+
+// Parsed APNG file
+typedef struct _Apng {
+  const uint8_t *ihdr; // IHDR chunk data
+  uint32_t width;      // Canvas width
+  uint32_t height;     // Canvas height
+  uint32_t num_plays;  // Number of times to loop the animation
+
+  GByteArray *shared; // Chunks shared by all the frames (PLTE, tRNS, iCCP, etc)
+  GArray *frames;     // Animation frames
+} Apng;
+
+static void
+apng_frame_clear(gpointer data)
+{
+  ApngFrame *frame = (ApngFrame *) data;
+
+  if (frame->data)
+    g_byte_array_unref(frame->data);
+}
+
+static void
+apng_clear(Apng *apng)
+{
+  if (apng->shared)
+    g_byte_array_unref(apng->shared);
+
+  if (apng->frames)
+    g_array_unref(apng->frames);
+}
+
+/**
+ * Parses the frame control chunk
+ */
+static int
+apng_parse_fctl(APNG_Chunk *chunk, APNG_fcTL *fctl)
+{
+  if (chunk->len < APNG_FCTL_LEN) {
+    vips_error("vips_apngload", "fcTL chunk is too short");
+    return -1;
+  }
+
+  memcpy(fctl, chunk->data, APNG_FCTL_LEN);
+
+  fctl->sequence_number = GUINT32_FROM_BE(fctl->sequence_number);
+  fctl->width = GUINT32_FROM_BE(fctl->width);
+  fctl->height = GUINT32_FROM_BE(fctl->height);
+  fctl->x_offset = GUINT32_FROM_BE(fctl->x_offset);
+  fctl->y_offset = GUINT32_FROM_BE(fctl->y_offset);
+  fctl->delay_num = GUINT16_FROM_BE(fctl->delay_num);
+  fctl->delay_den = GUINT16_FROM_BE(fctl->delay_den);
+
+  return 0;
+}
+
+/**
+ * Splits APNG data into the shared chunks and frames
+ */
+static int
+apng_parse(const uint8_t *buf, size_t len, Apng *apng)
+{
+  if (len < APNG_SIGNATURE_LEN || memcmp(buf, apng_signature, APNG_SIGNATURE_LEN) != 0) {
+    vips_error("vips_apngload", "not a PNG file");
+    return -1;
+  }
+
+  apng->shared = g_byte_array_new();
+  apng->frames = g_array_new(FALSE, TRUE, sizeof(ApngFrame));
+  g_array_set_clear_func(apng->frames, apng_frame_clear);
+
+  gboolean has_actl = FALSE;
+  gboolean idat_seen = FALSE;
+  uint32_t num_frames = 0;
+  ApngFrame *frame = NULL;
+
+  size_t offset = APNG_SIGNATURE_LEN;
+  APNG_Chunk chunk;
+  int res;
+
+  while ((res = apng_read_chunk(buf, len, &offset, &chunk)) > 0) {
+    if (strcmp(chunk.type, "IHDR") == 0) {
+      if (chunk.len < APNG_IHDR_LEN) {
+        vips_error("vips_apngload", "IHDR chunk is too short");
+        return -1;
+      }
+
+      apng->ihdr = chunk.data;
+      apng->width = apng_read_u32(chunk.data);
+      apng->height = apng_read_u32(chunk.data + 4);
+    }
+    else if (strcmp(chunk.type, "acTL") == 0) {
+      if (chunk.len < APNG_ACTL_LEN) {
+        vips_error("vips_apngload", "acTL chunk is too short");
+        return -1;
+      }
+
+      has_actl = TRUE;
+      num_frames = apng_read_u32(chunk.data);
+      apng->num_plays = apng_read_u32(chunk.data + 4);
+    }
+    else if (strcmp(chunk.type, "fcTL") == 0) {
+      ApngFrame new_frame = { 0 };
+
+      // Don't let the file declare more frames than acTL says.
+      // Data of the extra frames is ignored
+      if (has_actl && apng->frames->len >= num_frames) {
+        frame = NULL;
+        continue;
+      }
+
+      if (apng_parse_fctl(&chunk, &new_frame.fctl))
+        return -1;
+
+      new_frame.data = g_byte_array_new();
+      g_array_append_val(apng->frames, new_frame);
+
+      frame = &g_array_index(apng->frames, ApngFrame, apng->frames->len - 1);
+    }
+    else if (strcmp(chunk.type, "IDAT") == 0) {
+      idat_seen = TRUE;
+
+      // If there was no fcTL before IDAT, the default image is not a part of the animation
+      if (frame)
+        apng_append_chunk(frame->data, "IDAT", chunk.data, chunk.len);
+    }
+    else if (strcmp(chunk.type, "fdAT") == 0) {
+      // fdAT is the same as IDAT but prefixed with the sequence number
+      if (frame && chunk.len > 4)
+        apng_append_chunk(frame->data, "IDAT", chunk.data + 4, chunk.len - 4);
+    }
+    else if (strcmp(chunk.type, "IEND") == 0) {
+      break;
+    }
+    else if (!idat_seen) {
+      // Chunks before the first IDAT describe all the frames
+      apng_append_chunk(apng->shared, chunk.type, chunk.data, chunk.len);
+    }
+  }
+
+  if (res < 0) {
+    vips_error("vips_apngload", "PNG chunk is truncated");
+    return -1;
+  }
+
+  if (!apng->ihdr) {
+    vips_error("vips_apngload", "IHDR chunk not found");
+    return -1;
+  }
+
+  if (!has_actl || apng->frames->len == 0) {
+    vips_error("vips_apngload", "not an animated PNG file");
+    return -1;
+  }
+
+  return 0;
+}
+
+/**
+ * Loads the frame image as a standalone PNG.
+ * The frame PNG data is owned by the loaded image, so it can be loaded lazily.
+ */
+static int
+apng_load_frame(Apng *apng, ApngFrame *frame, VipsImage **out, gboolean unlimited)
+{
+  uint8_t ihdr[APNG_IHDR_LEN];
+
+  memcpy(ihdr, apng->ihdr, APNG_IHDR_LEN);
+  apng_write_u32(ihdr, frame->fctl.width);
+  apng_write_u32(ihdr + 4, frame->fctl.height);
+
+  GByteArray *png = g_byte_array_sized_new(
+      APNG_SIGNATURE_LEN + apng->shared->len + frame->data->len + 64);
+
+  g_byte_array_append(png, apng_signature, APNG_SIGNATURE_LEN);
+  apng_append_chunk(png, "IHDR", ihdr, APNG_IHDR_LEN);
+  g_byte_array_append(png, apng->shared->data, apng->shared->len);
+  g_byte_array_append(png, frame->data->data, frame->data->len);
+  apng_append_chunk(png, "IEND", NULL, 0);
+
+  size_t png_len = png->len;
+  VipsBlob *blob = vips_blob_new(
+      (VipsCallbackFn) vips_area_free_cb, g_byte_array_free(png, FALSE), png_len);
+
+  VipsSource *source = vips_source_new_from_blob(blob);
+  vips_area_unref(VIPS_AREA(blob));
+
+  if (!source)
+    return -1;
+
+  int res = vips_pngload_source(source, out, "unlimited", unlimited, NULL);
+
+  VIPS_UNREF(source);
+
+  return res;
+}
+
+/**
+ * Creates a transparent image of the given size with the same bands and format as the reference
+ */
+static int
+apng_transparent(VipsImage *ref, VipsImage **out, int width, int height)
+{
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 2);
+
+  if (vips_black(&t[0], width, height, "bands", ref->Bands, NULL) ||
+      vips_cast(t[0], &t[1], ref->BandFmt, NULL) ||
+      vips_copy(t[1], out, "interpretation", ref->Type, NULL)) {
+    VIPS_UNREF(base);
+    return -1;
+  }
+
+  VIPS_UNREF(base);
+
+  return 0;
+}
+
+/**
+ * Renders the frame onto the canvas.
+ * Returns the rendered frame and the canvas to render the next frame onto.
+ *
+ * If the frame is the last one to render, the canvas is not updated
+ * and the frame is not rendered to memory, so its pixels are decoded only when needed.
+ * This keeps loading of the first frame cheap, so the image dimensions can be checked
+ * before decoding any pixels.
+ */
+static int
+apng_render_frame(
+    Apng *apng, ApngFrame *frame, int index, gboolean last, VipsImage **canvas,
+    VipsImage **rendered, gboolean unlimited)
+{
+  APNG_fcTL *fctl = &frame->fctl;
+
+  if (fctl->width == 0 || fctl->height == 0 ||
+      fctl->x_offset > apng->width || fctl->width > apng->width - fctl->x_offset ||
+      fctl->y_offset > apng->height || fctl->height > apng->height - fctl->y_offset) {
+    vips_error("vips_apngload", "APNG frame is out of the canvas bounds");
+    return -1;
+  }
+
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 8);
+
+  VipsImage *img;
+
+  if (apng_load_frame(apng, frame, &t[0], unlimited))
+    goto error;
+
+  img = t[0];
+
+  if (img->Xsize != (int) fctl->width || img->Ysize != (int) fctl->height) {
+    vips_error("vips_apngload", "APNG frame has unexpected dimensions");
+    goto error;
+  }
+
+  // Render all the frames as RGBA so they can be composed together
+  if (img->Bands < 3) {
+    VipsInterpretation interpretation = img->BandFmt == VIPS_FORMAT_USHORT
+        ? VIPS_INTERPRETATION_RGB16
+        : VIPS_INTERPRETATION_sRGB;
+
+    if (vips_colourspace(img, &t[1], interpretation, NULL))
+      goto error;
+
+    img = t[1];
+  }
+
+  if (!vips_image_hasalpha(img)) {
+    if (vips_addalpha(img, &t[2], NULL))
+      goto error;
+
+    img = t[2];
+  }
+
+  // The canvas is fully transparent initially.
+  // Frames inherit metadata from the canvas, so we copy the colour profile to it
+  if (!*canvas) {
+    if (apng_transparent(img, canvas, apng->width, apng->height))
+      goto error;
+
+    const void *icc;
+    size_t icc_len;
+
+    if (vips_image_get_typeof(t[0], VIPS_META_ICC_NAME) &&
+        !vips_image_get_blob(t[0], VIPS_META_ICC_NAME, &icc, &icc_len))
+      vips_image_set_blob_copy(*canvas, VIPS_META_ICC_NAME, icc, icc_len);
+  }
+
+  // Save the frame area to restore it after rendering.
+  // If the first frame should be disposed to the previous state,
+  // it's disposed to the background instead
+  if (fctl->dispose_op == APNG_DISPOSE_OP_PREVIOUS && index > 0 && !last) {
+    if (vips_extract_area(*canvas, &t[3],
+            fctl->x_offset, fctl->y_offset, fctl->width, fctl->height, NULL))
+      goto error;
+  }
+
+  if (fctl->blend_op == APNG_BLEND_OP_OVER && index > 0) {
+    if (vips_composite2(*canvas, img, &t[4], VIPS_BLEND_MODE_OVER,
+            "x", fctl->x_offset,
+            "y", fctl->y_offset,
+            "compositing_space", (*canvas)->Type,
+            NULL) ||
+        vips_cast(t[4], &t[5], (*canvas)->BandFmt, NULL))
+      goto error;
+  }
+  else if (vips_insert(*canvas, img, &t[5], fctl->x_offset, fctl->y_offset, NULL))
+    goto error;
+
+  if (last) {
+    *rendered = t[5];
+    g_object_ref(*rendered);
+
+    VIPS_UNREF(base);
+
+    return 0;
+  }
+
+  // Render the frame to memory so we don't keep the whole chain of frames
+  if (!(*rendered = vips_image_copy_memory(t[5])))
+    goto error;
+
+  // Dispose the frame area
+  switch (fctl->dispose_op) {
+  case APNG_DISPOSE_OP_PREVIOUS:
+    if (t[3]) {
+      if (vips_insert(*rendered, t[3], &t[7], fctl->x_offset, fctl->y_offset, NULL))
+        goto error;
+      break;
+    }
+    // Fall through to the background disposal
+  case APNG_DISPOSE_OP_BACKGROUND:
+    if (apng_transparent(*rendered, &t[6], fctl->width, fctl->height) ||
+        vips_insert(*rendered, t[6], &t[7], fctl->x_offset, fctl->y_offset, NULL))
+      goto error;
+    break;
+  default:
+    t[7] = *rendered;
+    g_object_ref(t[7]);
+  }
+
+  VIPS_UNREF(*canvas);
+  *canvas = t[7];
+  g_object_ref(*canvas);
+
+  VIPS_UNREF(base);
+
+  return 0;
+
+error:
+  VIPS_UNREF(*rendered);
+  VIPS_UNREF(base);
+
+  return -1;
+}
+
+int
+vips_apngload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoadOptions lo)
+{
+  size_t len;
+  const uint8_t *buf = vips_source_map(VIPS_SOURCE(source), &len);
+  if (!buf)
+    return -1;
+
+  Apng apng = { 0 };
+
+  if (apng_parse(buf, len, &apng)) {
+    apng_clear(&apng);
+    return -1;
+  }
+
+  int n_frames = apng.frames->len;
+
+  if (lo.Page < 0 || lo.Page >= n_frames) {
+    vips_error("vips_apngload", "page out of range");
+    apng_clear(&apng);
+    return -1;
+  }
+
+  int n = n_frames - lo.Page;
+  if (lo.Pages > 0)
+    n = VIPS_MIN(n, lo.Pages);
+
+  VipsImage **frames = VIPS_ARRAY(NULL, n, VipsImage *);
+  int *delays = VIPS_ARRAY(NULL, n, int);
+  memset(frames, 0, n * sizeof(VipsImage *));
+
+  VipsImage *canvas = NULL;
+  VipsImage *joined = NULL;
+  int res = -1;
+
+  // Frames depend on the previous ones, so we need to render them from the very first one
+  for (int i = 0; i < lo.Page + n; i++) {
+    ApngFrame *frame = &g_array_index(apng.frames, ApngFrame, i);
+    VipsImage *rendered = NULL;
+
+    gboolean last = i == lo.Page + n - 1;
+
+    if (apng_render_frame(&apng, frame, i, last, &canvas, &rendered, lo.PngUnlimited))
+      goto cleanup;
+
+    if (i < lo.Page) {
+      VIPS_UNREF(rendered);
+      continue;
+    }
+
+    int den = frame->fctl.delay_den > 0 ? frame->fctl.delay_den : 100;
+
+    frames[i - lo.Page] = rendered;
+    delays[i - lo.Page] = VIPS_RINT(frame->fctl.delay_num * 1000.0 / den);
+  }
+
+  if (vips_arrayjoin(frames, &joined, n, "across", 1, NULL) ||
+      vips_copy(joined, out, NULL))
+    goto cleanup;
+
+  vips_image_set_int(*out, "page-height", apng.height);
+  vips_image_set_int(*out, "n-pages", n_frames);
+  vips_image_set_int(*out, "loop", apng.num_plays);
+  vips_image_set_array_int(*out, "delay", delays, n);
+
+  res = 0;
+
+cleanup:
+  for (int i = 0; i < n; i++)
+    VIPS_UNREF(frames[i]);
+
+  VIPS_UNREF(canvas);
+  VIPS_UNREF(joined);
+  VIPS_FREE(frames);
+  VIPS_FREE(delays);
+  apng_clear(&apng);
+
+  return res;
+}
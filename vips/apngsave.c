@@ -0,0 +1,183 @@
+// APNG saver
+//
+// libvips can't save APNG, so we save each frame as a standalone PNG
+// with the regular PNG saver, and combine their data into a single APNG.
+//
+// See: https://wiki.mozilla.org/APNG_Specification
+
+#include "vips.h"
+
+#include <stdlib.h>
+#include <stdint.h>
+#include <string.h>
+
+/**
+ * Appends the frame control chunk to the buffer
+ */
+static void
+apng_append_fctl(GByteArray *buf, uint32_t seq, int width, int height, int delay)
+{
+  APNG_fcTL fctl;
+
+  // Delay is stored as a fraction of two 16-bit numbers.
+  // Delays that don't fit into milliseconds are stored in hundredths of a second
+  uint16_t delay_num = delay;
+  uint16_t delay_den = 1000;
+
+  if (delay > UINT16_MAX) {
+    delay_num = VIPS_MIN(delay / 10, UINT16_MAX);
+    delay_den = 100;
+  }
+
+  fctl.sequence_number = GUINT32_TO_BE(seq);
+  fctl.width = GUINT32_TO_BE(width);
+  fctl.height = GUINT32_TO_BE(height);
+  fctl.x_offset = 0;
+  fctl.y_offset = 0;
+  fctl.delay_num = GUINT16_TO_BE(delay_num);
+  fctl.delay_den = GUINT16_TO_BE(delay_den);
+  fctl.dispose_op = APNG_DISPOSE_OP_NONE;
+  fctl.blend_op = APNG_BLEND_OP_SOURCE;
+
+  apng_append_chunk(buf, "fcTL", (const uint8_t *) &fctl, APNG_FCTL_LEN);
+}
+
+/**
+ * Saves the frame as PNG and appends its data to the APNG buffer.
+ * The first frame also provides the header and the metadata chunks.
+ */
+static int
+apng_append_frame(
+    GByteArray *buf, VipsImage *frame, int index, uint32_t *seq,
+    int n_frames, int loop, int delay, ImgproxySaveOptions opts)
+{
+  void *png;
+  size_t png_len;
+
+  // All the frames should share the same header,
+  // so we save them with the same explicit bit depth and without palette.
+  // APNG frames also share a single PLTE chunk, while quantizing each frame separately
+  // would produce a palette per frame. That's why APNG is never quantized,
+  // and PngQuantize and PngQuantizationColors options are ignored here.
+  if (vips_pngsave_buffer(
+          frame, &png, &png_len,
+          "filter", VIPS_FOREIGN_PNG_FILTER_ALL,
+          "interlace", opts.PngInterlaced,
+          "bitdepth", frame->BandFmt == VIPS_FORMAT_USHORT ? 16 : 8,
+          "palette", FALSE,
+          NULL))
+    return -1;
+
+  size_t offset = APNG_SIGNATURE_LEN;
+  APNG_Chunk chunk;
+  int res;
+  gboolean fctl_written = FALSE;
+
+  while ((res = apng_read_chunk(png, png_len, &offset, &chunk)) > 0) {
+    if (strcmp(chunk.type, "IEND") == 0)
+      break;
+
+    if (strcmp(chunk.type, "IDAT") != 0) {
+      // Only the first frame's header and metadata are kept
+      if (index > 0)
+        continue;
+
+      apng_append_chunk(buf, chunk.type, chunk.data, chunk.len);
+
+      if (strcmp(chunk.type, "IHDR") == 0) {
+        APNG_acTL actl = {
+          .num_frames = GUINT32_TO_BE(n_frames),
+          .num_plays = GUINT32_TO_BE(loop),
+        };
+
+        apng_append_chunk(buf, "acTL", (const uint8_t *) &actl, APNG_ACTL_LEN);
+      }
+
+      continue;
+    }
+
+    if (!fctl_written) {
+      apng_append_fctl(buf, (*seq)++, frame->Xsize, frame->Ysize, delay);
+      fctl_written = TRUE;
+    }
+
+    if (index == 0) {
+      apng_append_chunk(buf, "IDAT", chunk.data, chunk.len);
+      continue;
+    }
+
+    // fdAT is the same as IDAT but prefixed with the sequence number
+    uint8_t *fdat = g_malloc(chunk.len + 4);
+    apng_write_u32(fdat, (*seq)++);
+    memcpy(fdat + 4, chunk.data, chunk.len);
+
+    apng_append_chunk(buf, "fdAT", fdat, chunk.len + 4);
+
+    g_free(fdat);
+  }
+
+  g_free(png);
+
+  if (res < 0) {
+    vips_error("vips_apngsave", "PNG chunk is truncated");
+    return -1;
+  }
+
+  return 0;
+}
+
+int
+vips_apngsave_target_go(VipsImage *in, VipsTarget *target, ImgproxySaveOptions opts)
+{
+  int page_height = vips_image_get_page_height(in);
+  int n_frames = in->Ysize / page_height;
+
+  int *delay = NULL;
+  int n_delay = 0;
+  int loop = 0;
+
+  if (vips_image_get_typeof(in, "delay") &&
+      vips_image_get_array_int(in, "delay", &delay, &n_delay))
+    return -1;
+
+  if (vips_image_get_typeof(in, "loop") &&
+      vips_image_get_int(in, "loop", &loop))
+    return -1;
+
+  GByteArray *buf = g_byte_array_new();
+  g_byte_array_append(buf, apng_signature, APNG_SIGNATURE_LEN);
+
+  // Sequence number of fcTL and fdAT chunks
+  uint32_t seq = 0;
+
+  for (int i = 0; i < n_frames; i++) {
+    VipsImage *frame;
+
+    if (vips_extract_area(in, &frame, 0, i * page_height, in->Xsize, page_height, NULL)) {
+      g_byte_array_unref(buf);
+      return -1;
+    }
+
+    int frame_delay = i < n_delay ? delay[i] : 40;
+
+    if (apng_append_frame(buf, frame, i, &seq, n_frames, loop, frame_delay, opts)) {
+      VIPS_UNREF(frame);
+      g_byte_array_unref(buf);
+      return -1;
+    }
+
+    VIPS_UNREF(frame);
+  }
+
+  apng_append_chunk(buf, "IEND", NULL, 0);
+
+  if (vips_target_write(target, buf->data, buf->len) ||
+      vips_target_end(target)) {
+    g_byte_array_unref(buf);
+    return -1;
+  }
+
+  g_byte_array_unref(buf);
+
+  return 0;
+}
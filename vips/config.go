@@ -23,6 +23,7 @@ var (
 	IMGPROXY_PNG_UNLIMITED           = env.Bool("IMGPROXY_PNG_UNLIMITED")
 	IMGPROXY_SVG_UNLIMITED           = env.Bool("IMGPROXY_SVG_UNLIMITED")
 	IMGPROXY_TIFF_UNLIMITED          = env.Bool("IMGPROXY_TIFF_UNLIMITED")
+	IMGPROXY_PDF_DPI                 = env.Float("IMGPROXY_PDF_DPI")
 	IMGPROXY_VIPS_LEAK_CHECK         = env.Bool("IMGPROXY_VIPS_LEAK_CHECK")
 	IMGPROXY_VIPS_CACHE_TRACE        = env.Bool("IMGPROXY_VIPS_CACHE_TRACE")
 )
@@ -55,6 +56,9 @@ type Config struct {
 	// Whether to not apply any limits when loading TIFF
 	TiffUnlimited bool
 
+	// DPI to render PDF pages at
+	PdfDpi float64
+
 	// Whether to enable libvips memory leak check
 	LeakCheck bool
 	// Whether to enable libvips operation cache tracing
@@ -79,6 +83,8 @@ func NewDefaultConfig() Config {
 		SvgUnlimited:  false,
 		TiffUnlimited: false,
 
+		PdfDpi: 72,
+
 		LeakCheck:  false,
 		CacheTrace: false,
 	}
@@ -98,6 +104,7 @@ func LoadConfigFromEnv(c *Config) (*Config, error) {
 		IMGPROXY_PNG_UNLIMITED.Parse(&c.PngUnlimited),
 		IMGPROXY_SVG_UNLIMITED.Parse(&c.SvgUnlimited),
 		IMGPROXY_TIFF_UNLIMITED.Parse(&c.TiffUnlimited),
+		IMGPROXY_PDF_DPI.Parse(&c.PdfDpi),
 
 		IMGPROXY_WEBP_PRESET.Parse(&c.WebpPreset),
 		IMGPROXY_VIPS_LEAK_CHECK.Parse(&c.LeakCheck),
@@ -128,5 +135,9 @@ func (c *Config) Validate() error {
 		return IMGPROXY_WEBP_EFFORT.ErrorRange()
 	}
 
+	if c.PdfDpi <= 0 {
+		return IMGPROXY_PDF_DPI.ErrorZeroOrNegative()
+	}
+
 	return nil
 }
@@ -0,0 +1,58 @@
+package vips
+
+/*
+#include "vips.h"
+*/
+import "C"
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// Kernel represents the resampling kernel to use when resizing images
+type Kernel C.VipsKernel
+
+const (
+	KernelNearest  = C.VIPS_KERNEL_NEAREST
+	KernelLinear   = C.VIPS_KERNEL_LINEAR
+	KernelCubic    = C.VIPS_KERNEL_CUBIC
+	KernelMitchell = C.VIPS_KERNEL_MITCHELL
+	KernelLanczos2 = C.VIPS_KERNEL_LANCZOS2
+	KernelLanczos3 = C.VIPS_KERNEL_LANCZOS3
+)
+
+// Kernels maps string representations to Kernel values
+var Kernels = map[string]Kernel{
+	"nearest":  KernelNearest,
+	"linear":   KernelLinear,
+	"cubic":    KernelCubic,
+	"mitchell": KernelMitchell,
+	"lanczos2": KernelLanczos2,
+	"lanczos3": KernelLanczos3,
+}
+
+// C converts Kernel to C.VipsKernel
+func (k Kernel) C() C.VipsKernel {
+	return C.VipsKernel(k)
+}
+
+// String returns the string representation of the Kernel
+func (k Kernel) String() string {
+	for n, v := range Kernels {
+		if v == k {
+			return n
+		}
+	}
+	return "unknown"
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (k Kernel) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, k.String()), nil
+}
+
+// LogValue implements the slog.LogValuer interface
+func (k Kernel) LogValue() slog.Value {
+	return slog.StringValue(k.String())
+}
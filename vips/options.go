@@ -6,6 +6,7 @@ package vips
 import "C"
 import (
 	"github.com/imgproxy/imgproxy/v4/options"
+	"github.com/imgproxy/imgproxy/v4/options/keys"
 )
 
 func newLoadOptions(shrink float64, page, pages int) C.ImgproxyLoadOptions {
@@ -19,22 +20,38 @@ func newLoadOptions(shrink float64, page, pages int) C.ImgproxyLoadOptions {
 		PngUnlimited:  gbool(config.PngUnlimited),
 		SvgUnlimited:  gbool(config.SvgUnlimited),
 		TiffUnlimited: gbool(config.TiffUnlimited),
+
+		PdfDpi: C.double(config.PdfDpi),
 	}
 }
 
-func newSaveOptions(_ *options.Options) C.ImgproxySaveOptions {
+// newSaveOptions creates save options from the config.
+// Encoder options specified in the processing options override the config values.
+func newSaveOptions(o *options.Options) C.ImgproxySaveOptions {
+	o = o.Main()
+
+	// Format-specific lossless options override the common one
+	lossless := o.GetBool(keys.Lossless, false)
+	webpLossless := o.GetBool(keys.WebpLossless, lossless)
+
 	return C.ImgproxySaveOptions{
-		JpegProgressive: gbool(config.JpegProgressive),
+		JpegProgressive:  gbool(o.GetBool(keys.JpegProgressive, config.JpegProgressive)),
+		JpegNoSubsample:  gbool(o.GetBool(keys.JpegNoSubsample, false)),
+		JpegTrellisQuant: gbool(o.GetBool(keys.JpegTrellisQuant, false)),
 
-		PngInterlaced:         gbool(config.PngInterlaced),
-		PngQuantize:           gbool(config.PngQuantize),
-		PngQuantizationColors: C.int(config.PngQuantizationColors),
+		PngInterlaced:         gbool(o.GetBool(keys.PngInterlaced, config.PngInterlaced)),
+		PngQuantize:           gbool(o.GetBool(keys.PngQuantize, config.PngQuantize)),
+		PngQuantizationColors: C.int(o.GetInt(keys.PngQuantizationColors, config.PngQuantizationColors)),
 
-		WebpPreset: C.VipsForeignWebpPreset(config.WebpPreset),
-		WebpEffort: C.int(config.WebpEffort),
+		WebpPreset:       options.Get(o, keys.WebpPreset, config.WebpPreset).C(),
+		WebpEffort:       C.int(o.GetInt(keys.WebpEffort, config.WebpEffort)),
+		WebpLossless:     gbool(webpLossless),
+		WebpNearLossless: gbool(webpLossless && o.GetBool(keys.LosslessNear, false)),
 
-		AvifSpeed: C.int(config.AvifSpeed),
+		AvifSpeed:    C.int(o.GetInt(keys.AvifSpeed, config.AvifSpeed)),
+		AvifLossless: gbool(o.GetBool(keys.AvifLossless, lossless)),
 
-		JxlEffort: C.int(config.JxlEffort),
+		JxlEffort:   C.int(o.GetInt(keys.JxlEffort, config.JxlEffort)),
+		JxlLossless: gbool(o.GetBool(keys.JxlLossless, lossless)),
 	}
 }
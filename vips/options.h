@@ -10,10 +10,14 @@ typedef struct _ImgproxyLoadOptions {
   gboolean PngUnlimited;  // Whether to disable vips_pngload limits.
   gboolean SvgUnlimited;  // Whether to disable vips_svgload limits.
   gboolean TiffUnlimited; // Whether to disable vips_tiffload limits.
+
+  double PdfDpi; // DPI to render PDF at.
 } ImgproxyLoadOptions;
 
 typedef struct _ImgproxySaveOptions {
-  gboolean JpegProgressive; // Whether to save JPEG as progressive.
+  gboolean JpegProgressive;  // Whether to save JPEG as progressive.
+  gboolean JpegNoSubsample;  // Whether to disable JPEG chroma subsampling.
+  gboolean JpegTrellisQuant; // Whether to apply trellis quantization to JPEG.
 
   gboolean PngInterlaced;    // Whether to save PNG as interlaced.
   gboolean PngQuantize;      // Whether to quantize PNG (save with palette).
@@ -21,8 +25,12 @@ typedef struct _ImgproxySaveOptions {
 
   VipsForeignWebpPreset WebpPreset; // WebP preset to use.
   int WebpEffort;                   // WebP encoding effort level.
+  gboolean WebpLossless;            // Whether to save WebP as lossless.
+  gboolean WebpNearLossless;        // Whether to save WebP as near-lossless.
 
-  int AvifSpeed; // AVIF encoding speed.
+  int AvifSpeed;         // AVIF encoding speed.
+  gboolean AvifLossless; // Whether to save AVIF as lossless.
 
-  int JxlEffort; // JPEG XL encoding effort.
+  int JxlEffort;        // JPEG XL encoding effort.
+  gboolean JxlLossless; // Whether to save JPEG XL as lossless.
 } ImgproxySaveOptions;
@@ -4,11 +4,20 @@
 #define VIPS_SCRGB_ALPHA_FIXED \
   (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 15))
 
+#define VIPS_JXLSAVE_ANIMATION \
+  (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 15))
+
+#define VIPS_HEIFSAVE_ANIMATION \
+  (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 18))
+
 #define VIPS_META_PALETTE_BITS_DEPTH "palette-bit-depth"
 
 #define IMGPROXY_META_ICC_NAME "imgproxy-icc-profile"
 #define IMGPROXY_ICC_IMPORTED "imgproxy-icc-imported"
 
+// The blur fill is calculated at the size where the blur sigma is not larger than this
+#define EMBED_BLUR_MAX_SIGMA 2.0
+
 int
 vips_initialize()
 {
@@ -164,6 +173,7 @@ vips_heifload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoa
   return vips_heifload_source(
       VIPS_SOURCE(source), out,
       "access", VIPS_ACCESS_SEQUENTIAL,
+      "page", lo.Page,
       "thumbnail", lo.Thumbnail,
       NULL);
 }
@@ -180,6 +190,22 @@ vips_tiffload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoa
       NULL);
 }
 
+int
+vips_pdfload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoadOptions lo)
+{
+  if (check_shrink("vips_pdfload_source_go", lo.Shrink))
+    return -1;
+
+  return vips_pdfload_source(
+      VIPS_SOURCE(source), out,
+      "access", VIPS_ACCESS_SEQUENTIAL,
+      "page", lo.Page,
+      "n", lo.Pages,
+      "dpi", lo.PdfDpi,
+      "scale", 1.0 / lo.Shrink,
+      NULL);
+}
+
 int
 vips_black_go(VipsImage **out, int width, int height, int bands)
 {
@@ -408,10 +434,10 @@ vips_rad2float_go(VipsImage *in, VipsImage **out)
 }
 
 int
-vips_resize_go(VipsImage *in, VipsImage **out, double wscale, double hscale)
+vips_resize_go(VipsImage *in, VipsImage **out, double wscale, double hscale, VipsKernel kernel)
 {
   if (!vips_image_hasalpha(in))
-    return vips_resize(in, out, wscale, "vscale", hscale, NULL);
+    return vips_resize(in, out, wscale, "vscale", hscale, "kernel", kernel, NULL);
 
   VipsBandFormat format = vips_band_format(in);
 
@@ -421,7 +447,7 @@ vips_resize_go(VipsImage *in, VipsImage **out, double wscale, double hscale)
   int res =
       vips_premultiply(in, &t[0], NULL) ||
       vips_cast(t[0], &t[1], format, NULL) ||
-      vips_resize(t[1], &t[2], wscale, "vscale", hscale, NULL) ||
+      vips_resize(t[1], &t[2], wscale, "vscale", hscale, "kernel", kernel, NULL) ||
       vips_unpremultiply(t[2], &t[3], NULL) ||
       vips_cast(t[3], out, format, NULL);
 
@@ -771,6 +797,91 @@ vips_rot_go(VipsImage *in, VipsImage **out, VipsAngle angle)
   return vips_rot(in, out, angle, NULL);
 }
 
+int
+vips_rotate_free_go(VipsImage *in, VipsImage **out, double angle, gboolean crop)
+{
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 6);
+
+  int width = in->Xsize;
+  int height = in->Ysize;
+
+  // Exposed corners should be transparent unless we crop them out
+  if (!crop && !vips_image_hasalpha(in)) {
+    if (vips_addalpha(in, &t[0], NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+
+    in = t[0];
+  }
+
+  if (vips_image_hasalpha(in)) {
+    VipsBandFormat format = vips_band_format(in);
+
+    if (
+        vips_premultiply(in, &t[1], NULL) ||
+        vips_rotate(t[1], &t[2], angle, NULL) ||
+        vips_unpremultiply(t[2], &t[3], NULL) ||
+        vips_cast(t[3], &t[4], format, NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+  }
+  else if (vips_rotate(in, &t[4], angle, NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  if (!crop) {
+    int res = vips_copy(t[4], out, NULL);
+    VIPS_UNREF(base);
+    return res;
+  }
+
+  // Calculate the size of the largest axis-aligned rectangle
+  // that fits into the rotated image
+  double rad = angle * M_PI / 180.0;
+  double s = fabs(sin(rad));
+  double c = fabs(cos(rad));
+
+  double long_side = VIPS_MAX(width, height);
+  double short_side = VIPS_MIN(width, height);
+
+  double crop_width, crop_height;
+
+  if (short_side <= 2.0 * s * c * long_side || fabs(s - c) < 1e-10) {
+    // The rectangle touches the rotated image on two opposite corners
+    double x = 0.5 * short_side;
+
+    if (width >= height) {
+      crop_width = x / s;
+      crop_height = x / c;
+    }
+    else {
+      crop_width = x / c;
+      crop_height = x / s;
+    }
+  }
+  else {
+    double cos2a = c * c - s * s;
+
+    crop_width = (width * c - height * s) / cos2a;
+    crop_height = (height * c - width * s) / cos2a;
+  }
+
+  // Cut off a pixel from each side to get rid of the antialiased edges
+  int cw = VIPS_CLIP(1, (int) floor(crop_width) - 2, t[4]->Xsize);
+  int ch = VIPS_CLIP(1, (int) floor(crop_height) - 2, t[4]->Ysize);
+
+  int res = vips_extract_area(
+      t[4], out, (t[4]->Xsize - cw) / 2, (t[4]->Ysize - ch) / 2, cw, ch, NULL);
+
+  VIPS_UNREF(base);
+
+  return res;
+}
+
 int
 vips_flip_horizontal_go(VipsImage *in, VipsImage **out)
 {
@@ -888,6 +999,382 @@ vips_apply_filters(VipsImage *in, VipsImage **out, double blur_sigma,
   return res;
 }
 
+int
+vips_apply_adjustments(VipsImage *in, VipsImage **out, int brightness,
+    double contrast, double saturation, double gamma)
+{
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 11);
+
+  VipsInterpretation interpretation = in->Type;
+  VipsBandFormat format = in->BandFmt;
+  VipsImage *alpha = NULL;
+
+  if (vips_guard_colorspace(in, &t[0], FALSE)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  in = t[0];
+
+  /* We don't want to adjust the alpha channel, so we extract it
+   * and join it back after all the adjustments
+   */
+  if (vips_image_hasalpha(in)) {
+    if (
+        vips_extract_band(in, &t[1], 0, "n", in->Bands - 1, NULL) ||
+        vips_extract_band(in, &t[2], in->Bands - 1, "n", 1, NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+
+    in = t[1];
+    alpha = t[2];
+  }
+
+  VipsInterpretation work_interpretation = vips_image_guess_interpretation(in);
+  VipsBandFormat work_format = in->BandFmt;
+  double max_value = image_depth(in) == 16 ? 65535.0 : 255.0;
+
+  /* Saturation is adjusted in the LCh colorspace by scaling the chroma.
+   * Grayscale images have no chroma, so we skip them
+   */
+  if (saturation != 1.0 && in->Bands >= 3) {
+    double a[3] = { 1.0, saturation, 1.0 };
+    double b[3] = { 0.0, 0.0, 0.0 };
+
+    if (
+        vips_colourspace(in, &t[3], VIPS_INTERPRETATION_LCH, NULL) ||
+        vips_linear(t[3], &t[4], a, b, 3, NULL) ||
+        vips_colourspace(t[4], &t[5], work_interpretation, NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+
+    in = t[5];
+  }
+
+  /* Brightness and contrast are applied as a single linear transformation
+   * around the middle of the value range
+   */
+  if (brightness != 0 || contrast != 1.0) {
+    double a = contrast;
+    double b = brightness * max_value / 255.0 + (max_value + 1.0) / 2.0 * (1.0 - contrast);
+
+    if (
+        vips_linear1(in, &t[6], a, b, NULL) ||
+        vips_cast(t[6], &t[7], work_format, NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+
+    in = t[7];
+  }
+
+  if (gamma != 1.0) {
+    if (
+        vips_gamma(in, &t[8], "exponent", gamma, NULL) ||
+        vips_cast(t[8], &t[9], work_format, NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+
+    in = t[9];
+  }
+
+  if (alpha) {
+    if (vips_bandjoin2(in, alpha, &t[10], NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+
+    in = t[10];
+  }
+
+  VipsImage *tmp = NULL;
+
+  int res =
+      vips_colourspace(in, &tmp, interpretation, NULL) ||
+      vips_cast(tmp, out, format, NULL);
+
+  VIPS_UNREF(tmp);
+  VIPS_UNREF(base);
+
+  return res;
+}
+
+static int
+vips_luminance_prepare(VipsImage *in, VipsImage **out)
+{
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 3);
+
+  // We compare luminance only, 8 bits per pixel is enough for that
+  if (
+      vips_colourspace(in, &t[0], VIPS_INTERPRETATION_B_W, NULL) ||
+      vips_extract_band(t[0], &t[1], 0, NULL) ||
+      vips_cast(t[1], &t[2], VIPS_FORMAT_UCHAR, "shift", image_depth(t[1]) == 16, NULL) ||
+      vips_cast(t[2], out, VIPS_FORMAT_FLOAT, NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  VIPS_UNREF(base);
+  return 0;
+}
+
+int
+vips_dssim_go(VipsImage *in1, VipsImage *in2, double *out)
+{
+  // SSIM constants for 8-bit images: (0.01 * 255)^2 and (0.03 * 255)^2
+  const double c1 = 6.5025;
+  const double c2 = 58.5225;
+  const double sigma = 1.5;
+
+  if (in1->Xsize != in2->Xsize || in1->Ysize != in2->Ysize) {
+    vips_error("vips_dssim_go", "images have different dimensions");
+    return 1;
+  }
+
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 25);
+
+  double ssim;
+
+  if (
+      vips_luminance_prepare(in1, &t[0]) ||
+      vips_luminance_prepare(in2, &t[1]) ||
+      // Means
+      vips_gaussblur(t[0], &t[2], sigma, NULL) ||
+      vips_gaussblur(t[1], &t[3], sigma, NULL) ||
+      // Squares and product
+      vips_multiply(t[0], t[0], &t[4], NULL) ||
+      vips_multiply(t[1], t[1], &t[5], NULL) ||
+      vips_multiply(t[0], t[1], &t[6], NULL) ||
+      vips_multiply(t[2], t[2], &t[7], NULL) ||
+      vips_multiply(t[3], t[3], &t[8], NULL) ||
+      vips_multiply(t[2], t[3], &t[9], NULL) ||
+      // Variances and covariance
+      vips_gaussblur(t[4], &t[10], sigma, NULL) ||
+      vips_gaussblur(t[5], &t[11], sigma, NULL) ||
+      vips_gaussblur(t[6], &t[12], sigma, NULL) ||
+      vips_subtract(t[10], t[7], &t[13], NULL) ||
+      vips_subtract(t[11], t[8], &t[14], NULL) ||
+      vips_subtract(t[12], t[9], &t[15], NULL) ||
+      // Numerator: (2 * mu1 * mu2 + c1) * (2 * sigma12 + c2)
+      vips_linear1(t[9], &t[16], 2.0, c1, NULL) ||
+      vips_linear1(t[15], &t[17], 2.0, c2, NULL) ||
+      vips_multiply(t[16], t[17], &t[18], NULL) ||
+      // Denominator: (mu1^2 + mu2^2 + c1) * (sigma1^2 + sigma2^2 + c2)
+      vips_add(t[7], t[8], &t[19], NULL) ||
+      vips_linear1(t[19], &t[20], 1.0, c1, NULL) ||
+      vips_add(t[13], t[14], &t[21], NULL) ||
+      vips_linear1(t[21], &t[22], 1.0, c2, NULL) ||
+      vips_multiply(t[20], t[22], &t[23], NULL) ||
+      // SSIM map
+      vips_divide(t[18], t[23], &t[24], NULL) ||
+      vips_avg(t[24], &ssim, NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  VIPS_UNREF(base);
+
+  *out = ssim > 0 ? 1.0 / ssim - 1.0 : G_MAXDOUBLE;
+
+  return 0;
+}
+
+int
+vips_complexity_go(VipsImage *in, double *out)
+{
+  // We need at least two pixels in each dimension to calculate gradients
+  if (in->Xsize < 2 || in->Ysize < 2) {
+    *out = 0.0;
+    return 0;
+  }
+
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 9);
+
+  double dx, dy;
+
+  if (
+      vips_luminance_prepare(in, &t[0]) ||
+      // Horizontal gradient
+      vips_extract_area(t[0], &t[1], 1, 0, t[0]->Xsize - 1, t[0]->Ysize, NULL) ||
+      vips_extract_area(t[0], &t[2], 0, 0, t[0]->Xsize - 1, t[0]->Ysize, NULL) ||
+      vips_subtract(t[1], t[2], &t[3], NULL) ||
+      vips_abs(t[3], &t[4], NULL) ||
+      vips_avg(t[4], &dx, NULL) ||
+      // Vertical gradient
+      vips_extract_area(t[0], &t[5], 0, 1, t[0]->Xsize, t[0]->Ysize - 1, NULL) ||
+      vips_extract_area(t[0], &t[6], 0, 0, t[0]->Xsize, t[0]->Ysize - 1, NULL) ||
+      vips_subtract(t[5], t[6], &t[7], NULL) ||
+      vips_abs(t[7], &t[8], NULL) ||
+      vips_avg(t[8], &dy, NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  VIPS_UNREF(base);
+
+  *out = dx + dy;
+
+  return 0;
+}
+
+int
+vips_rgba_pixels_go(VipsImage *in, int max_size, void **buf, size_t *len, int *width, int *height)
+{
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 4);
+
+  double scale = VIPS_MIN(1.0, (double) max_size / VIPS_MAX(in->Xsize, in->Ysize));
+
+  if (
+      vips_resize_go(in, &t[0], scale, scale, VIPS_KERNEL_LANCZOS3) ||
+      vips_colourspace(t[0], &t[1], VIPS_INTERPRETATION_sRGB, NULL) ||
+      vips_cast(t[1], &t[2], VIPS_FORMAT_UCHAR, NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  int res = vips_image_hasalpha(t[2]) ?
+      vips_copy(t[2], &t[3], NULL) :
+      vips_addalpha(t[2], &t[3], NULL);
+
+  if (res) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  if (t[3]->Bands != 4) {
+    vips_error("vips_rgba_pixels_go", "unexpected number of bands: %d", t[3]->Bands);
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  if (!(*buf = vips_image_write_to_memory(t[3], len))) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  *width = t[3]->Xsize;
+  *height = t[3]->Ysize;
+
+  VIPS_UNREF(base);
+  return 0;
+}
+
+/* Calculates the signed distance from the pixel center to the mask shape edge.
+ * Negative values are inside the shape.
+ */
+static double
+vips_mask_distance(MaskShape shape, double x, double y, double w, double h, double radius)
+{
+  double dx = x - w / 2.0;
+  double dy = y - h / 2.0;
+
+  switch (shape) {
+  case MASK_SHAPE_CIRCLE:
+    return sqrt(dx * dx + dy * dy) - VIPS_MIN(w, h) / 2.0;
+
+  case MASK_SHAPE_ELLIPSE: {
+    double rx = w / 2.0;
+    double ry = h / 2.0;
+    double k = sqrt((dx * dx) / (rx * rx) + (dy * dy) / (ry * ry));
+
+    if (k == 0.0)
+      return -VIPS_MIN(rx, ry);
+
+    // First-order approximation of the distance to the ellipse
+    double grad = sqrt((dx * dx) / (rx * rx * rx * rx) + (dy * dy) / (ry * ry * ry * ry)) / k;
+
+    return (k - 1.0) / grad;
+  }
+
+  default: {
+    double qx = fabs(dx) - (w / 2.0 - radius);
+    double qy = fabs(dy) - (h / 2.0 - radius);
+
+    return sqrt(VIPS_SQR(VIPS_MAX(qx, 0.0)) + VIPS_SQR(VIPS_MAX(qy, 0.0))) +
+        VIPS_MIN(VIPS_MAX(qx, qy), 0.0) - radius;
+  }
+  }
+}
+
+int
+vips_mask_go(VipsImage *in, VipsImage **out, MaskShape shape, double radius)
+{
+  int width = in->Xsize;
+  int height = in->Ysize;
+  // Animated images are stored as frames stacked vertically,
+  // so the mask should be applied to each frame separately
+  int page_height = vips_image_get_page_height(in);
+
+  radius = VIPS_CLIP(0.0, radius, VIPS_MIN(width, page_height) / 2.0);
+
+  unsigned char *buf = g_try_malloc((size_t) width * height);
+  if (!buf) {
+    vips_error("vips_mask_go", "can't allocate mask");
+    return 1;
+  }
+
+  // Calculate the antialiased shape coverage of each pixel of the first frame
+  for (int y = 0; y < page_height; y++) {
+    for (int x = 0; x < width; x++) {
+      double d = vips_mask_distance(shape, x + 0.5, y + 0.5, width, page_height, radius);
+      buf[y * width + x] = (unsigned char) (VIPS_CLIP(0.0, 0.5 - d, 1.0) * 255.0 + 0.5);
+    }
+  }
+
+  // Copy the mask to the rest of the frames
+  size_t page_size = (size_t) width * page_height;
+  for (int page = 1; page < height / page_height; page++)
+    memcpy(buf + page * page_size, buf, page_size);
+
+  VipsImage *mask = vips_image_new_from_memory_copy(
+      buf, (size_t) width * height, width, height, 1, VIPS_FORMAT_UCHAR);
+
+  g_free(buf);
+
+  if (!mask)
+    return 1;
+
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 7);
+
+  t[0] = mask;
+
+  if (vips_image_hasalpha(in)) {
+    if (vips_copy(in, &t[1], NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+  }
+  else if (vips_addalpha(in, &t[1], NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  VipsBandFormat format = vips_band_format(t[1]);
+
+  if (
+      vips_extract_band(t[1], &t[2], 0, "n", t[1]->Bands - 1, NULL) ||
+      vips_extract_band(t[1], &t[3], t[1]->Bands - 1, NULL) ||
+      vips_linear1(t[0], &t[4], 1.0 / 255.0, 0, NULL) ||
+      vips_multiply(t[3], t[4], &t[5], NULL) ||
+      vips_cast(t[5], &t[6], format, NULL) ||
+      vips_bandjoin2(t[2], t[6], out, NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  VIPS_UNREF(base);
+  return 0;
+}
+
 int
 vips_flatten_go(VipsImage *in, VipsImage **out, RGB bg)
 {
@@ -1065,6 +1552,174 @@ vips_embed_go(VipsImage *in, VipsImage **out, int x, int y, int width, int heigh
   return ret;
 }
 
+int
+vips_embed_mirror_go(VipsImage *in, VipsImage **out, int x, int y, int width, int height)
+{
+  return vips_embed(in, out, x, y, width, height, "extend", VIPS_EXTEND_MIRROR, NULL);
+}
+
+int
+vips_embed_edge_go(VipsImage *in, VipsImage **out, int x, int y, int width, int height)
+{
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 8);
+
+  int bands = in->Bands;
+
+  // Extract one-pixel strips along each side of the image
+  if (
+      vips_extract_area(in, &t[0], 0, 0, in->Xsize, 1, NULL) ||
+      vips_extract_area(in, &t[1], 0, in->Ysize - 1, in->Xsize, 1, NULL) ||
+      vips_extract_area(in, &t[2], 0, 0, 1, in->Ysize, NULL) ||
+      vips_extract_area(in, &t[3], in->Xsize - 1, 0, 1, in->Ysize, NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  double *bg = g_new0(double, bands);
+  double total = 0;
+
+  // Calculate the average color of the edges weighted by the strip size
+  for (int i = 0; i < 4; i++) {
+    if (vips_stats(t[i], &t[i + 4], NULL)) {
+      g_free(bg);
+      VIPS_UNREF(base);
+      return 1;
+    }
+
+    double pixels = t[i]->Xsize * t[i]->Ysize;
+
+    for (int b = 0; b < bands; b++)
+      bg[b] += *VIPS_MATRIX(t[i + 4], 4, b + 1) * pixels;
+
+    total += pixels;
+  }
+
+  for (int b = 0; b < bands; b++)
+    bg[b] /= total;
+
+  VipsArrayDouble *bga = vips_array_double_new(bg, bands);
+
+  int res = vips_embed(in, out, x, y, width, height,
+      "extend", VIPS_EXTEND_BACKGROUND, "background", bga, NULL);
+
+  vips_area_unref((VipsArea *) bga);
+  g_free(bg);
+  VIPS_UNREF(base);
+
+  return res;
+}
+
+int
+vips_embed_blur_go(VipsImage *in, VipsImage **out, int x, int y, int width, int height,
+    double sigma)
+{
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 7);
+
+  // The fill is heavily blurred, so it doesn't need high resolution.
+  // We blur a downscaled copy and upscale it to the canvas size so the blur cost
+  // doesn't depend on the canvas size
+  double shrink = VIPS_MIN(1.0, EMBED_BLUR_MAX_SIGMA / sigma);
+  int blur_width = VIPS_MAX(1, VIPS_RINT(width * shrink));
+  int blur_height = VIPS_MAX(1, VIPS_RINT(height * shrink));
+
+  // Scale the image copy to cover the downscaled canvas
+  double scale = VIPS_MAX((double) blur_width / in->Xsize, (double) blur_height / in->Ysize);
+
+  if (vips_resize_go(in, &t[0], scale, scale, VIPS_KERNEL_LANCZOS3)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  int left = VIPS_MAX(0, (t[0]->Xsize - blur_width) / 2);
+  int top = VIPS_MAX(0, (t[0]->Ysize - blur_height) / 2);
+
+  if (
+      vips_extract_area(t[0], &t[1], left, top,
+          VIPS_MIN(blur_width, t[0]->Xsize), VIPS_MIN(blur_height, t[0]->Ysize), NULL) ||
+      vips_embed(t[1], &t[2], 0, 0, blur_width, blur_height, "extend", VIPS_EXTEND_COPY, NULL) ||
+      vips_gaussblur(t[2], &t[3], sigma * shrink, NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  // Upscale the blurred copy to the canvas size.
+  // The upscaled image can be a pixel off due to rounding, so we embed it
+  // to get the exact canvas size
+  if (blur_width != width || blur_height != height) {
+    if (
+        vips_resize_go(t[3], &t[4],
+            (double) width / blur_width, (double) height / blur_height, VIPS_KERNEL_LINEAR) ||
+        vips_embed(t[4], &t[5], 0, 0, width, height, "extend", VIPS_EXTEND_COPY, NULL)) {
+      VIPS_UNREF(base);
+      return 1;
+    }
+  }
+  else if (vips_copy(t[3], &t[5], NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  int res;
+
+  if (vips_image_hasalpha(in)) {
+    res = vips_composite2(t[5], in, &t[6], VIPS_BLEND_MODE_OVER, "x", x, "y", y, NULL) ||
+        vips_cast(t[6], out, vips_image_get_format(in), NULL);
+  }
+  else {
+    res = vips_insert(t[5], in, out, x, y, NULL);
+  }
+
+  VIPS_UNREF(base);
+
+  return res;
+}
+
+int
+vips_text_go(VipsImage **out, const char *text, const char *font, int width, int height, RGB color)
+{
+  VipsImage *base = vips_image_new();
+  VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 4);
+
+  // vips_text treats the text as Pango markup, so we need to escape it
+  char *escaped = g_markup_escape_text(text, -1);
+
+  int res;
+
+  // If both width and height are set, vips_text adjusts the font size to fit the area
+  if (width > 0 && height > 0)
+    res = vips_text(&t[0], escaped, "font", font, "width", width, "height", height, NULL);
+  else
+    res = vips_text(&t[0], escaped, "font", font, "dpi", 72, NULL);
+
+  g_free(escaped);
+
+  if (res) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  // vips_text renders a one-band mask. We create a solid color image of the same size
+  // and use the mask as its alpha channel
+  double zeros[3] = { 0.0, 0.0, 0.0 };
+  double rgb[3] = { color.r, color.g, color.b };
+
+  if (
+      vips_black(&t[1], t[0]->Xsize, t[0]->Ysize, "bands", 3, NULL) ||
+      vips_linear(t[1], &t[2], zeros, rgb, 3, "uchar", TRUE, NULL) ||
+      vips_bandjoin2(t[2], t[0], &t[3], NULL)) {
+    VIPS_UNREF(base);
+    return 1;
+  }
+
+  res = vips_copy(t[3], out, "interpretation", VIPS_INTERPRETATION_sRGB, NULL);
+
+  VIPS_UNREF(base);
+
+  return res;
+}
+
 int
 vips_apply_watermark(VipsImage *in, VipsImage *watermark, VipsImage **out, int left, int top, double opacity)
 {
@@ -1242,9 +1897,17 @@ vips_jpegsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOpt
       "Q", quality,
       "optimize_coding", TRUE,
       "interlace", opts.JpegProgressive,
+      "subsample_mode", opts.JpegNoSubsample ? VIPS_FOREIGN_SUBSAMPLE_OFF : VIPS_FOREIGN_SUBSAMPLE_AUTO,
+      "trellis_quant", opts.JpegTrellisQuant,
       NULL);
 }
 
+gboolean
+vips_jxlsave_supports_animation()
+{
+  return VIPS_JXLSAVE_ANIMATION;
+}
+
 int
 vips_jxlsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOptions opts)
 {
@@ -1252,6 +1915,7 @@ vips_jxlsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOpti
       in, target,
       "Q", quality,
       "effort", opts.JxlEffort,
+      "lossless", opts.JxlLossless,
       NULL);
 }
 
@@ -1305,6 +1969,8 @@ vips_webpsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOpt
       "Q", quality,
       "effort", opts.WebpEffort,
       "preset", opts.WebpPreset,
+      "lossless", opts.WebpLossless,
+      "near_lossless", opts.WebpNearLossless,
       NULL);
 }
 
@@ -1334,6 +2000,12 @@ vips_heifsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOpt
       NULL);
 }
 
+gboolean
+vips_heifsave_supports_animation()
+{
+  return VIPS_HEIFSAVE_ANIMATION;
+}
+
 int
 vips_avifsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOptions opts)
 {
@@ -1342,6 +2014,7 @@ vips_avifsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOpt
       "Q", quality,
       "compression", VIPS_FOREIGN_HEIF_COMPRESSION_AV1,
       "effort", 9 - opts.AvifSpeed,
+      "lossless", opts.AvifLossless,
       NULL);
 }
 
@@ -168,6 +168,8 @@ func SupportsLoad(it imagetype.Type) bool {
 		sup = hasOperation("heifload_source")
 	case imagetype.TIFF:
 		sup = hasOperation("tiffload_source")
+	case imagetype.PDF:
+		sup = hasOperation("pdfload_source")
 	}
 
 	typeSupportLoad.Store(it, sup)
@@ -208,6 +210,23 @@ func SupportsSave(it imagetype.Type) bool {
 	return sup
 }
 
+// SupportsAnimationSave checks if the image type supports saving animations
+// and the used libvips version can save it as animated
+func SupportsAnimationSave(it imagetype.Type) bool {
+	if !it.SupportsAnimationSave() {
+		return false
+	}
+
+	switch it {
+	case imagetype.JXL:
+		return C.vips_jxlsave_supports_animation() != 0
+	case imagetype.AVIF:
+		return C.vips_heifsave_supports_animation() != 0
+	}
+
+	return true
+}
+
 func GifResolutionLimit() int {
 	return gifResolutionLimit
 }
@@ -276,7 +295,11 @@ func (img *Image) Load(
 	case imagetype.JXL:
 		err = C.vips_jxlload_source_go(source, &tmp, lo)
 	case imagetype.PNG:
-		err = C.vips_pngload_source_go(source, &tmp, lo)
+		if isAnimatedPNG(imgdata) {
+			err = C.vips_apngload_source_go(source, &tmp, lo)
+		} else {
+			err = C.vips_pngload_source_go(source, &tmp, lo)
+		}
 	case imagetype.WEBP:
 		err = C.vips_webpload_source_go(source, &tmp, lo)
 	case imagetype.GIF:
@@ -291,6 +314,8 @@ func (img *Image) Load(
 		err = C.vips_bmpload_source_go(source, &tmp, lo)
 	case imagetype.ICO:
 		err = C.vips_icoload_source_go(source, &tmp, lo)
+	case imagetype.PDF:
+		err = C.vips_pdfload_source_go(source, &tmp, lo)
 	default:
 		return newVipsError("Usupported image type to load")
 	}
@@ -311,6 +336,13 @@ func (img *Image) Load(
 	return nil
 }
 
+// isAnimatedPNG checks if the image data is an animated PNG.
+// Detection errors are ignored here, the PNG loader will report them.
+func isAnimatedPNG(imgdata imagedata.ImageData) bool {
+	animated, err := imagetype.IsAnimatedPNG(imgdata.Reader())
+	return err == nil && animated
+}
+
 func (img *Image) LoadThumbnail(imgdata imagedata.ImageData) error {
 	if imgdata.Format() != imagetype.HEIC && imgdata.Format() != imagetype.AVIF {
 		return newVipsError("Usupported image type to load thumbnail")
@@ -355,7 +387,11 @@ func (img *Image) Save(
 	case imagetype.JXL:
 		err = C.vips_jxlsave_go(img.VipsImage, target, C.int(quality), so)
 	case imagetype.PNG:
-		err = C.vips_pngsave_go(img.VipsImage, target, so)
+		if img.IsAnimated() {
+			err = C.vips_apngsave_target_go(img.VipsImage, target, so)
+		} else {
+			err = C.vips_pngsave_go(img.VipsImage, target, so)
+		}
 	case imagetype.WEBP:
 		err = C.vips_webpsave_go(img.VipsImage, target, C.int(quality), so)
 	case imagetype.GIF:
@@ -528,6 +564,48 @@ func (img *Image) GetBlob(name string) ([]byte, error) {
 	return C.GoBytes(tmp, C.int(size)), nil
 }
 
+// ExifFields returns EXIF tags parsed by libvips as a map of tag names to their
+// string representations
+func (img *Image) ExifFields() map[string]string {
+	fields := C.vips_image_get_fields(img.VipsImage)
+	defer C.g_strfreev(fields)
+
+	res := make(map[string]string)
+
+	for p := fields; *p != nil; p = (**C.gchar)(unsafe.Add(unsafe.Pointer(p), unsafe.Sizeof(*p))) {
+		name := (*C.char)(unsafe.Pointer(*p))
+
+		// EXIF fields are named like "exif-ifd0-Orientation"
+		ifdTag, found := strings.CutPrefix(C.GoString(name), "exif-ifd")
+		if !found {
+			continue
+		}
+
+		_, tag, found := strings.Cut(ifdTag, "-")
+		if !found {
+			continue
+		}
+
+		var cValue *C.char
+		if C.vips_image_get_as_string(img.VipsImage, name, &cValue) != 0 {
+			continue
+		}
+
+		value := C.GoString(cValue)
+		C.g_free(C.gpointer(cValue))
+
+		// libvips appends the raw value description in parentheses:
+		// "Canon (Canon, ASCII, 6 components, 6 bytes)". We don't need it.
+		if i := strings.LastIndex(value, " ("); i >= 0 {
+			value = value[:i]
+		}
+
+		res[tag] = value
+	}
+
+	return res
+}
+
 func (img *Image) SetInt(name string, value int) {
 	C.vips_image_set_int(img.VipsImage, cachedCString(name), C.int(value))
 }
@@ -575,10 +653,10 @@ func (img *Image) Rad2Float() error {
 	return nil
 }
 
-func (img *Image) Resize(wscale, hscale float64) error {
+func (img *Image) Resize(wscale, hscale float64, kernel Kernel) error {
 	var tmp *C.VipsImage
 
-	if C.vips_resize_go(img.VipsImage, &tmp, C.double(wscale), C.double(hscale)) != 0 {
+	if C.vips_resize_go(img.VipsImage, &tmp, C.double(wscale), C.double(hscale), kernel.C()) != 0 {
 		return Error()
 	}
 
@@ -610,6 +688,20 @@ func (img *Image) Rotate(angle int) error {
 	return nil
 }
 
+// RotateFree rotates the image clockwise by an arbitrary angle in degrees.
+// Exposed corners are made transparent. If crop is true, the image is cropped
+// to the largest rectangle that doesn't contain the exposed corners instead.
+func (img *Image) RotateFree(angle float64, crop bool) error {
+	var tmp *C.VipsImage
+
+	if C.vips_rotate_free_go(img.VipsImage, &tmp, C.double(angle), gbool(crop)) != 0 {
+		return Error()
+	}
+
+	img.swapAndUnref(tmp)
+	return nil
+}
+
 func (img *Image) FlipHorizontal() error {
 	var tmp *C.VipsImage
 
@@ -666,6 +758,15 @@ func (img *Image) Extract(out *Image, left, top, width, height int) error {
 	return nil
 }
 
+// Copy creates a copy of the image in out.
+// The copy shares pixels with the image but has its own metadata.
+func (img *Image) Copy(out *Image) error {
+	if C.vips_copy_go(img.VipsImage, &out.VipsImage) != 0 {
+		return Error()
+	}
+	return nil
+}
+
 func (img *Image) SmartCrop(width, height int) error {
 	var tmp *C.VipsImage
 
@@ -704,6 +805,76 @@ func (img *Image) Flatten(bg color.RGB) error {
 	return nil
 }
 
+// MaskRoundedRect makes the image corners transparent using the rounded rectangle mask
+// with the given corner radius
+func (img *Image) MaskRoundedRect(radius float64) error {
+	return img.mask(C.MASK_SHAPE_ROUNDED_RECT, radius)
+}
+
+// MaskCircle makes everything outside the inscribed circle transparent
+func (img *Image) MaskCircle() error {
+	return img.mask(C.MASK_SHAPE_CIRCLE, 0)
+}
+
+// MaskEllipse makes everything outside the inscribed ellipse transparent
+func (img *Image) MaskEllipse() error {
+	return img.mask(C.MASK_SHAPE_ELLIPSE, 0)
+}
+
+func (img *Image) mask(shape C.MaskShape, radius float64) error {
+	var tmp *C.VipsImage
+
+	if C.vips_mask_go(img.VipsImage, &tmp, shape, C.double(radius)) != 0 {
+		return Error()
+	}
+	img.swapAndUnref(tmp)
+
+	return nil
+}
+
+// DSSIM calculates the structural dissimilarity between the image and another image
+// of the same size. 0 means the images are identical.
+func (img *Image) DSSIM(other *Image) (float64, error) {
+	var dssim C.double
+
+	if C.vips_dssim_go(img.VipsImage, other.VipsImage, &dssim) != 0 {
+		return 0, Error()
+	}
+
+	return float64(dssim), nil
+}
+
+// Complexity estimates the visual complexity of the image as the mean
+// luminance gradient. Flat graphics have low complexity, while photos
+// and noisy images have high complexity.
+func (img *Image) Complexity() (float64, error) {
+	var complexity C.double
+
+	if C.vips_complexity_go(img.VipsImage, &complexity) != 0 {
+		return 0, Error()
+	}
+
+	return float64(complexity), nil
+}
+
+// RGBAPixels returns the image pixels as 8-bit sRGB with alpha,
+// downscaled to fit maxSize x maxSize if needed.
+// It returns the pixels along with the width and height of the downscaled image.
+func (img *Image) RGBAPixels(maxSize int) ([]byte, int, int, error) {
+	var (
+		buf           unsafe.Pointer
+		size          C.size_t
+		width, height C.int
+	)
+
+	if C.vips_rgba_pixels_go(img.VipsImage, C.int(maxSize), &buf, &size, &width, &height) != 0 {
+		return nil, 0, 0, Error()
+	}
+	defer C.g_free_go(&buf)
+
+	return C.GoBytes(buf, C.int(size)), int(width), int(height), nil
+}
+
 func (img *Image) ApplyFilters(blurSigma, sharpSigma float64, pixelatePixels int) error {
 	var tmp *C.VipsImage
 
@@ -716,6 +887,24 @@ func (img *Image) ApplyFilters(blurSigma, sharpSigma float64, pixelatePixels int
 	return nil
 }
 
+// Adjust changes brightness, contrast, saturation and gamma of the image.
+// Brightness is added to the pixel values, while contrast and saturation are multipliers
+// where 1.0 means no change. Gamma greater than 1.0 brightens the image.
+func (img *Image) Adjust(brightness int, contrast, saturation, gamma float64) error {
+	var tmp *C.VipsImage
+
+	if C.vips_apply_adjustments(
+		img.VipsImage, &tmp,
+		C.int(brightness), C.double(contrast), C.double(saturation), C.double(gamma),
+	) != 0 {
+		return Error()
+	}
+
+	img.swapAndUnref(tmp)
+
+	return nil
+}
+
 // Type returns the current colorspace interpretation of the image.
 func (img *Image) Type() Interpretation {
 	return Interpretation(img.VipsImage.Type)
@@ -757,6 +946,11 @@ func (img *Image) RestoreColourProfile() {
 	}
 }
 
+// HasEmbeddedColourProfile returns true if the image has an embedded ICC profile
+func (img *Image) HasEmbeddedColourProfile() bool {
+	return C.vips_has_embedded_icc(img.VipsImage) != 0
+}
+
 func (img *Image) ImportColourProfile() error {
 	var tmp *C.VipsImage
 
@@ -864,6 +1058,69 @@ func (img *Image) Embed(width, height int, offX, offY int) error {
 	return nil
 }
 
+// EmbedMirror places the image on the canvas of the given size
+// and fills the rest of the canvas with the mirrored image edges
+func (img *Image) EmbedMirror(width, height int, offX, offY int) error {
+	var tmp *C.VipsImage
+
+	if C.vips_embed_mirror_go(img.VipsImage, &tmp, C.int(offX), C.int(offY), C.int(width), C.int(height)) != 0 {
+		return Error()
+	}
+	img.swapAndUnref(tmp)
+
+	return nil
+}
+
+// EmbedEdge places the image on the canvas of the given size
+// and fills the rest of the canvas with the average color of the image edges
+func (img *Image) EmbedEdge(width, height int, offX, offY int) error {
+	var tmp *C.VipsImage
+
+	if C.vips_embed_edge_go(img.VipsImage, &tmp, C.int(offX), C.int(offY), C.int(width), C.int(height)) != 0 {
+		return Error()
+	}
+	img.swapAndUnref(tmp)
+
+	return nil
+}
+
+// EmbedBlur places the image on the canvas of the given size
+// and fills the rest of the canvas with the blurred image copy scaled to cover the canvas
+func (img *Image) EmbedBlur(width, height int, offX, offY int, sigma float64) error {
+	var tmp *C.VipsImage
+
+	if C.vips_embed_blur_go(
+		img.VipsImage, &tmp,
+		C.int(offX), C.int(offY), C.int(width), C.int(height),
+		C.double(sigma),
+	) != 0 {
+		return Error()
+	}
+	img.swapAndUnref(tmp)
+
+	return nil
+}
+
+// Text renders the text with the given Pango font description and color
+// into an RGBA image. If both width and height are positive,
+// the font size is adjusted so the text fits the given area.
+func (img *Image) Text(text, font string, width, height int, c color.RGB) error {
+	cText := C.CString(text)
+	defer C.free(unsafe.Pointer(cText))
+
+	cFont := C.CString(font)
+	defer C.free(unsafe.Pointer(cFont))
+
+	var tmp *C.VipsImage
+
+	if C.vips_text_go(&tmp, cText, cFont, C.int(width), C.int(height), cRGB(c)) != 0 {
+		return Error()
+	}
+	img.swapAndUnref(tmp)
+
+	return nil
+}
+
 func (img *Image) ApplyWatermark(wm *Image, left, top int, opacity float64) error {
 	var tmp *C.VipsImage
 
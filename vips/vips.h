@@ -10,6 +10,7 @@
 #include "source.h"
 #include "bmp.h"
 #include "ico.h"
+#include "apng.h"
 
 typedef struct _RGB {
   double r;
@@ -17,6 +18,12 @@ typedef struct _RGB {
   double b;
 } RGB;
 
+typedef enum {
+  MASK_SHAPE_ROUNDED_RECT,
+  MASK_SHAPE_CIRCLE,
+  MASK_SHAPE_ELLIPSE,
+} MaskShape;
+
 int vips_initialize();
 
 void unref_image(VipsImage *in);
@@ -34,6 +41,7 @@ int vips_gifload_source_go(VipsImgproxySource *source, VipsImage **out, Imgproxy
 int vips_svgload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoadOptions lo);
 int vips_heifload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoadOptions lo);
 int vips_tiffload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoadOptions lo);
+int vips_pdfload_source_go(VipsImgproxySource *source, VipsImage **out, ImgproxyLoadOptions lo);
 
 int vips_black_go(VipsImage **out, int width, int height, int bands);
 
@@ -56,7 +64,7 @@ int vips_copy_go(VipsImage *in, VipsImage **out);
 int vips_cast_go(VipsImage *in, VipsImage **out, VipsBandFormat format);
 int vips_rad2float_go(VipsImage *in, VipsImage **out);
 
-int vips_resize_go(VipsImage *in, VipsImage **out, double wscale, double hscale);
+int vips_resize_go(VipsImage *in, VipsImage **out, double wscale, double hscale, VipsKernel kernel);
 
 int vips_icc_is_srgb_iec61966(VipsImage *in);
 int vips_has_embedded_icc(VipsImage *in);
@@ -69,6 +77,7 @@ int vips_icc_remove(VipsImage *in, VipsImage **out);
 int vips_colourspace_go(VipsImage *in, VipsImage **out, VipsInterpretation cs);
 
 int vips_rot_go(VipsImage *in, VipsImage **out, VipsAngle angle);
+int vips_rotate_free_go(VipsImage *in, VipsImage **out, double angle, gboolean crop);
 int vips_flip_horizontal_go(VipsImage *in, VipsImage **out);
 int vips_flip_vertical_go(VipsImage *in, VipsImage **out);
 
@@ -79,10 +88,27 @@ int vips_trim(VipsImage *in, VipsImage **out, double threshold, gboolean smart,
 int vips_apply_filters(VipsImage *in, VipsImage **out, double blur_sigma, double sharp_sigma,
     int pixelate_pixels);
 
+int vips_apply_adjustments(VipsImage *in, VipsImage **out, int brightness, double contrast,
+    double saturation, double gamma);
+
 int vips_flatten_go(VipsImage *in, VipsImage **out, RGB bg);
 
+int vips_mask_go(VipsImage *in, VipsImage **out, MaskShape shape, double radius);
+
+int vips_dssim_go(VipsImage *in1, VipsImage *in2, double *out);
+int vips_complexity_go(VipsImage *in, double *out);
+
+int vips_rgba_pixels_go(VipsImage *in, int max_size, void **buf, size_t *len, int *width,
+    int *height);
+
 int vips_replicate_go(VipsImage *in, VipsImage **out, int across, int down, int centered);
 int vips_embed_go(VipsImage *in, VipsImage **out, int x, int y, int width, int height);
+int vips_embed_mirror_go(VipsImage *in, VipsImage **out, int x, int y, int width, int height);
+int vips_embed_edge_go(VipsImage *in, VipsImage **out, int x, int y, int width, int height);
+int vips_embed_blur_go(VipsImage *in, VipsImage **out, int x, int y, int width, int height,
+    double sigma);
+
+int vips_text_go(VipsImage **out, const char *text, const char *font, int width, int height, RGB color);
 
 int vips_apply_watermark(VipsImage *in, VipsImage *watermark, VipsImage **out, int left, int top,
     double opacity);
@@ -95,11 +121,13 @@ int vips_strip(VipsImage *in, VipsImage **out, int keep_exif_copyright);
 int vips_strip_all(VipsImage *in, VipsImage **out);
 
 int vips_jpegsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOptions opts);
+gboolean vips_jxlsave_supports_animation();
 int vips_jxlsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOptions opts);
 int vips_pngsave_go(VipsImage *in, VipsTarget *target, ImgproxySaveOptions opts);
 int vips_webpsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOptions opts);
 int vips_gifsave_go(VipsImage *in, VipsTarget *target, ImgproxySaveOptions opts);
 int vips_heifsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOptions opts);
+gboolean vips_heifsave_supports_animation();
 int vips_avifsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOptions opts);
 int vips_tiffsave_go(VipsImage *in, VipsTarget *target, int quality, ImgproxySaveOptions opts);
 